@@ -0,0 +1,120 @@
+package slack
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+)
+
+// Ack is Slack's acknowledgement of a message the bot sent. ID is the ID the
+// bot gave the message on its RTM connection (messages sent with the Web API
+// have none), and TS is the timestamp Slack gave the message.
+type Ack struct {
+	ID string
+	TS string
+}
+
+type ackResult struct {
+	ack Ack
+	err error
+}
+
+// SendAndWait sends message like Send, and waits for Slack to acknowledge it.
+// It returns an error if Slack rejects the message, if the connection closes
+// before Slack acknowledges it, or if ctx is done first.
+func (bot *Bot) SendAndWait(ctx context.Context, message *Message) (Ack, error) {
+	message.acks = make(chan ackResult, 1)
+	select {
+	case bot.outgoing <- message:
+	case <-ctx.Done():
+		return Ack{}, ctx.Err()
+	}
+	select {
+	case result := <-message.acks:
+		return result.ack, result.err
+	case <-ctx.Done():
+		return Ack{}, ctx.Err()
+	}
+}
+
+// acknowledge tells whoever is waiting on the message how sending it went.
+func (m *Message) acknowledge(ack Ack, err error) {
+	if m.acks == nil {
+		return
+	}
+	select {
+	case m.acks <- ackResult{ack, err}:
+	default:
+	}
+}
+
+// acknowledged resolves the pending message event acknowledges, if it is an
+// acknowledgement. Rejected messages are logged.
+func (bot *Bot) acknowledged(event map[string]interface{}) {
+	replyTo, ok := event["reply_to"]
+	if !ok || event["type"] != nil {
+		return
+	}
+	id := fmt.Sprint(replyTo)
+	message := bot.connection.resolve(id)
+	if message == nil {
+		return
+	}
+	if ok, _ := event["ok"].(bool); ok {
+		ts, _ := event["ts"].(string)
+		message.acknowledge(Ack{ID: id, TS: ts}, nil)
+		return
+	}
+	reason := event["error"]
+	if details, ok := reason.(map[string]interface{}); ok {
+		reason = details["msg"]
+	}
+	err := &Error{fmt.Sprintf("Slack rejected message %s: %v", id, reason)}
+	bot.Log().Warn("Message was rejected.", Fields{
+		"channel": message.channel,
+		"error":   err,
+	})
+	message.acknowledge(Ack{ID: id}, err)
+}
+
+// failPending fails every message still waiting to be acknowledged.
+func (bot *Bot) failPending() {
+	for _, message := range bot.connection.drain() {
+		message.acknowledge(Ack{ID: message.id}, &Error{"connection closed before Slack acknowledged message " + message.id})
+	}
+}
+
+// send gives message the next ID on the connection, and notes that it is
+// waiting for Slack to acknowledge it.
+func (c *connection) send(message *Message) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.lastID++
+	message.id = strconv.Itoa(c.lastID)
+	if c.pending == nil {
+		c.pending = make(map[string]*Message)
+	}
+	c.pending[message.id] = message
+}
+
+// resolve returns the pending message with the given ID, if there is one, and
+// stops waiting for it.
+func (c *connection) resolve(id string) *Message {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	message := c.pending[id]
+	delete(c.pending, id)
+	return message
+}
+
+// drain returns every pending message, and stops waiting for them.
+func (c *connection) drain() []*Message {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	messages := make([]*Message, 0, len(c.pending))
+	for _, message := range c.pending {
+		messages = append(messages, message)
+	}
+	c.pending = nil
+	return messages
+}
@@ -0,0 +1,91 @@
+package slack
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPrivate_connection_send(t *testing.T) {
+	c := &connection{}
+	now := time.Now()
+	c.connected(now)
+	first, second := NewMessage("a", "C1"), NewMessage("b", "C1")
+	c.send(first)
+	ping := c.ping(now)
+	c.send(second)
+	assert(first.id == "1" && ping["id"] == 2 && second.id == "3", t)
+	state, _, _, _, _ := c.snapshot()
+	assert(state.Unacknowledged == 2, t)
+
+	assert(c.resolve("1") == first && c.resolve("1") == nil, t)
+	c.disconnected()
+	c.connected(now)
+	third := NewMessage("c", "C1")
+	c.send(third)
+	assert(third.id == "1", t)
+}
+
+func TestPrivate_acknowledged(t *testing.T) {
+	var tests = []struct {
+		event    map[string]interface{}
+		resolved bool
+		ts       string
+		err      string
+	}{
+		{map[string]interface{}{"ok": true, "reply_to": float64(1), "ts": "1.0"}, true, "1.0", ""},
+		{map[string]interface{}{"ok": true, "reply_to": "1", "ts": "1.0"}, true, "1.0", ""},
+		{
+			map[string]interface{}{"ok": false, "reply_to": float64(1), "error": map[string]interface{}{"code": float64(2), "msg": "message text is missing"}},
+			true, "", "Slack rejected message 1: message text is missing",
+		},
+		{map[string]interface{}{"type": "pong", "reply_to": float64(1)}, false, "", ""},
+		{map[string]interface{}{"ok": true, "reply_to": float64(2), "ts": "1.0"}, false, "", ""},
+	}
+
+	for i, test := range tests {
+		bot := NewBot("token")
+		message := NewMessage("text", "C1")
+		message.acks = make(chan ackResult, 1)
+		bot.connection.send(message)
+		bot.acknowledged(test.event)
+		select {
+		case result := <-message.acks:
+			if !test.resolved {
+				t.Errorf("Error. Expected case %d not to acknowledge the message. Got %v.", i, result)
+			} else if result.ack.ID != "1" || result.ack.TS != test.ts {
+				t.Errorf("Error. Expected case %d to acknowledge 1 at %q. Got %v.", i, test.ts, result.ack)
+			} else if (result.err == nil) != (test.err == "") || (result.err != nil && result.err.Error() != test.err) {
+				t.Errorf("Error. Expected case %d to fail with %q. Got %v.", i, test.err, result.err)
+			}
+		default:
+			if test.resolved {
+				t.Errorf("Error. Expected case %d to acknowledge the message.", i)
+			}
+		}
+	}
+}
+
+func TestPrivate_failPending(t *testing.T) {
+	bot := NewBot("token")
+	message := NewMessage("text", "C1")
+	message.acks = make(chan ackResult, 1)
+	bot.connection.send(message)
+	bot.failPending()
+	result := <-message.acks
+	if result.err == nil || !strings.Contains(result.err.Error(), "connection closed") {
+		t.Errorf("Error. Expected the message to fail. Got %v.", result.err)
+	}
+	state, _, _, _, _ := bot.connection.snapshot()
+	assert(state.Unacknowledged == 0, t)
+}
+
+func TestSendAndWait_canceled(t *testing.T) {
+	bot := NewBot("token")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := bot.SendAndWait(ctx, NewMessage("text", "C1")); err != context.DeadlineExceeded {
+		t.Errorf("Error. Expected the wait to time out. Got %v.", err)
+	}
+}
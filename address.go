@@ -0,0 +1,152 @@
+package slack
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Addressed reports whether the "message" event is addressed to the bot. If it
+// is, the text of the message is returned with the portion that addressed the
+// bot removed.
+//
+// A message is addressed to the bot if it begins with one of the bot's
+// Prefixes (e.g. "!deploy"), begins with the bot's name or one of its Aliases
+// (optionally preceded by an "@" and followed by ":" or ","), begins with or
+// contains a mention of the bot's ID (either "<@ID>" or "<@ID|name>"), or is
+// sent in a direct message channel with the bot. The bot's own messages are
+// never addressed to it, so that it cannot answer itself.
+//
+// Addressed works purely on strings, so the bot's Name, ID, Aliases and
+// Prefixes may change at any time (such as when the bot connects) without
+// anything needing to be recompiled.
+func (bot *Bot) Addressed(event map[string]interface{}) (string, bool) {
+	text, ok := event["text"].(string)
+	if !ok {
+		return "", false
+	}
+	if user, _ := event["user"].(string); user != "" && user == bot.ID {
+		return "", false
+	}
+	if rest, ok := bot.trimMention(text); ok {
+		return rest, true
+	}
+	channel, _ := event["channel"].(string)
+	if isDirectMessageChannel(channel) {
+		return strings.TrimSpace(text), true
+	}
+	if rest, ok := bot.removeInlineMention(text); ok {
+		return rest, true
+	}
+	return "", false
+}
+
+func isDirectMessageChannel(channel string) bool {
+	return strings.HasPrefix(channel, "D")
+}
+
+// trimMention removes a leading prefix, name, alias or ID mention from text.
+func (bot *Bot) trimMention(text string) (string, bool) {
+	text = strings.TrimLeftFunc(text, unicode.IsSpace)
+	for _, prefix := range bot.Prefixes {
+		if prefix == "" || !strings.HasPrefix(text, prefix) {
+			continue
+		}
+		rest := strings.TrimLeftFunc(text[len(prefix):], unicode.IsSpace)
+		if rest != "" {
+			return rest, true
+		}
+	}
+	if end, ok := bot.idMentionEnd(text, 0); ok {
+		return afterMention(text[end:])
+	}
+	text = strings.TrimPrefix(text, "@")
+	for _, name := range bot.names() {
+		if len(text) < len(name) || !strings.EqualFold(text[:len(name)], name) {
+			continue
+		}
+		if rest, ok := afterMention(text[len(name):]); ok {
+			return rest, true
+		}
+	}
+	return "", false
+}
+
+// removeInlineMention removes the first "<@ID>" mention of the bot from
+// anywhere in text.
+func (bot *Bot) removeInlineMention(text string) (string, bool) {
+	if bot.ID == "" {
+		return "", false
+	}
+	token := "<@" + bot.ID
+	offset := 0
+	for {
+		i := strings.Index(text[offset:], token)
+		if i < 0 {
+			return "", false
+		}
+		start := offset + i
+		if end, ok := bot.idMentionEnd(text, start); ok {
+			before := strings.TrimRightFunc(text[:start], unicode.IsSpace)
+			after := strings.TrimLeft(text[end:], ":,")
+			after = strings.TrimLeftFunc(after, unicode.IsSpace)
+			if before != "" && after != "" {
+				return before + " " + after, true
+			}
+			return before + after, true
+		}
+		offset = start + len(token)
+	}
+}
+
+// idMentionEnd returns the index just past a "<@ID>" or "<@ID|name>" mention
+// of the bot which begins at start in text.
+func (bot *Bot) idMentionEnd(text string, start int) (int, bool) {
+	if bot.ID == "" {
+		return 0, false
+	}
+	token := "<@" + bot.ID
+	if !strings.HasPrefix(text[start:], token) {
+		return 0, false
+	}
+	end := start + len(token)
+	if end >= len(text) {
+		return 0, false
+	}
+	switch text[end] {
+	case '>':
+		return end + 1, true
+	case '|':
+		closing := strings.IndexByte(text[end:], '>')
+		if closing < 0 {
+			return 0, false
+		}
+		return end + closing + 1, true
+	}
+	return 0, false
+}
+
+// afterMention returns the text following a mention, provided the mention is
+// followed by optional punctuation and then whitespace or the end of the text.
+func afterMention(rest string) (string, bool) {
+	rest = strings.TrimLeft(rest, ":,")
+	if rest == "" {
+		return "", true
+	}
+	if !unicode.IsSpace(rune(rest[0])) {
+		return "", false
+	}
+	return strings.TrimLeftFunc(rest, unicode.IsSpace), true
+}
+
+func (bot *Bot) names() []string {
+	names := make([]string, 0, len(bot.Aliases)+1)
+	if bot.Name != "" {
+		names = append(names, bot.Name)
+	}
+	for _, alias := range bot.Aliases {
+		if alias != "" {
+			names = append(names, alias)
+		}
+	}
+	return names
+}
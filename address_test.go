@@ -0,0 +1,61 @@
+package slack
+
+import (
+	"testing"
+)
+
+func TestAddressed(t *testing.T) {
+	var tests = []struct {
+		text, channel, user string
+		expectedText        string
+		expectedOk          bool
+	}{
+		{"testbot hello", "C1", "U2", "hello", true},
+		{"testbot: hello", "C1", "U2", "hello", true},
+		{"@testbot, hello", "C1", "U2", "hello", true},
+		{"TestBot hello", "C1", "U2", "hello", true},
+		{"testbot\nhello", "C1", "U2", "hello", true},
+		{"  testbot hello", "C1", "U2", "hello", true},
+		{"testbothello", "C1", "U2", "", false},
+		{"tb: hello", "C1", "U2", "hello", true},
+		{"<@U1> hello", "C1", "U2", "hello", true},
+		{"<@U1>: hello", "C1", "U2", "hello", true},
+		{"<@U1|testbot> hello", "C1", "U2", "hello", true},
+		{"hey <@U1> deploy prod", "C1", "U2", "hey deploy prod", true},
+		{"deploy prod <@U1>", "C1", "U2", "deploy prod", true},
+		{"<@U2> hello", "C1", "U2", "", false},
+		{"!deploy prod", "C1", "U2", "deploy prod", true},
+		{"!", "C1", "U2", "", false},
+		{"hello", "C1", "U2", "", false},
+		{"hello", "D1", "U2", "hello", true},
+		{"testbot hello", "D1", "U2", "hello", true},
+		{"hello", "D1", "U1", "", false},
+		{"testbot hello", "C1", "U1", "", false},
+	}
+
+	bot := NewBot("token")
+	bot.Name = "testbot"
+	bot.ID = "U1"
+	bot.Aliases = []string{"tb"}
+	bot.Prefixes = []string{"!"}
+	for _, test := range tests {
+		event := map[string]interface{}{
+			"text":    test.text,
+			"channel": test.channel,
+			"user":    test.user,
+		}
+		text, ok := bot.Addressed(event)
+		if ok != test.expectedOk || text != test.expectedText {
+			t.Errorf("Error. Expected (%q, %v) for %q from %s in %s. Got (%q, %v).",
+				test.expectedText, test.expectedOk, test.text, test.user, test.channel,
+				text, ok)
+		}
+	}
+}
+
+func TestAddressedNoText(t *testing.T) {
+	bot := NewBot("token")
+	bot.Name = "testbot"
+	_, ok := bot.Addressed(map[string]interface{}{"channel": "D1"})
+	assert(!ok, t)
+}
@@ -1,34 +1,120 @@
 package slack
 
 import (
+	"context"
 	"encoding/json"
-	"fmt"
 	"io/ioutil"
 	"net/http"
 	"net/url"
+	"strconv"
+	"strings"
+	"time"
 )
 
-const (
-	slackURL = "https://slack.com/api/%s"
-)
+// DefaultAPIURL is the base URL of the Slack Web API, which bots call unless
+// their APIURL is changed.
+const DefaultAPIURL = "https://slack.com/api/"
+
+// defaultRetryAfter is how long to wait before retrying a rate limited call,
+// if Slack does not say.
+const defaultRetryAfter = time.Second
 
 // Call calls a Slack API method, setting the token of bot in the method call
-// parameters.
+// parameters. If Slack rate limits the call, Call does not retry it: it
+// returns a payload whose "error" is "rate_limited", and whose "retry_after"
+// is how many seconds Slack asked to wait, so that handlers on the bot's read
+// loop are never held up waiting.
 func (bot *Bot) Call(method string, data url.Values) (map[string]interface{}, error) {
+	return bot.CallContext(context.Background(), method, data)
+}
+
+// CallContext is Call, traced as a child of the span carried by ctx. Handlers
+// pass the context of the event they are handling (see Context), so that their
+// calls are part of the event's trace. The request is cancelled if ctx is done
+// before Slack responds, and CallContext returns its error.
+func (bot *Bot) CallContext(ctx context.Context, method string, data url.Values) (map[string]interface{}, error) {
+	_, span := bot.StartSpan(ctx, "slack.call")
+	defer span.End()
+	span.SetAttribute("slack.method", method)
+	payload, err := bot.call(ctx, method, data)
+	status := callStatus(payload, err)
+	span.SetAttribute("slack.status", status)
+	if err != nil {
+		span.RecordError(err)
+	} else if status != "ok" {
+		span.RecordError(&Error{status})
+	}
+	return payload, err
+}
+
+func (bot *Bot) call(ctx context.Context, method string, data url.Values) (map[string]interface{}, error) {
 	data.Set("token", bot.Token)
-	return callAPI(method, data)
+	start := time.Now()
+	response, err := callAPI(ctx, bot.APIURL, method, data)
+	if err == nil && response.StatusCode == http.StatusTooManyRequests {
+		response.Body.Close()
+		wait := retryAfter(response)
+		bot.metrics().APICalled(method, "rate_limited", time.Since(start))
+		bot.metrics().RateLimited(method, wait)
+		bot.Log().Warn("Rate limited by Slack.", Fields{
+			"method": method,
+			"wait":   wait,
+		})
+		return map[string]interface{}{
+			"ok":          false,
+			"error":       "rate_limited",
+			"retry_after": wait.Seconds(),
+		}, nil
+	}
+	payload, err := httpToJSON(response, err)
+	bot.metrics().APICalled(method, callStatus(payload, err), time.Since(start))
+	return payload, err
 }
 
-func callAPI(method string, data url.Values) (map[string]interface{}, error) {
-	methodURL := fmt.Sprintf(slackURL, method)
-	response, err := http.PostForm(methodURL, data)
-	return httpToJSON(response, err)
+func callAPI(ctx context.Context, apiURL, method string, data url.Values) (*http.Response, error) {
+	if apiURL == "" {
+		apiURL = DefaultAPIURL
+	}
+	if !strings.HasSuffix(apiURL, "/") {
+		apiURL += "/"
+	}
+	request, err := http.NewRequest("POST", apiURL+method, strings.NewReader(data.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return http.DefaultClient.Do(request.WithContext(ctx))
+}
+
+// retryAfter returns how long Slack asked to wait before retrying a rate
+// limited call.
+func retryAfter(response *http.Response) time.Duration {
+	seconds, err := strconv.Atoi(response.Header.Get("Retry-After"))
+	if err != nil || seconds < 0 {
+		return defaultRetryAfter
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// callStatus returns the status of a Web API call for Metrics.
+func callStatus(payload map[string]interface{}, err error) string {
+	if err != nil {
+		return "failed"
+	}
+	if ok, _ := payload["ok"].(bool); ok {
+		return "ok"
+	}
+	if reason, ok := payload["error"].(string); ok && reason != "" {
+		return reason
+	}
+	return "failed"
 }
 
 func httpToJSON(response *http.Response, err error) (map[string]interface{}, error) {
 	if err != nil {
 		return nil, err
 	}
+	defer response.Body.Close()
 	body, err := ioutil.ReadAll(response.Body)
 	if err != nil {
 		return nil, err
@@ -0,0 +1,363 @@
+/*
+Package blocks provides typed builders for Slack's Block Kit, which is used to
+lay out rich messages. See https://api.slack.com/block-kit for an overview.
+
+Blocks are plain structs, which are converted to the JSON Slack expects when
+they are encoded:
+
+	msg := slack.NewMessage("Deploy finished", channel).WithBlocks(
+		&blocks.Header{Text: blocks.PlainText("Deploy finished")},
+		&blocks.Section{Text: blocks.Markdown("*api* is now at `v1.2.3`")},
+		&blocks.Divider{},
+		&blocks.Actions{Elements: []blocks.Element{
+			&blocks.Button{Text: blocks.PlainText("Roll back"), ActionID: "rollback", Style: blocks.Danger},
+		}},
+	)
+
+Slack documents a number of limits on blocks (the number of blocks in a
+message, the length of text fields, etc). Validate checks blocks against these
+limits, so that mistakes are caught before Slack rejects the message. Encode
+validates blocks and returns them in the form expected by the "blocks"
+parameter of any Web API method which accepts them:
+
+	encoded, err := blocks.Encode(myBlocks...)
+	if err != nil {
+		return err
+	}
+	params.Set("blocks", encoded)
+*/
+package blocks
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+const (
+	// MaxBlocks is the maximum number of blocks in a single message.
+	MaxBlocks  = 50
+	maxBlockID = 255
+)
+
+// Block is a single Block Kit layout block.
+//
+// Type should return the block's "type" in Block Kit, and Validate should
+// return an error if the block violates any of Slack's limits.
+type Block interface {
+	Type() string
+	Validate() error
+}
+
+// Error describes a block which violates one of Slack's limits. Field is the
+// path to the offending field, such as "blocks[1].fields".
+type Error struct {
+	Field   string
+	Message string
+}
+
+func (err *Error) Error() string {
+	if err.Field == "" {
+		return err.Message
+	}
+	return fmt.Sprintf("%s: %s", err.Field, err.Message)
+}
+
+// Validate checks each of blocks against Slack's limits, and returns an Error
+// describing the first violation, if any.
+func Validate(blocks ...Block) error {
+	if len(blocks) > MaxBlocks {
+		return &Error{"blocks", fmt.Sprintf("at most %d blocks are allowed", MaxBlocks)}
+	}
+	for i, block := range blocks {
+		if block == nil {
+			return &Error{fmt.Sprintf("blocks[%d]", i), "block is nil"}
+		}
+		if err := block.Validate(); err != nil {
+			return within(fmt.Sprintf("blocks[%d]", i), err)
+		}
+	}
+	return nil
+}
+
+// Encode validates blocks, and then encodes them as a JSON array.
+func Encode(blocks ...Block) (string, error) {
+	if err := Validate(blocks...); err != nil {
+		return "", err
+	}
+	data, err := json.Marshal(blocks)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// Section displays text, optionally alongside up to 10 fields of text and an
+// accessory element. At least one of Text and Fields is required.
+type Section struct {
+	BlockID   string  `json:"block_id,omitempty"`
+	Text      *Text   `json:"text,omitempty"`
+	Fields    []*Text `json:"fields,omitempty"`
+	Accessory Element `json:"accessory,omitempty"`
+}
+
+// Type returns "section".
+func (block *Section) Type() string { return "section" }
+
+// Validate checks the section against Slack's limits.
+func (block *Section) Validate() error {
+	if block.Text == nil && len(block.Fields) == 0 {
+		return &Error{"text", "one of text or fields is required"}
+	}
+	if err := validateBlockID(block.BlockID); err != nil {
+		return err
+	}
+	if err := validateText("text", block.Text, 3000, false); err != nil {
+		return err
+	}
+	if len(block.Fields) > 10 {
+		return &Error{"fields", "at most 10 fields are allowed"}
+	}
+	for i, field := range block.Fields {
+		name := fmt.Sprintf("fields[%d]", i)
+		if field == nil {
+			return &Error{name, "field is nil"}
+		}
+		if err := validateText(name, field, 2000, false); err != nil {
+			return err
+		}
+	}
+	return validateElement("accessory", block.Accessory)
+}
+
+// MarshalJSON encodes the section, including its type.
+func (block *Section) MarshalJSON() ([]byte, error) {
+	type section Section
+	return marshalTyped(block.Type(), (*section)(block))
+}
+
+// Divider is a horizontal rule between blocks.
+type Divider struct {
+	BlockID string `json:"block_id,omitempty"`
+}
+
+// Type returns "divider".
+func (block *Divider) Type() string { return "divider" }
+
+// Validate checks the divider against Slack's limits.
+func (block *Divider) Validate() error {
+	return validateBlockID(block.BlockID)
+}
+
+// MarshalJSON encodes the divider, including its type.
+func (block *Divider) MarshalJSON() ([]byte, error) {
+	type divider Divider
+	return marshalTyped(block.Type(), (*divider)(block))
+}
+
+// Context displays up to 10 small pieces of text and images.
+type Context struct {
+	BlockID  string           `json:"block_id,omitempty"`
+	Elements []ContextElement `json:"elements"`
+}
+
+// ContextElement is an element which may be displayed in a Context block.
+// Only *Text and *ImageElement are context elements.
+type ContextElement interface {
+	contextElement()
+}
+
+// Type returns "context".
+func (block *Context) Type() string { return "context" }
+
+// Validate checks the context against Slack's limits.
+func (block *Context) Validate() error {
+	if err := validateBlockID(block.BlockID); err != nil {
+		return err
+	}
+	if len(block.Elements) == 0 || len(block.Elements) > 10 {
+		return &Error{"elements", "between 1 and 10 elements are required"}
+	}
+	for i, element := range block.Elements {
+		name := fmt.Sprintf("elements[%d]", i)
+		switch e := element.(type) {
+		case *Text:
+			if err := validateText(name, e, 3000, false); err != nil {
+				return err
+			}
+		case *ImageElement:
+			if err := validateElement(name, e); err != nil {
+				return err
+			}
+		default:
+			return &Error{name, "element is nil"}
+		}
+	}
+	return nil
+}
+
+// MarshalJSON encodes the context, including its type.
+func (block *Context) MarshalJSON() ([]byte, error) {
+	type context Context
+	return marshalTyped(block.Type(), (*context)(block))
+}
+
+// Actions holds up to 25 interactive elements, such as buttons and selects.
+type Actions struct {
+	BlockID  string    `json:"block_id,omitempty"`
+	Elements []Element `json:"elements"`
+}
+
+// Type returns "actions".
+func (block *Actions) Type() string { return "actions" }
+
+// Validate checks the actions against Slack's limits.
+func (block *Actions) Validate() error {
+	if err := validateBlockID(block.BlockID); err != nil {
+		return err
+	}
+	if len(block.Elements) == 0 || len(block.Elements) > 25 {
+		return &Error{"elements", "between 1 and 25 elements are required"}
+	}
+	for i, element := range block.Elements {
+		name := fmt.Sprintf("elements[%d]", i)
+		if element == nil {
+			return &Error{name, "element is nil"}
+		}
+		if err := validateElement(name, element); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// MarshalJSON encodes the actions, including its type.
+func (block *Actions) MarshalJSON() ([]byte, error) {
+	type actions Actions
+	return marshalTyped(block.Type(), (*actions)(block))
+}
+
+// Header displays plain text in a large, bold font.
+type Header struct {
+	BlockID string `json:"block_id,omitempty"`
+	Text    *Text  `json:"text"`
+}
+
+// Type returns "header".
+func (block *Header) Type() string { return "header" }
+
+// Validate checks the header against Slack's limits.
+func (block *Header) Validate() error {
+	if err := validateBlockID(block.BlockID); err != nil {
+		return err
+	}
+	return requireText("text", block.Text, 150, true)
+}
+
+// MarshalJSON encodes the header, including its type.
+func (block *Header) MarshalJSON() ([]byte, error) {
+	type header Header
+	return marshalTyped(block.Type(), (*header)(block))
+}
+
+// Image displays an image, with an optional title.
+type Image struct {
+	BlockID  string `json:"block_id,omitempty"`
+	ImageURL string `json:"image_url"`
+	AltText  string `json:"alt_text"`
+	Title    *Text  `json:"title,omitempty"`
+}
+
+// Type returns "image".
+func (block *Image) Type() string { return "image" }
+
+// Validate checks the image against Slack's limits.
+func (block *Image) Validate() error {
+	if err := validateBlockID(block.BlockID); err != nil {
+		return err
+	}
+	if err := requireString("image_url", block.ImageURL, 3000); err != nil {
+		return err
+	}
+	if err := requireString("alt_text", block.AltText, 2000); err != nil {
+		return err
+	}
+	return validateText("title", block.Title, 2000, true)
+}
+
+// MarshalJSON encodes the image, including its type.
+func (block *Image) MarshalJSON() ([]byte, error) {
+	type image Image
+	return marshalTyped(block.Type(), (*image)(block))
+}
+
+// Input collects information from a user with a single element, such as a
+// PlainTextInput or a select.
+type Input struct {
+	BlockID        string  `json:"block_id,omitempty"`
+	Label          *Text   `json:"label"`
+	Element        Element `json:"element"`
+	Hint           *Text   `json:"hint,omitempty"`
+	Optional       bool    `json:"optional,omitempty"`
+	DispatchAction bool    `json:"dispatch_action,omitempty"`
+}
+
+// Type returns "input".
+func (block *Input) Type() string { return "input" }
+
+// Validate checks the input against Slack's limits.
+func (block *Input) Validate() error {
+	if err := validateBlockID(block.BlockID); err != nil {
+		return err
+	}
+	if err := requireText("label", block.Label, 2000, true); err != nil {
+		return err
+	}
+	if block.Element == nil {
+		return &Error{"element", "element is required"}
+	}
+	if err := validateElement("element", block.Element); err != nil {
+		return err
+	}
+	return validateText("hint", block.Hint, 2000, true)
+}
+
+// MarshalJSON encodes the input, including its type.
+func (block *Input) MarshalJSON() ([]byte, error) {
+	type input Input
+	return marshalTyped(block.Type(), (*input)(block))
+}
+
+func validateBlockID(id string) error {
+	return validateString("block_id", id, maxBlockID)
+}
+
+// marshalTyped encodes v (which must marshal to a JSON object) with an added
+// "type" key.
+func marshalTyped(typ string, v interface{}) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	typeField, err := json.Marshal(typ)
+	if err != nil {
+		return nil, err
+	}
+	encoded := append([]byte(`{"type":`), typeField...)
+	if len(data) > 2 {
+		encoded = append(encoded, ',')
+	}
+	return append(encoded, data[1:]...), nil
+}
+
+// within nests the field of err under parent.
+func within(parent string, err error) error {
+	blockErr, ok := err.(*Error)
+	if !ok {
+		return err
+	}
+	field := parent
+	if blockErr.Field != "" {
+		field = parent + "." + blockErr.Field
+	}
+	return &Error{field, blockErr.Message}
+}
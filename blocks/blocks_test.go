@@ -0,0 +1,99 @@
+package blocks
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestEncode(t *testing.T) {
+	encoded, err := Encode(
+		&Header{Text: PlainText("Deploy")},
+		&Section{
+			Text:      Markdown("*api*"),
+			Accessory: &Button{ActionID: "go", Text: PlainText("Go"), Style: Primary},
+		},
+		&Divider{},
+	)
+	if err != nil {
+		t.Fatalf("Error. Was not expecting an error, but found %v", err)
+	}
+	var decoded []map[string]interface{}
+	if err := json.Unmarshal([]byte(encoded), &decoded); err != nil {
+		t.Fatalf("Error. Could not decode %s: %v", encoded, err)
+	}
+	if len(decoded) != 3 {
+		t.Fatalf("Error. Expected 3 blocks. Got %d.", len(decoded))
+	}
+	for i, expected := range []string{"header", "section", "divider"} {
+		if decoded[i]["type"] != expected {
+			t.Errorf("Error. Expected type %s. Got %v.", expected, decoded[i]["type"])
+		}
+	}
+	accessory := decoded[1]["accessory"].(map[string]interface{})
+	if accessory["type"] != "button" || accessory["style"] != "primary" {
+		t.Errorf("Error. Unexpected accessory %v.", accessory)
+	}
+	if encoded[len(encoded)-len(`{"type":"divider"}]`):] != `{"type":"divider"}]` {
+		t.Errorf("Error. Expected an empty divider. Got %s.", encoded)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	var tests = []struct {
+		blocks        []Block
+		expectedField string
+	}{
+		{[]Block{&Divider{}}, ""},
+		{[]Block{&Section{}}, "blocks[0].text"},
+		{[]Block{&Divider{}, &Header{Text: Markdown("hi")}}, "blocks[1].text"},
+		{[]Block{&Header{Text: PlainText(strings.Repeat("a", 151))}}, "blocks[0].text"},
+		{[]Block{&Section{Fields: make([]*Text, 11)}}, "blocks[0].fields"},
+		{[]Block{&Context{}}, "blocks[0].elements"},
+		{
+			[]Block{&Context{Elements: []ContextElement{
+				Markdown("hi"), &ImageElement{ImageURL: "https://a.com/i.png"},
+			}}},
+			"blocks[0].elements[1].alt_text",
+		},
+		{[]Block{&Image{ImageURL: "https://a.com/i.png", AltText: "i"}}, ""},
+		{[]Block{&Input{Label: PlainText("Env")}}, "blocks[0].element"},
+		{
+			[]Block{&Input{Label: PlainText("Env"), Element: &PlainTextInput{}}},
+			"",
+		},
+		{
+			[]Block{&Actions{Elements: []Element{
+				&Button{Text: PlainText("ok"), Style: "blue"},
+			}}},
+			"blocks[0].elements[0].style",
+		},
+		{[]Block{nil}, "blocks[0]"},
+		{make([]Block, MaxBlocks+1), "blocks"},
+	}
+
+	for _, test := range tests {
+		err := Validate(test.blocks...)
+		if test.expectedField == "" {
+			if err != nil {
+				t.Errorf("Error. Was not expecting an error, but found %v", err)
+			}
+			continue
+		}
+		blockErr, ok := err.(*Error)
+		if !ok {
+			t.Errorf("Error. Expected an error for %s. Got %v.", test.expectedField, err)
+			continue
+		}
+		if blockErr.Field != test.expectedField {
+			t.Errorf("Error. Expected field %s. Got %s.", test.expectedField, blockErr.Field)
+		}
+	}
+}
+
+func TestError(t *testing.T) {
+	err := &Error{"blocks[0].text", "text is required"}
+	if err.Error() != "blocks[0].text: text is required" {
+		t.Errorf("Error. Unexpected message %q.", err.Error())
+	}
+}
@@ -0,0 +1,394 @@
+package blocks
+
+import (
+	"fmt"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	plainTextType = "plain_text"
+	markdownType  = "mrkdwn"
+)
+
+// Style changes the appearance of a Button.
+type Style string
+
+const (
+	// Default is the default button style.
+	Default Style = ""
+	// Primary gives a button a green outline and text, and should be used for
+	// the one affirmative action in a set of buttons.
+	Primary Style = "primary"
+	// Danger gives a button a red outline and text, and should be used for
+	// destructive actions.
+	Danger Style = "danger"
+)
+
+// Text is a text object, which is either plain text or mrkdwn. Use PlainText
+// and Markdown to construct one.
+type Text struct {
+	Type     string `json:"type"`
+	Text     string `json:"text"`
+	Emoji    bool   `json:"emoji,omitempty"`
+	Verbatim bool   `json:"verbatim,omitempty"`
+}
+
+// PlainText constructs a plain text object. Emoji in text (e.g. ":smile:")
+// will be displayed as emoji.
+func PlainText(text string) *Text {
+	return &Text{Type: plainTextType, Text: text, Emoji: true}
+}
+
+// Markdown constructs a mrkdwn text object. See the format package for helpers
+// for building mrkdwn.
+func Markdown(text string) *Text {
+	return &Text{Type: markdownType, Text: text}
+}
+
+func (*Text) contextElement() {}
+
+// Option is a single choice in a select or overflow menu.
+type Option struct {
+	Text        *Text  `json:"text"`
+	Value       string `json:"value"`
+	Description *Text  `json:"description,omitempty"`
+	URL         string `json:"url,omitempty"`
+}
+
+func (option *Option) validate() error {
+	if err := requireText("text", option.Text, 75, false); err != nil {
+		return err
+	}
+	if err := requireString("value", option.Value, 150); err != nil {
+		return err
+	}
+	if err := validateText("description", option.Description, 75, true); err != nil {
+		return err
+	}
+	return validateString("url", option.URL, 3000)
+}
+
+// Element is an interactive element, such as a button or a select menu.
+//
+// Type should return the element's "type" in Block Kit, and Validate should
+// return an error if the element violates any of Slack's limits.
+type Element interface {
+	Type() string
+	Validate() error
+}
+
+// Button is a button which sends an interaction payload (with Value) when
+// clicked. If URL is set, clicking the button also opens the URL.
+type Button struct {
+	ActionID string `json:"action_id,omitempty"`
+	Text     *Text  `json:"text"`
+	URL      string `json:"url,omitempty"`
+	Value    string `json:"value,omitempty"`
+	Style    Style  `json:"style,omitempty"`
+}
+
+// Type returns "button".
+func (element *Button) Type() string { return "button" }
+
+// Validate checks the button against Slack's limits.
+func (element *Button) Validate() error {
+	if err := validateActionID(element.ActionID); err != nil {
+		return err
+	}
+	if err := requireText("text", element.Text, 75, true); err != nil {
+		return err
+	}
+	if err := validateString("url", element.URL, 3000); err != nil {
+		return err
+	}
+	if err := validateString("value", element.Value, 2000); err != nil {
+		return err
+	}
+	switch element.Style {
+	case Default, Primary, Danger:
+		return nil
+	}
+	return &Error{"style", fmt.Sprintf("unknown style %q", element.Style)}
+}
+
+// MarshalJSON encodes the button, including its type.
+func (element *Button) MarshalJSON() ([]byte, error) {
+	type button Button
+	return marshalTyped(element.Type(), (*button)(element))
+}
+
+// StaticSelect is a select menu with a fixed list of up to 100 options.
+type StaticSelect struct {
+	ActionID      string    `json:"action_id,omitempty"`
+	Placeholder   *Text     `json:"placeholder,omitempty"`
+	Options       []*Option `json:"options"`
+	InitialOption *Option   `json:"initial_option,omitempty"`
+}
+
+// Type returns "static_select".
+func (element *StaticSelect) Type() string { return "static_select" }
+
+// Validate checks the select against Slack's limits.
+func (element *StaticSelect) Validate() error {
+	if err := validateActionID(element.ActionID); err != nil {
+		return err
+	}
+	if err := validatePlaceholder(element.Placeholder); err != nil {
+		return err
+	}
+	if err := validateOptions(element.Options, 1, 100); err != nil {
+		return err
+	}
+	if element.InitialOption != nil {
+		if err := element.InitialOption.validate(); err != nil {
+			return within("initial_option", err)
+		}
+	}
+	return nil
+}
+
+// MarshalJSON encodes the select, including its type.
+func (element *StaticSelect) MarshalJSON() ([]byte, error) {
+	type staticSelect StaticSelect
+	return marshalTyped(element.Type(), (*staticSelect)(element))
+}
+
+// UsersSelect is a select menu listing the users on the team.
+type UsersSelect struct {
+	ActionID    string `json:"action_id,omitempty"`
+	Placeholder *Text  `json:"placeholder,omitempty"`
+	InitialUser string `json:"initial_user,omitempty"`
+}
+
+// Type returns "users_select".
+func (element *UsersSelect) Type() string { return "users_select" }
+
+// Validate checks the select against Slack's limits.
+func (element *UsersSelect) Validate() error {
+	if err := validateActionID(element.ActionID); err != nil {
+		return err
+	}
+	return validatePlaceholder(element.Placeholder)
+}
+
+// MarshalJSON encodes the select, including its type.
+func (element *UsersSelect) MarshalJSON() ([]byte, error) {
+	type usersSelect UsersSelect
+	return marshalTyped(element.Type(), (*usersSelect)(element))
+}
+
+// ChannelsSelect is a select menu listing the public channels on the team.
+type ChannelsSelect struct {
+	ActionID       string `json:"action_id,omitempty"`
+	Placeholder    *Text  `json:"placeholder,omitempty"`
+	InitialChannel string `json:"initial_channel,omitempty"`
+}
+
+// Type returns "channels_select".
+func (element *ChannelsSelect) Type() string { return "channels_select" }
+
+// Validate checks the select against Slack's limits.
+func (element *ChannelsSelect) Validate() error {
+	if err := validateActionID(element.ActionID); err != nil {
+		return err
+	}
+	return validatePlaceholder(element.Placeholder)
+}
+
+// MarshalJSON encodes the select, including its type.
+func (element *ChannelsSelect) MarshalJSON() ([]byte, error) {
+	type channelsSelect ChannelsSelect
+	return marshalTyped(element.Type(), (*channelsSelect)(element))
+}
+
+// DatePicker lets a user choose a date from a calendar. InitialDate must have
+// the form "YYYY-MM-DD".
+type DatePicker struct {
+	ActionID    string `json:"action_id,omitempty"`
+	Placeholder *Text  `json:"placeholder,omitempty"`
+	InitialDate string `json:"initial_date,omitempty"`
+}
+
+// Type returns "datepicker".
+func (element *DatePicker) Type() string { return "datepicker" }
+
+// Validate checks the date picker against Slack's limits.
+func (element *DatePicker) Validate() error {
+	if err := validateActionID(element.ActionID); err != nil {
+		return err
+	}
+	if err := validatePlaceholder(element.Placeholder); err != nil {
+		return err
+	}
+	if element.InitialDate != "" {
+		if _, err := time.Parse("2006-01-02", element.InitialDate); err != nil {
+			return &Error{"initial_date", "date must have the form YYYY-MM-DD"}
+		}
+	}
+	return nil
+}
+
+// MarshalJSON encodes the date picker, including its type.
+func (element *DatePicker) MarshalJSON() ([]byte, error) {
+	type datePicker DatePicker
+	return marshalTyped(element.Type(), (*datePicker)(element))
+}
+
+// Overflow is a compact "..." menu with between 2 and 5 options.
+type Overflow struct {
+	ActionID string    `json:"action_id,omitempty"`
+	Options  []*Option `json:"options"`
+}
+
+// Type returns "overflow".
+func (element *Overflow) Type() string { return "overflow" }
+
+// Validate checks the overflow menu against Slack's limits.
+func (element *Overflow) Validate() error {
+	if err := validateActionID(element.ActionID); err != nil {
+		return err
+	}
+	return validateOptions(element.Options, 2, 5)
+}
+
+// MarshalJSON encodes the overflow menu, including its type.
+func (element *Overflow) MarshalJSON() ([]byte, error) {
+	type overflow Overflow
+	return marshalTyped(element.Type(), (*overflow)(element))
+}
+
+// PlainTextInput is a free-form text field, for use in Input blocks.
+type PlainTextInput struct {
+	ActionID     string `json:"action_id,omitempty"`
+	Placeholder  *Text  `json:"placeholder,omitempty"`
+	InitialValue string `json:"initial_value,omitempty"`
+	Multiline    bool   `json:"multiline,omitempty"`
+	MinLength    int    `json:"min_length,omitempty"`
+	MaxLength    int    `json:"max_length,omitempty"`
+}
+
+// Type returns "plain_text_input".
+func (element *PlainTextInput) Type() string { return "plain_text_input" }
+
+// Validate checks the input against Slack's limits.
+func (element *PlainTextInput) Validate() error {
+	if err := validateActionID(element.ActionID); err != nil {
+		return err
+	}
+	if err := validatePlaceholder(element.Placeholder); err != nil {
+		return err
+	}
+	if element.MinLength < 0 || element.MinLength > 3000 {
+		return &Error{"min_length", "must be between 0 and 3000"}
+	}
+	if element.MaxLength < 0 || (element.MaxLength != 0 && element.MaxLength < element.MinLength) {
+		return &Error{"max_length", "must not be less than min_length"}
+	}
+	return nil
+}
+
+// MarshalJSON encodes the input, including its type.
+func (element *PlainTextInput) MarshalJSON() ([]byte, error) {
+	type plainTextInput PlainTextInput
+	return marshalTyped(element.Type(), (*plainTextInput)(element))
+}
+
+// ImageElement is a small image, for use in Section accessories and Context
+// blocks. See Image for the full-width image block.
+type ImageElement struct {
+	ImageURL string `json:"image_url"`
+	AltText  string `json:"alt_text"`
+}
+
+// Type returns "image".
+func (element *ImageElement) Type() string { return "image" }
+
+// Validate checks the image against Slack's limits.
+func (element *ImageElement) Validate() error {
+	if err := requireString("image_url", element.ImageURL, 3000); err != nil {
+		return err
+	}
+	return requireString("alt_text", element.AltText, 2000)
+}
+
+// MarshalJSON encodes the image, including its type.
+func (element *ImageElement) MarshalJSON() ([]byte, error) {
+	type imageElement ImageElement
+	return marshalTyped(element.Type(), (*imageElement)(element))
+}
+
+func (*ImageElement) contextElement() {}
+
+func validateElement(name string, element Element) error {
+	if element == nil {
+		return nil
+	}
+	if err := element.Validate(); err != nil {
+		return within(name, err)
+	}
+	return nil
+}
+
+func validateActionID(id string) error {
+	return validateString("action_id", id, 255)
+}
+
+func validatePlaceholder(placeholder *Text) error {
+	return validateText("placeholder", placeholder, 150, true)
+}
+
+func validateOptions(options []*Option, min, max int) error {
+	if len(options) < min || len(options) > max {
+		return &Error{"options", fmt.Sprintf("between %d and %d options are required", min, max)}
+	}
+	for i, option := range options {
+		name := fmt.Sprintf("options[%d]", i)
+		if option == nil {
+			return &Error{name, "option is nil"}
+		}
+		if err := option.validate(); err != nil {
+			return within(name, err)
+		}
+	}
+	return nil
+}
+
+// validateText checks that text, if it is not nil, is at most max characters,
+// and, if plainOnly is set, is plain text.
+func validateText(name string, text *Text, max int, plainOnly bool) error {
+	if text == nil {
+		return nil
+	}
+	return requireText(name, text, max, plainOnly)
+}
+
+func requireText(name string, text *Text, max int, plainOnly bool) error {
+	if text == nil {
+		return &Error{name, "text is required"}
+	}
+	switch text.Type {
+	case plainTextType:
+	case markdownType:
+		if plainOnly {
+			return &Error{name, "text must be plain_text"}
+		}
+	default:
+		return &Error{name, fmt.Sprintf("unknown text type %q", text.Type)}
+	}
+	return requireString(name, text.Text, max)
+}
+
+func validateString(name, s string, max int) error {
+	if utf8.RuneCountInString(s) > max {
+		return &Error{name, fmt.Sprintf("must be at most %d characters", max)}
+	}
+	return nil
+}
+
+func requireString(name, s string, max int) error {
+	if s == "" {
+		return &Error{name, "must not be empty"}
+	}
+	return validateString(name, s, max)
+}
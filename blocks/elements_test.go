@@ -0,0 +1,82 @@
+package blocks
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func option(value string) *Option {
+	return &Option{Text: PlainText(value), Value: value}
+}
+
+func TestElementsValidate(t *testing.T) {
+	var tests = []struct {
+		element       Element
+		expectedField string
+	}{
+		{&Button{Text: PlainText("ok")}, ""},
+		{&Button{}, "text"},
+		{&StaticSelect{Options: []*Option{option("a")}}, ""},
+		{&StaticSelect{}, "options"},
+		{&StaticSelect{Options: []*Option{{Text: PlainText("a")}}}, "options[0].value"},
+		{
+			&StaticSelect{Options: []*Option{option("a")}, InitialOption: &Option{}},
+			"initial_option.text",
+		},
+		{&UsersSelect{Placeholder: Markdown("who?")}, "placeholder"},
+		{&ChannelsSelect{Placeholder: PlainText("where?")}, ""},
+		{&DatePicker{InitialDate: "2016-02-29"}, ""},
+		{&DatePicker{InitialDate: "02/29/2016"}, "initial_date"},
+		{&Overflow{Options: []*Option{option("a")}}, "options"},
+		{&Overflow{Options: []*Option{option("a"), option("b")}}, ""},
+		{&PlainTextInput{MinLength: 10, MaxLength: 5}, "max_length"},
+		{&ImageElement{ImageURL: "https://a.com/i.png", AltText: "i"}, ""},
+	}
+
+	for _, test := range tests {
+		err := test.element.Validate()
+		if test.expectedField == "" {
+			if err != nil {
+				t.Errorf("Error. Was not expecting an error, but found %v", err)
+			}
+			continue
+		}
+		blockErr, ok := err.(*Error)
+		if !ok {
+			t.Errorf("Error. Expected an error for %s. Got %v.", test.expectedField, err)
+			continue
+		}
+		if blockErr.Field != test.expectedField {
+			t.Errorf("Error. Expected field %s. Got %s.", test.expectedField, blockErr.Field)
+		}
+	}
+}
+
+func TestElementsMarshalJSON(t *testing.T) {
+	var tests = []struct {
+		element      Element
+		expectedType string
+	}{
+		{&Button{}, "button"},
+		{&StaticSelect{}, "static_select"},
+		{&UsersSelect{}, "users_select"},
+		{&ChannelsSelect{}, "channels_select"},
+		{&DatePicker{}, "datepicker"},
+		{&Overflow{}, "overflow"},
+		{&PlainTextInput{}, "plain_text_input"},
+		{&ImageElement{}, "image"},
+	}
+
+	for _, test := range tests {
+		data, err := json.Marshal(test.element)
+		if err != nil {
+			t.Errorf("Error. Was not expecting an error, but found %v", err)
+			continue
+		}
+		var decoded map[string]interface{}
+		json.Unmarshal(data, &decoded)
+		if decoded["type"] != test.expectedType {
+			t.Errorf("Error. Expected type %s. Got %v.", test.expectedType, decoded["type"])
+		}
+	}
+}
@@ -3,40 +3,116 @@ package slack
 import (
 	"net/http"
 	"net/url"
+	"sync"
+	"time"
 
-	log "github.com/Sirupsen/logrus"
+	"github.com/ajm188/slack/brain"
 	"github.com/gorilla/websocket"
 )
 
 const (
 	// Version is the semantic version of this library.
 	Version = "0.2.0"
+
+	outgoingBuffer = 100
 )
 
 // Bot encapsulates all the data needed to interact with Slack.
+//
+// Handlers and Subhandlers hold the BotActions of the handlers registered with
+// OnEvent and the other registration methods, in the order they fire. They are
+// rebuilt from the registered handlers whenever one is removed, so handlers
+// should be changed through the Handler returned when they were registered,
+// not by modifying Handlers or Subhandlers.
+//
+// Aliases are additional names the bot will answer to, and Prefixes are
+// strings (such as "!") which, when they begin a message, address the message
+// to the bot. See Addressed for more details.
+//
+// Scheduler runs jobs on a schedule while the bot is running. See Scheduler
+// for more details.
+//
+// APIURL is the base URL of the Slack Web API, which defaults to
+// DefaultAPIURL. It can be pointed at a fake server, like the one in package
+// github.com/ajm188/slack/slacktest, to test bots without connecting to Slack.
+//
+// Recorder, if it is set, records every event the bot receives and every
+// message it sends, so that a session can be replayed later with Replay.
+//
+// Metrics, if it is set, receives measurements of the events the bot
+// receives, its handlers, the messages it sends and its Web API calls.
+//
+// Tracer, if it is set, traces each event the bot handles, with child spans
+// for its handlers and their Web API calls.
+//
+// Logger is where the bot writes its logs, and defaults to the standard logrus
+// logger. Redact says what to leave out of them; NewBot leaves out tokens.
+//
+// PingInterval is how often the bot pings Slack while it is connected, so that
+// Ready can tell whether the connection is alive. NewBot sets it to
+// DefaultPingInterval; zero turns pings off.
+//
+// AdminAddr, if it is set, is the address the bot serves its AdminHandler at
+// while it is running, for health checks and debugging.
+//
+// Brain is where the bot and its plugins store data. It defaults to an
+// in-memory store, so set it to a persistent store (such as a brain.File) if
+// the data should survive restarts. Plugins should use BrainFor rather than
+// using Brain directly.
 type Bot struct {
-	Token        string
-	Name         string
-	ID           string
-	Handlers     map[string]([]BotAction)
-	Subhandlers  map[string](map[string]([]BotAction))
-	Users        map[string]*User
-	Channels     map[string]string
-	reconnectURL string
+	Token         string
+	APIURL        string
+	Name          string
+	ID            string
+	Aliases       []string
+	Prefixes      []string
+	Handlers      map[string]([]BotAction)
+	Subhandlers   map[string](map[string]([]BotAction))
+	Users         map[string]*User
+	Channels      map[string]string
+	Scheduler     *Scheduler
+	Brain         brain.Store
+	Recorder      *Recorder
+	Metrics       Metrics
+	Tracer        Tracer
+	Logger        Logger
+	Redact        Redaction
+	PingInterval  time.Duration
+	AdminAddr     string
+	reconnectURL  string
+	outgoing      chan *Message
+	conversations *conversations
+	plugins       *pluginRegistry
+	handlersMu    sync.RWMutex
+	handles       map[string][]*Handler
+	connection    *connection
+	dms           *directMessages
 }
 
 // NewBot constructs a new bot with the passed-in Slack API token.
 func NewBot(token string) *Bot {
-	return &Bot{
-		Token:        token,
-		Name:         "",
-		ID:           "",
-		Handlers:     make(map[string]([]BotAction)),
-		Subhandlers:  make(map[string](map[string]([]BotAction))),
-		Users:        make(map[string]*User),
-		Channels:     make(map[string]string),
-		reconnectURL: "",
+	bot := &Bot{
+		Token:         token,
+		APIURL:        DefaultAPIURL,
+		Name:          "",
+		ID:            "",
+		Handlers:      make(map[string]([]BotAction)),
+		Subhandlers:   make(map[string](map[string]([]BotAction))),
+		Users:         make(map[string]*User),
+		Channels:      make(map[string]string),
+		Brain:         brain.NewMemory(),
+		Redact:        Redaction{Tokens: true},
+		PingInterval:  DefaultPingInterval,
+		reconnectURL:  "",
+		outgoing:      make(chan *Message, outgoingBuffer),
+		conversations: newConversations(),
+		plugins:       newPluginRegistry(),
+		handles:       make(map[string][]*Handler),
+		connection:    &connection{},
+		dms:           newDirectMessages(),
 	}
+	bot.Scheduler = newScheduler(bot)
+	return bot
 }
 
 // StoreReconnectURL takes a "url" from an event and stores it. This is done so
@@ -50,6 +126,13 @@ func StoreReconnectURL(bot *Bot, event map[string]interface{}) (*Message, Status
 // Start initiates the bot's interaction with Slack. It obtains a websockect
 // URL, connects to it, and then starts the main loop.
 func (bot *Bot) Start() error {
+	if bot.AdminAddr != "" {
+		server, err := bot.serveAdmin(bot.AdminAddr)
+		if err != nil {
+			return err
+		}
+		defer server.Close()
+	}
 	payload, err := bot.Call("rtm.start", url.Values{})
 	if err != nil {
 		return err
@@ -76,14 +159,22 @@ func (bot *Bot) Start() error {
 	}
 	bot.Name = self["name"].(string)
 	bot.ID = self["id"].(string)
-	log.WithFields(log.Fields{
+	bot.connection.authenticated(bot.Name, bot.ID, len(users), len(channels))
+	bot.Log().Info("bot authenticated", Fields{
 		"id":   bot.ID,
 		"name": bot.Name,
-	}).Info("bot authenticated")
+	})
 	bot.OnEvent("reconnect_url", StoreReconnectURL)
+	if err := bot.startPlugins(); err != nil {
+		return err
+	}
+	defer bot.stopPlugins()
+	bot.Scheduler.start()
+	defer bot.Scheduler.shutdown()
 	for {
 		reconnect, err := bot.connect(websocketURL)
 		if reconnect && bot.reconnectURL != "" {
+			bot.metrics().Reconnected()
 			websocketURL = bot.reconnectURL
 		} else {
 			return err
@@ -101,8 +192,28 @@ func (bot *Bot) connect(websocketURL string) (bool, error) {
 	return bot.loop(conn), nil
 }
 
+// Send queues message to be written to Slack by the bot's writer. Unlike the
+// messages returned by BotActions, Send may be called from any goroutine, so it
+// can be used to send messages outside of the main loop (for example, after
+// waiting on a Conversation). If the bot is not connected, the message is sent
+// once it connects; Send blocks if too many messages are already waiting.
+// Use SendAndWait to find out whether Slack accepted the message.
+func (bot *Bot) Send(message *Message) {
+	bot.outgoing <- message
+}
+
 func (bot *Bot) loop(conn *websocket.Conn) bool {
-	defer conn.Close()
+	stop := make(chan struct{})
+	stopped := make(chan struct{})
+	bot.connection.connected(time.Now())
+	go bot.writeLoop(conn, stop, stopped)
+	defer func() {
+		bot.connection.disconnected()
+		close(stop)
+		<-stopped
+		conn.Close()
+		bot.failPending()
+	}()
 	for {
 		messageType, bytes, err := conn.ReadMessage()
 		if err != nil {
@@ -114,39 +225,82 @@ func (bot *Bot) loop(conn *websocket.Conn) bool {
 		}
 		event, err := unpackJSON(bytes)
 		if err != nil {
-			log.WithFields(log.Fields{
+			bot.Log().Warn("message could not be unpacked", Fields{
 				"raw bytes": bytes,
 				"error":     err,
-			}).Warn("message could not be unpacked")
+			})
 			continue
 		}
-		log.WithFields(log.Fields{
+		bot.connection.received(event, time.Now())
+		bot.acknowledged(event)
+		if bot.Recorder != nil {
+			if err := bot.Recorder.recordEvent(event); err != nil {
+				bot.Log().Error("Failed to record.", Fields{
+					"error": err,
+				})
+			}
+		}
+		eventSubtype, _ := event["subtype"].(string)
+		if eventType, ok := event["type"].(string); ok {
+			bot.metrics().EventReceived(eventType, eventSubtype)
+		}
+		bot.Log().Debug("received event", Fields{
 			"event": event,
-		}).Info("received event")
+		})
 		eventType, ok := event["type"]
 		if ok && eventType.(string) == "team_migration_started" {
 			return true
 		}
 		wrappers := bot.handle(event)
-		closeConnection := sendResponses(wrappers, conn)
+		closeConnection := bot.sendResponses(wrappers)
 		if closeConnection {
 			return false
 		}
 	}
 }
 
-func sendResponses(wrappers []messageWrapper, conn *websocket.Conn) bool {
+// writeLoop is the only goroutine which writes to conn. It writes queued
+// messages, and pings Slack every PingInterval. When stop is closed, it writes
+// any messages which are still queued and then closes stopped.
+func (bot *Bot) writeLoop(conn *websocket.Conn, stop <-chan struct{}, stopped chan<- struct{}) {
+	defer close(stopped)
+	var pings <-chan time.Time
+	if bot.PingInterval > 0 {
+		ticker := time.NewTicker(bot.PingInterval)
+		defer ticker.Stop()
+		pings = ticker.C
+	}
+	for {
+		select {
+		case message := <-bot.outgoing:
+			bot.write(message, conn)
+		case now := <-pings:
+			conn.WriteJSON(bot.connection.ping(now))
+		case <-stop:
+			for {
+				select {
+				case message := <-bot.outgoing:
+					bot.write(message, conn)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+func (bot *Bot) sendResponses(wrappers []messageWrapper) bool {
 	abort := false
 	for _, wrapper := range wrappers {
 		message := wrapper.message
 		switch wrapper.status {
 		case Continue:
 			if message != nil {
-				conn.WriteJSON(message.toMap())
+				bot.Send(message)
 			}
 		case Shutdown:
 			if message != nil {
-				conn.WriteJSON(message.toMap())
+				bot.Send(message)
 			}
 			abort = true
 		case ShutdownNow:
@@ -155,3 +309,35 @@ func sendResponses(wrappers []messageWrapper, conn *websocket.Conn) bool {
 	}
 	return abort
 }
+
+func (bot *Bot) write(message *Message, conn *websocket.Conn) {
+	if len(message.blocks) == 0 {
+		bot.connection.send(message)
+	}
+	if bot.Recorder != nil {
+		if err := bot.Recorder.recordMessage(message); err != nil {
+			bot.Log().Error("Failed to record.", Fields{
+				"error": err,
+			})
+		}
+	}
+	if len(message.blocks) == 0 {
+		if err := conn.WriteJSON(message.toMap()); err != nil {
+			bot.connection.resolve(message.id)
+			message.acknowledge(Ack{ID: message.id}, err)
+			return
+		}
+		bot.metrics().MessageSent("rtm")
+		return
+	}
+	bot.metrics().MessageSent("web")
+	payload, err := bot.PostMessage(message)
+	if err != nil {
+		bot.Log().Error("Failed to post message.", Fields{
+			"channel": message.channel,
+			"error":   err,
+		})
+	}
+	ts, _ := payload["ts"].(string)
+	message.acknowledge(Ack{TS: ts}, err)
+}
@@ -18,3 +18,26 @@ func TestPrivate_connect(t *testing.T) {
 		t.Error("Error. Expecting error. Got nil")
 	}
 }
+
+func TestPrivate_sendResponses(t *testing.T) {
+	bot := NewBot("token")
+	m1 := NewMessage("one", "general")
+	m2 := NewMessage("two", "general")
+	abort := bot.sendResponses([]messageWrapper{
+		{m1, Continue},
+		{nil, Continue},
+		{m2, Shutdown},
+		{NewMessage("three", "general"), ShutdownNow},
+	})
+	assert(abort, t)
+	assert(len(bot.outgoing) == 2, t)
+	assert(<-bot.outgoing == m1, t)
+	assert(<-bot.outgoing == m2, t)
+}
+
+func TestSend(t *testing.T) {
+	bot := NewBot("token")
+	message := NewMessage("hi", "general")
+	bot.Send(message)
+	assert(<-bot.outgoing == message, t)
+}
@@ -0,0 +1,95 @@
+/*
+Package brain provides persistent key-value storage for bots and plugins.
+
+A Store maps string keys to byte values, optionally with a time-to-live, and
+supports atomic read-modify-write updates with Update. There are three
+implementations: Memory, which does not persist anything; File, which keeps its
+contents in a JSON file; and SQL, which uses a table in any database/sql
+database.
+
+Every slack.Bot has a Brain, which defaults to a Memory store. Plugins should
+use the namespace returned by Bot.BrainFor, so that their keys do not collide
+with those of other plugins:
+
+	store := bot.BrainFor(plugin)
+	err := brain.UpdateJSON(store, "karma/"+user, 0, func(karma *int) error {
+		*karma++
+		return nil
+	})
+*/
+package brain
+
+import (
+	"strings"
+	"time"
+)
+
+// UpdateFunc receives the current value of a key (and whether the key exists),
+// and returns the value to replace it with. Returning a nil value deletes the
+// key, and returning an error aborts the update.
+type UpdateFunc func(value []byte, exists bool) ([]byte, error)
+
+// Store is a key-value store.
+//
+// Get returns the value for key, and false if the key does not exist or has
+// expired. Set stores value under key; if ttl is positive, the key expires
+// after ttl. Delete removes key, and is not an error if key does not exist.
+// Update atomically replaces the value of key with the result of fn, which
+// expires after ttl if ttl is positive. Keys returns the keys (which have not
+// expired) that begin with prefix.
+type Store interface {
+	Get(key string) ([]byte, bool, error)
+	Set(key string, value []byte, ttl time.Duration) error
+	Delete(key string) error
+	Update(key string, ttl time.Duration, fn UpdateFunc) error
+	Keys(prefix string) ([]string, error)
+}
+
+// Namespace returns a Store which prefixes every key with name, so that
+// several users can share store without their keys colliding.
+func Namespace(store Store, name string) Store {
+	return &namespace{store, name + "/"}
+}
+
+type namespace struct {
+	store  Store
+	prefix string
+}
+
+func (ns *namespace) Get(key string) ([]byte, bool, error) {
+	return ns.store.Get(ns.prefix + key)
+}
+
+func (ns *namespace) Set(key string, value []byte, ttl time.Duration) error {
+	return ns.store.Set(ns.prefix+key, value, ttl)
+}
+
+func (ns *namespace) Delete(key string) error {
+	return ns.store.Delete(ns.prefix + key)
+}
+
+func (ns *namespace) Update(key string, ttl time.Duration, fn UpdateFunc) error {
+	return ns.store.Update(ns.prefix+key, ttl, fn)
+}
+
+func (ns *namespace) Keys(prefix string) ([]string, error) {
+	keys, err := ns.store.Keys(ns.prefix + prefix)
+	if err != nil {
+		return nil, err
+	}
+	for i, key := range keys {
+		keys[i] = strings.TrimPrefix(key, ns.prefix)
+	}
+	return keys, nil
+}
+
+func expired(expires time.Time, now time.Time) bool {
+	return !expires.IsZero() && !now.Before(expires)
+}
+
+func expiry(ttl time.Duration, now time.Time) time.Time {
+	if ttl <= 0 {
+		return time.Time{}
+	}
+	return now.Add(ttl)
+}
@@ -0,0 +1,36 @@
+package brain
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNamespace(t *testing.T) {
+	store := NewMemory()
+	karma := Namespace(store, "karma")
+	other := Namespace(store, "other")
+
+	karma.Set("alice", []byte("1"), 0)
+	other.Set("alice", []byte("2"), 0)
+	value, _, _ := karma.Get("alice")
+	if string(value) != "1" {
+		t.Errorf("Error. Expected \"1\". Got %q.", value)
+	}
+	value, _, _ = store.Get("other/alice")
+	if string(value) != "2" {
+		t.Errorf("Error. Expected \"2\". Got %q.", value)
+	}
+	keys, _ := karma.Keys("")
+	if !reflect.DeepEqual(keys, []string{"alice"}) {
+		t.Errorf("Error. Unexpected keys %v.", keys)
+	}
+
+	karma.Update("bob", 0, func(_ []byte, _ bool) ([]byte, error) {
+		return []byte("5"), nil
+	})
+	karma.Delete("alice")
+	keys, _ = store.Keys("")
+	if !reflect.DeepEqual(keys, []string{"karma/bob", "other/alice"}) {
+		t.Errorf("Error. Unexpected keys %v.", keys)
+	}
+}
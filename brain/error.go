@@ -0,0 +1,11 @@
+package brain
+
+// Error is the struct used to create custom errors that occur within the brain
+// package.
+type Error struct {
+	Message string
+}
+
+func (err *Error) Error() string {
+	return err.Message
+}
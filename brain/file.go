@@ -0,0 +1,114 @@
+package brain
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"time"
+)
+
+// File is a Store which keeps its contents in memory, and writes them to a
+// JSON file after every change. It is suitable for small amounts of data which
+// change infrequently, such as plugin settings or karma counts.
+type File struct {
+	path   string
+	memory *Memory
+}
+
+// NewFile opens the File store at path, loading its contents if the file
+// exists.
+func NewFile(path string) (*File, error) {
+	store := &File{path, NewMemory()}
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return store, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &store.memory.entries); err != nil {
+		return nil, err
+	}
+	if store.memory.entries == nil {
+		// the file held null
+		store.memory.entries = make(map[string]entry)
+	}
+	return store, nil
+}
+
+// Get returns the value of key.
+func (store *File) Get(key string) ([]byte, bool, error) {
+	return store.memory.Get(key)
+}
+
+// Set stores value under key, and saves the file. If the file cannot be saved,
+// key keeps its previous value.
+func (store *File) Set(key string, value []byte, ttl time.Duration) error {
+	return store.change(key, func() error {
+		store.memory.set(key, value, ttl)
+		return nil
+	})
+}
+
+// Delete removes key, and saves the file. If the file cannot be saved, key is
+// not removed.
+func (store *File) Delete(key string) error {
+	return store.change(key, func() error {
+		delete(store.memory.entries, key)
+		return nil
+	})
+}
+
+// Update atomically replaces the value of key with the result of fn, and
+// saves the file. If the file cannot be saved, key keeps its previous value.
+func (store *File) Update(key string, ttl time.Duration, fn UpdateFunc) error {
+	return store.change(key, func() error {
+		return store.memory.update(key, ttl, fn)
+	})
+}
+
+// change applies a change to key in memory, and saves the file. If the file
+// cannot be saved, the change is undone, so that memory matches the file.
+func (store *File) change(key string, apply func() error) error {
+	store.memory.mu.Lock()
+	defer store.memory.mu.Unlock()
+	previous, existed := store.memory.entries[key]
+	if err := apply(); err != nil {
+		return err
+	}
+	if err := store.save(); err != nil {
+		if existed {
+			store.memory.entries[key] = previous
+		} else {
+			delete(store.memory.entries, key)
+		}
+		return err
+	}
+	return nil
+}
+
+// Keys returns the keys which begin with prefix, in sorted order.
+func (store *File) Keys(prefix string) ([]string, error) {
+	return store.memory.Keys(prefix)
+}
+
+// save writes the store to a temporary file, and then renames it over the
+// store's file, so that the file is never left partially written. It must be
+// called with the memory store's mu held.
+func (store *File) save() error {
+	now := store.memory.now()
+	for key, e := range store.memory.entries {
+		if expired(e.Expires, now) {
+			delete(store.memory.entries, key)
+		}
+	}
+	data, err := json.Marshal(store.memory.entries)
+	if err != nil {
+		return err
+	}
+	tmp := store.path + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, store.path)
+}
@@ -0,0 +1,107 @@
+package brain
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "brain")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "brain.json")
+
+	store, err := NewFile(path)
+	if err != nil {
+		t.Fatalf("Error. Was not expecting an error, but found %v", err)
+	}
+	c := &clock{time.Now()}
+	store.memory.now = c.Now
+	testStore(store, c.Advance, t)
+
+	reopened, err := NewFile(path)
+	if err != nil {
+		t.Fatalf("Error. Was not expecting an error, but found %v", err)
+	}
+	value, ok, _ := reopened.Get("b/1")
+	if !ok || string(value) != "three" {
+		t.Errorf("Error. Expected \"three\" to persist. Got %q, %v.", value, ok)
+	}
+	_, ok, _ = reopened.Get("a/1")
+	if ok {
+		t.Error("Error. Expected a/1 to stay deleted.")
+	}
+}
+
+func TestFileInvalid(t *testing.T) {
+	dir, err := ioutil.TempDir("", "brain")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "brain.json")
+	ioutil.WriteFile(path, []byte("not json"), 0600)
+	if _, err := NewFile(path); err == nil {
+		t.Error("Error. Expected an error for an invalid file.")
+	}
+}
+
+func TestFileNull(t *testing.T) {
+	dir, err := ioutil.TempDir("", "brain")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "brain.json")
+	ioutil.WriteFile(path, []byte("null"), 0600)
+	store, err := NewFile(path)
+	if err != nil {
+		t.Fatalf("Error. Was not expecting an error, but found %v", err)
+	}
+	if err := store.Set("key", []byte("value"), 0); err != nil {
+		t.Errorf("Error. Was not expecting an error, but found %v", err)
+	}
+}
+
+func TestFileSaveFails(t *testing.T) {
+	dir, err := ioutil.TempDir("", "brain")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	store, err := NewFile(filepath.Join(dir, "missing", "brain.json"))
+	if err != nil {
+		t.Fatalf("Error. Was not expecting an error, but found %v", err)
+	}
+	store.memory.entries["kept"] = entry{Value: []byte("old")}
+
+	var tests = []struct {
+		name   string
+		change func() error
+	}{
+		{"Set new", func() error { return store.Set("new", []byte("value"), 0) }},
+		{"Set", func() error { return store.Set("kept", []byte("new"), 0) }},
+		{"Delete", func() error { return store.Delete("kept") }},
+		{"Update", func() error {
+			return store.Update("kept", 0, func([]byte, bool) ([]byte, error) {
+				return []byte("new"), nil
+			})
+		}},
+	}
+	for _, test := range tests {
+		if err := test.change(); err == nil {
+			t.Errorf("Error. Expected %s to fail to save.", test.name)
+		}
+		if _, ok, _ := store.Get("new"); ok {
+			t.Errorf("Error. Expected %s not to leave new in memory.", test.name)
+		}
+		if value, ok, _ := store.Get("kept"); !ok || string(value) != "old" {
+			t.Errorf("Error. Expected %s to leave kept as \"old\". Got %q, %v.", test.name, value, ok)
+		}
+	}
+}
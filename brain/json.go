@@ -0,0 +1,64 @@
+package brain
+
+import (
+	"encoding/json"
+	"reflect"
+	"time"
+)
+
+// GetJSON decodes the JSON value of key into v. It returns false if the key
+// does not exist.
+func GetJSON(store Store, key string, v interface{}) (bool, error) {
+	value, ok, err := store.Get(key)
+	if err != nil || !ok {
+		return false, err
+	}
+	return true, json.Unmarshal(value, v)
+}
+
+// SetJSON stores the JSON encoding of v under key.
+func SetJSON(store Store, key string, v interface{}, ttl time.Duration) error {
+	value, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return store.Set(key, value, ttl)
+}
+
+// UpdateJSON atomically updates the JSON value of key. fn must be a function
+// which takes a pointer to the type the value is decoded into, such as
+// func(*int) error; it receives the zero value if the key does not exist, and
+// may modify it in place. Returning an error from fn aborts the update.
+func UpdateJSON(store Store, key string, ttl time.Duration, fn interface{}) error {
+	return store.Update(key, ttl, func(value []byte, exists bool) ([]byte, error) {
+		return updateJSON(value, exists, fn)
+	})
+}
+
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+func updateJSON(value []byte, exists bool, fn interface{}) ([]byte, error) {
+	fnValue := reflect.ValueOf(fn)
+	if !fnValue.IsValid() {
+		return nil, &Error{"UpdateJSON requires a func(*T) error, not nil"}
+	}
+	fnType := fnValue.Type()
+	if fnType.Kind() != reflect.Func || fnType.NumIn() != 1 ||
+		fnType.In(0).Kind() != reflect.Ptr || fnType.NumOut() != 1 ||
+		fnType.Out(0) != errorType {
+		return nil, &Error{"UpdateJSON requires a func(*T) error"}
+	}
+	if fnValue.IsNil() {
+		return nil, &Error{"UpdateJSON requires a func(*T) error, not nil"}
+	}
+	ptr := reflect.New(fnType.In(0).Elem())
+	if exists {
+		if err := json.Unmarshal(value, ptr.Interface()); err != nil {
+			return nil, err
+		}
+	}
+	if err, _ := fnValue.Call([]reflect.Value{ptr})[0].Interface().(error); err != nil {
+		return nil, err
+	}
+	return json.Marshal(ptr.Interface())
+}
@@ -0,0 +1,41 @@
+package brain
+
+import (
+	"testing"
+)
+
+func TestJSON(t *testing.T) {
+	store := NewMemory()
+	var karma int
+	ok, err := GetJSON(store, "alice", &karma)
+	if ok || err != nil {
+		t.Errorf("Error. Expected a missing key. Got %v, %v.", ok, err)
+	}
+
+	for i := 0; i < 3; i++ {
+		err := UpdateJSON(store, "alice", 0, func(karma *int) error {
+			*karma++
+			return nil
+		})
+		if err != nil {
+			t.Errorf("Error. Was not expecting an error, but found %v", err)
+		}
+	}
+	GetJSON(store, "alice", &karma)
+	if karma != 3 {
+		t.Errorf("Error. Expected 3. Got %d.", karma)
+	}
+
+	SetJSON(store, "bob", []string{"x"}, 0)
+	var list []string
+	GetJSON(store, "bob", &list)
+	if len(list) != 1 || list[0] != "x" {
+		t.Errorf("Error. Unexpected value %v.", list)
+	}
+
+	for _, fn := range []interface{}{func(karma int) {}, nil, (func(*int) error)(nil)} {
+		if err := UpdateJSON(store, "alice", 0, fn); err == nil {
+			t.Errorf("Error. Expected an error for the invalid function %T.", fn)
+		}
+	}
+}
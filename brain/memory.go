@@ -0,0 +1,116 @@
+package brain
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Memory is a Store which keeps everything in memory. Its contents are lost
+// when the process exits.
+type Memory struct {
+	mu      sync.Mutex
+	entries map[string]entry
+	now     func() time.Time
+}
+
+type entry struct {
+	Value   []byte    `json:"value"`
+	Expires time.Time `json:"expires"`
+}
+
+// NewMemory constructs an empty Memory store.
+func NewMemory() *Memory {
+	return &Memory{
+		entries: make(map[string]entry),
+		now:     time.Now,
+	}
+}
+
+// Get returns the value of key.
+func (store *Memory) Get(key string) ([]byte, bool, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	value, ok := store.get(key)
+	return value, ok, nil
+}
+
+// Set stores value under key.
+func (store *Memory) Set(key string, value []byte, ttl time.Duration) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	store.set(key, value, ttl)
+	return nil
+}
+
+// Delete removes key.
+func (store *Memory) Delete(key string) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	delete(store.entries, key)
+	return nil
+}
+
+// Update atomically replaces the value of key with the result of fn.
+func (store *Memory) Update(key string, ttl time.Duration, fn UpdateFunc) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	return store.update(key, ttl, fn)
+}
+
+// Keys returns the keys which begin with prefix, in sorted order.
+func (store *Memory) Keys(prefix string) ([]string, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	now := store.now()
+	var keys []string
+	for key, e := range store.entries {
+		if strings.HasPrefix(key, prefix) && !expired(e.Expires, now) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+// The following methods must be called with mu held.
+
+func (store *Memory) get(key string) ([]byte, bool) {
+	e, ok := store.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if expired(e.Expires, store.now()) {
+		delete(store.entries, key)
+		return nil, false
+	}
+	return copyBytes(e.Value), true
+}
+
+func (store *Memory) set(key string, value []byte, ttl time.Duration) {
+	store.entries[key] = entry{copyBytes(value), expiry(ttl, store.now())}
+}
+
+func (store *Memory) update(key string, ttl time.Duration, fn UpdateFunc) error {
+	value, ok := store.get(key)
+	updated, err := fn(value, ok)
+	if err != nil {
+		return err
+	}
+	if updated == nil {
+		delete(store.entries, key)
+		return nil
+	}
+	store.set(key, updated, ttl)
+	return nil
+}
+
+func copyBytes(b []byte) []byte {
+	if b == nil {
+		return nil
+	}
+	c := make([]byte, len(b))
+	copy(c, b)
+	return c
+}
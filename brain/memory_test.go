@@ -0,0 +1,86 @@
+package brain
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+// testStore exercises the behavior every Store should have.
+func testStore(store Store, advance func(time.Duration), t *testing.T) {
+	_, ok, err := store.Get("missing")
+	if ok || err != nil {
+		t.Errorf("Error. Expected a missing key. Got %v, %v.", ok, err)
+	}
+
+	store.Set("a/1", []byte("one"), 0)
+	store.Set("a/2", []byte("two"), time.Minute)
+	store.Set("b/1", []byte("three"), 0)
+	value, ok, err := store.Get("a/1")
+	if !ok || err != nil || string(value) != "one" {
+		t.Errorf("Error. Expected \"one\". Got %q, %v, %v.", value, ok, err)
+	}
+	keys, _ := store.Keys("a/")
+	if !reflect.DeepEqual(keys, []string{"a/1", "a/2"}) {
+		t.Errorf("Error. Unexpected keys %v.", keys)
+	}
+
+	advance(2 * time.Minute)
+	_, ok, _ = store.Get("a/2")
+	if ok {
+		t.Error("Error. Expected a/2 to have expired.")
+	}
+	keys, _ = store.Keys("")
+	if !reflect.DeepEqual(keys, []string{"a/1", "b/1"}) {
+		t.Errorf("Error. Unexpected keys %v.", keys)
+	}
+
+	err = store.Update("count", 0, func(value []byte, exists bool) ([]byte, error) {
+		if exists {
+			t.Error("Error. Expected count not to exist.")
+		}
+		return []byte("1"), nil
+	})
+	if err != nil {
+		t.Errorf("Error. Was not expecting an error, but found %v", err)
+	}
+	abort := &Error{"abort"}
+	err = store.Update("count", 0, func(value []byte, exists bool) ([]byte, error) {
+		return []byte("2"), abort
+	})
+	if err != abort {
+		t.Errorf("Error. Expected the update to abort. Got %v.", err)
+	}
+	value, _, _ = store.Get("count")
+	if string(value) != "1" {
+		t.Errorf("Error. Expected \"1\". Got %q.", value)
+	}
+	store.Update("count", 0, func(value []byte, exists bool) ([]byte, error) {
+		return nil, nil
+	})
+	_, ok, _ = store.Get("count")
+	if ok {
+		t.Error("Error. Expected count to be deleted.")
+	}
+
+	store.Delete("a/1")
+	store.Delete("a/1")
+	_, ok, _ = store.Get("a/1")
+	if ok {
+		t.Error("Error. Expected a/1 to be deleted.")
+	}
+}
+
+type clock struct {
+	now time.Time
+}
+
+func (c *clock) Now() time.Time          { return c.now }
+func (c *clock) Advance(d time.Duration) { c.now = c.now.Add(d) }
+
+func TestMemory(t *testing.T) {
+	c := &clock{time.Now()}
+	store := NewMemory()
+	store.now = c.Now
+	testStore(store, c.Advance, t)
+}
@@ -0,0 +1,193 @@
+package brain
+
+import (
+	"database/sql"
+	"fmt"
+	"regexp"
+	"sync"
+	"time"
+)
+
+// Dialect describes the differences between SQL databases that matter to the
+// SQL store: how query parameters are written, and the column type used for
+// binary values.
+type Dialect struct {
+	Placeholder func(n int) string
+	BlobType    string
+}
+
+var (
+	// SQLite is the Dialect for SQLite.
+	SQLite = Dialect{questionMark, "BLOB"}
+	// MySQL is the Dialect for MySQL.
+	MySQL = Dialect{questionMark, "LONGBLOB"}
+	// Postgres is the Dialect for PostgreSQL.
+	Postgres = Dialect{dollar, "BYTEA"}
+
+	tableName = regexp.MustCompile(`\A[A-Za-z_][A-Za-z0-9_]*\z`)
+)
+
+func questionMark(_ int) string { return "?" }
+func dollar(n int) string       { return fmt.Sprintf("$%d", n) }
+
+// SQL is a Store which keeps its contents in a table of a SQL database. The
+// table has the columns "k" (the key), "v" (the value) and "expires_at" (the
+// expiration time as a unix timestamp, or 0 for keys that do not expire).
+// Expiration times are rounded up to the second, so keys may outlive their TTL
+// by up to a second, but never expire early.
+//
+// Update runs in a transaction, and updates are also serialized within the
+// process, so updates are atomic as long as only one process writes to the
+// table.
+type SQL struct {
+	db      *sql.DB
+	table   string
+	dialect Dialect
+	mu      sync.Mutex
+	now     func() time.Time
+}
+
+// NewSQL constructs a SQL store which uses the given table of db, creating
+// the table if it does not exist.
+func NewSQL(db *sql.DB, table string, dialect Dialect) (*SQL, error) {
+	if !tableName.MatchString(table) {
+		return nil, &Error{fmt.Sprintf("invalid table name %q", table)}
+	}
+	store := &SQL{db: db, table: table, dialect: dialect, now: time.Now}
+	_, err := db.Exec(fmt.Sprintf(
+		"CREATE TABLE IF NOT EXISTS %s (k VARCHAR(255) PRIMARY KEY, v %s NOT NULL, expires_at BIGINT NOT NULL)",
+		table, dialect.BlobType,
+	))
+	if err != nil {
+		return nil, err
+	}
+	return store, nil
+}
+
+type queryer interface {
+	QueryRow(query string, args ...interface{}) *sql.Row
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+// Get returns the value of key.
+func (store *SQL) Get(key string) ([]byte, bool, error) {
+	return store.get(store.db, key)
+}
+
+// Set stores value under key.
+func (store *SQL) Set(key string, value []byte, ttl time.Duration) error {
+	tx, err := store.db.Begin()
+	if err != nil {
+		return err
+	}
+	if err := store.set(tx, key, value, ttl); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// Delete removes key.
+func (store *SQL) Delete(key string) error {
+	_, err := store.db.Exec(store.query("DELETE FROM %s WHERE k = %s", 1), key)
+	return err
+}
+
+// Update atomically replaces the value of key with the result of fn.
+func (store *SQL) Update(key string, ttl time.Duration, fn UpdateFunc) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	tx, err := store.db.Begin()
+	if err != nil {
+		return err
+	}
+	value, ok, err := store.get(tx, key)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	updated, err := fn(value, ok)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	if updated == nil {
+		_, err = tx.Exec(store.query("DELETE FROM %s WHERE k = %s", 1), key)
+	} else {
+		err = store.set(tx, key, updated, ttl)
+	}
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// Keys returns the keys which begin with prefix, in sorted order.
+func (store *SQL) Keys(prefix string) ([]string, error) {
+	rows, err := store.db.Query(
+		store.query("SELECT k FROM %s WHERE (expires_at = 0 OR expires_at > %s) ORDER BY k", 1),
+		store.now().Unix(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var keys []string
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			return nil, err
+		}
+		// filter in Go, since LIKE would require escaping prefix
+		if len(key) >= len(prefix) && key[:len(prefix)] == prefix {
+			keys = append(keys, key)
+		}
+	}
+	return keys, rows.Err()
+}
+
+func (store *SQL) get(q queryer, key string) ([]byte, bool, error) {
+	var value []byte
+	var expiresAt int64
+	err := q.QueryRow(
+		store.query("SELECT v, expires_at FROM %s WHERE k = %s", 1), key,
+	).Scan(&value, &expiresAt)
+	if err == sql.ErrNoRows {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	if expiresAt != 0 && store.now().Unix() >= expiresAt {
+		return nil, false, nil
+	}
+	return value, true, nil
+}
+
+func (store *SQL) set(q queryer, key string, value []byte, ttl time.Duration) error {
+	var expiresAt int64
+	if expires := expiry(ttl, store.now()); !expires.IsZero() {
+		expiresAt = expires.Unix()
+		if expires.Nanosecond() > 0 {
+			expiresAt++
+		}
+	}
+	if _, err := q.Exec(store.query("DELETE FROM %s WHERE k = %s", 1), key); err != nil {
+		return err
+	}
+	_, err := q.Exec(
+		store.query("INSERT INTO %s (k, v, expires_at) VALUES (%s, %s, %s)", 3),
+		key, value, expiresAt,
+	)
+	return err
+}
+
+// query fills in the table name and n placeholders in format.
+func (store *SQL) query(format string, n int) string {
+	args := []interface{}{store.table}
+	for i := 1; i <= n; i++ {
+		args = append(args, store.dialect.Placeholder(i))
+	}
+	return fmt.Sprintf(format, args...)
+}
@@ -0,0 +1,220 @@
+package brain
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSQL(t *testing.T) {
+	for _, dialect := range []Dialect{SQLite, Postgres} {
+		store := openSQL(t, dialect)
+		c := &clock{time.Now()}
+		store.now = c.Now
+		testStore(store, c.Advance, t)
+	}
+}
+
+func TestSQLSubsecondTTL(t *testing.T) {
+	store := openSQL(t, SQLite)
+	c := &clock{time.Unix(10, 300*int64(time.Millisecond))}
+	store.now = c.Now
+	store.Set("flash", []byte("now you see me"), 500*time.Millisecond)
+	if _, ok, _ := store.Get("flash"); !ok {
+		t.Error("Error. Expected flash not to expire before its TTL.")
+	}
+	if keys, _ := store.Keys(""); len(keys) != 1 {
+		t.Errorf("Error. Expected flash to be listed. Got %v.", keys)
+	}
+	c.Advance(time.Second)
+	if _, ok, _ := store.Get("flash"); ok {
+		t.Error("Error. Expected flash to have expired.")
+	}
+}
+
+func TestNewSQLInvalidTable(t *testing.T) {
+	if _, err := NewSQL(nil, "brain; DROP TABLE users", SQLite); err == nil {
+		t.Error("Error. Expected an error for an invalid table name.")
+	}
+}
+
+func TestPrivate_query(t *testing.T) {
+	var tests = []struct {
+		dialect  Dialect
+		expected string
+	}{
+		{SQLite, "INSERT INTO brain (k, v, expires_at) VALUES (?, ?, ?)"},
+		{Postgres, "INSERT INTO brain (k, v, expires_at) VALUES ($1, $2, $3)"},
+	}
+
+	for _, test := range tests {
+		store := &SQL{table: "brain", dialect: test.dialect}
+		actual := store.query("INSERT INTO %s (k, v, expires_at) VALUES (%s, %s, %s)", 3)
+		if actual != test.expected {
+			t.Errorf("Error. Expected %q. Got %q.", test.expected, actual)
+		}
+	}
+}
+
+// openSQL opens a SQL store on a new, empty fake database.
+func openSQL(t *testing.T, dialect Dialect) *SQL {
+	fakeDatabases.Lock()
+	fakeDatabases.n++
+	name := strconv.Itoa(fakeDatabases.n)
+	fakeDatabases.Unlock()
+	db, err := sql.Open("brainfake", name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store, err := NewSQL(db, "brain", dialect)
+	if err != nil {
+		t.Fatalf("Error. Was not expecting an error, but found %v", err)
+	}
+	return store
+}
+
+func init() {
+	sql.Register("brainfake", fakeDriver{})
+}
+
+// fakeDriver is a database/sql driver for an in-memory database which
+// understands only the queries the SQL store makes.
+type fakeDriver struct{}
+
+var fakeDatabases = struct {
+	sync.Mutex
+	n  int
+	db map[string]*fakeDB
+}{db: make(map[string]*fakeDB)}
+
+func (fakeDriver) Open(name string) (driver.Conn, error) {
+	fakeDatabases.Lock()
+	defer fakeDatabases.Unlock()
+	db, ok := fakeDatabases.db[name]
+	if !ok {
+		db = &fakeDB{rows: make(map[string]fakeRow)}
+		fakeDatabases.db[name] = db
+	}
+	return &fakeConn{db: db}, nil
+}
+
+type fakeDB struct {
+	mu   sync.Mutex
+	rows map[string]fakeRow
+}
+
+type fakeRow struct {
+	value     []byte
+	expiresAt int64
+}
+
+type fakeConn struct {
+	db       *fakeDB
+	snapshot map[string]fakeRow
+}
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return &fakeStmt{c, query}, nil
+}
+
+func (c *fakeConn) Close() error { return nil }
+
+// Begin snapshots the database, so that Rollback can restore it.
+func (c *fakeConn) Begin() (driver.Tx, error) {
+	c.db.mu.Lock()
+	defer c.db.mu.Unlock()
+	c.snapshot = make(map[string]fakeRow, len(c.db.rows))
+	for key, row := range c.db.rows {
+		c.snapshot[key] = row
+	}
+	return c, nil
+}
+
+func (c *fakeConn) Commit() error {
+	c.snapshot = nil
+	return nil
+}
+
+func (c *fakeConn) Rollback() error {
+	c.db.mu.Lock()
+	defer c.db.mu.Unlock()
+	c.db.rows = c.snapshot
+	c.snapshot = nil
+	return nil
+}
+
+type fakeStmt struct {
+	conn  *fakeConn
+	query string
+}
+
+func (s *fakeStmt) Close() error  { return nil }
+func (s *fakeStmt) NumInput() int { return -1 }
+
+func (s *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	db := s.conn.db
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	switch {
+	case strings.HasPrefix(s.query, "CREATE TABLE"):
+	case strings.HasPrefix(s.query, "DELETE FROM"):
+		delete(db.rows, args[0].(string))
+	case strings.HasPrefix(s.query, "INSERT INTO"):
+		db.rows[args[0].(string)] = fakeRow{args[1].([]byte), args[2].(int64)}
+	default:
+		return nil, &Error{"unexpected query " + s.query}
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func (s *fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	db := s.conn.db
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	rows := &fakeRows{}
+	switch {
+	case strings.HasPrefix(s.query, "SELECT v, expires_at"):
+		rows.columns = []string{"v", "expires_at"}
+		if row, ok := db.rows[args[0].(string)]; ok {
+			rows.values = [][]driver.Value{{row.value, row.expiresAt}}
+		}
+	case strings.HasPrefix(s.query, "SELECT k"):
+		rows.columns = []string{"k"}
+		var keys []string
+		for key, row := range db.rows {
+			if row.expiresAt == 0 || row.expiresAt > args[0].(int64) {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			rows.values = append(rows.values, []driver.Value{key})
+		}
+	default:
+		return nil, &Error{"unexpected query " + s.query}
+	}
+	return rows, nil
+}
+
+type fakeRows struct {
+	columns []string
+	values  [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string { return r.columns }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.values[0])
+	r.values = r.values[1:]
+	return nil
+}
@@ -0,0 +1,302 @@
+/*
+Package config loads the configuration for a github.com/ajm188/slack bot from a
+file and the environment, and uses it to construct the bot and load its
+plugins.
+
+Configuration files may be written in YAML, TOML or JSON. Here is an example in
+YAML:
+
+	slack:
+	  token: xoxb-my-token
+	  aliases: [jarvis]
+	  prefixes: ["!"]
+	brain:
+	  path: /var/lib/mybot/brain.json
+	admin:
+	  addr: localhost:8080
+	plugins:
+	  github:
+	    access_token: my-github-token
+
+Every setting can be overridden by an environment variable, whose name is
+EnvPrefix followed by the setting's key in upper case, with dots replaced by
+underscores. For example, with the default prefix the Slack token can be set
+with SLACKBOT_SLACK_TOKEN, and the GitHub access token with
+SLACKBOT_PLUGINS_GITHUB_ACCESS_TOKEN. Lists are given as comma-separated
+values.
+
+Each section under "plugins" configures (and enables) the plugin of the same
+name. The plugins which may be configured are passed to NewBot:
+
+	cfg, err := config.Load("mybot.yaml")
+	if err != nil {
+		log.Fatal(err)
+	}
+	bot, err := cfg.NewBot(config.Plugin{
+		Name:   "github",
+		Plugin: func() slack.Plugin { return github.New() },
+		Config: func() interface{} { return &github.Settings{} },
+	})
+
+A plugin's section is decoded into the value returned by its Config function,
+which is passed to the plugin's Load function. Fields of the value are named
+by their `config` struct tag (or their lower-cased name if they have no tag),
+and fields tagged with ",required" must be set. If the value implements
+slack.Validator, it is validated as well.
+
+Every error caused by a bad setting is an *Error, and names the offending key.
+*/
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/ajm188/slack"
+	"github.com/ajm188/slack/brain"
+	"gopkg.in/yaml.v2"
+)
+
+// DefaultEnvPrefix is the prefix of the environment variables which override
+// settings, unless a Loader is given a different one.
+const DefaultEnvPrefix = "SLACKBOT"
+
+// Format is the format of a configuration file.
+type Format string
+
+// The supported configuration formats.
+const (
+	YAML Format = "yaml"
+	TOML Format = "toml"
+	JSON Format = "json"
+)
+
+// Config is the configuration of a bot.
+//
+// Plugins holds the raw section of each plugin. The sections are decoded by
+// NewBot, once the types of their configuration are known.
+type Config struct {
+	Slack   Slack                  `config:"slack"`
+	Brain   Brain                  `config:"brain"`
+	Admin   Admin                  `config:"admin"`
+	Plugins map[string]interface{} `config:"plugins"`
+
+	loader *Loader
+}
+
+// Slack configures the bot's connection to Slack. See the documentation of
+// slack.Bot for Aliases and Prefixes. If Record is set, the bot records the
+// events it receives and the messages it sends to the file at that path (see
+// slack.Recorder). If RedactText is set, the text of messages is left out of
+// the bot's logs (see slack.Redaction).
+type Slack struct {
+	Token      string   `config:"token,required"`
+	Aliases    []string `config:"aliases"`
+	Prefixes   []string `config:"prefixes"`
+	Record     string   `config:"record"`
+	RedactText bool     `config:"redact_text"`
+}
+
+// Brain configures where the bot stores data. If Path is set, the bot uses a
+// brain.File at that path. Otherwise, it keeps its data in memory.
+type Brain struct {
+	Path string `config:"path"`
+}
+
+// Admin configures the bot's admin server. If Addr is set, the bot serves its
+// health checks and state at that address (see slack.Bot.AdminHandler).
+type Admin struct {
+	Addr string `config:"addr"`
+}
+
+// Plugin describes a plugin which can be configured by a section under
+// "plugins". Name is the key of the section, and should match the name of the
+// plugin. Config returns a pointer to a new configuration value for the plugin
+// to decode the section into; it may be nil if the plugin takes no
+// configuration.
+type Plugin struct {
+	Name   string
+	Plugin slack.PluginFunc
+	Config func() interface{}
+}
+
+// Loader loads configuration.
+//
+// EnvPrefix is the prefix of the environment variables which override
+// settings, and defaults to DefaultEnvPrefix. LookupEnv is used to read them,
+// and defaults to os.LookupEnv.
+type Loader struct {
+	EnvPrefix string
+	LookupEnv func(string) (string, bool)
+}
+
+// Load reads the configuration file at path with the default Loader.
+func Load(path string) (*Config, error) {
+	return (&Loader{}).Load(path)
+}
+
+// Load reads the configuration file at path. The format of the file is
+// determined by its extension, which must be one of ".yaml", ".yml", ".toml"
+// or ".json".
+func (loader *Loader) Load(path string) (*Config, error) {
+	var format Format
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		format = YAML
+	case ".toml":
+		format = TOML
+	case ".json":
+		format = JSON
+	default:
+		return nil, &Error{"", fmt.Sprintf("%s: unknown configuration format", path)}
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := loader.Parse(data, format)
+	if err, ok := err.(*Error); ok && err.Key == "" {
+		err.Message = path + ": " + err.Message
+	}
+	return cfg, err
+}
+
+// Parse reads configuration in the given format from data, and applies any
+// environment variable overrides. data may be empty, in which case the
+// configuration comes entirely from the environment.
+func (loader *Loader) Parse(data []byte, format Format) (*Config, error) {
+	raw := make(map[string]interface{})
+	if len(data) > 0 {
+		var err error
+		switch format {
+		case YAML:
+			var m map[interface{}]interface{}
+			err = yaml.Unmarshal(data, &m)
+			if m != nil {
+				raw = normalize(m).(map[string]interface{})
+			}
+		case TOML:
+			_, err = toml.Decode(string(data), &raw)
+		case JSON:
+			err = json.Unmarshal(data, &raw)
+		default:
+			return nil, &Error{"", fmt.Sprintf("unknown configuration format %q", format)}
+		}
+		if err != nil {
+			return nil, &Error{"", fmt.Sprintf("could not parse %s: %v", format, err)}
+		}
+	}
+
+	cfg := &Config{loader: loader}
+	if err := loader.apply(normalize(raw), cfg, ""); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// apply decodes raw into target, applies environment overrides, and checks
+// that required settings are present.
+func (loader *Loader) apply(raw interface{}, target interface{}, key string) error {
+	v := reflect.ValueOf(target).Elem()
+	if err := decode(raw, v, key); err != nil {
+		return err
+	}
+	if err := loader.env(v, key); err != nil {
+		return err
+	}
+	return loader.required(v, key)
+}
+
+func (loader *Loader) envName(key string) string {
+	prefix := loader.EnvPrefix
+	if prefix == "" {
+		prefix = DefaultEnvPrefix
+	}
+	name := strings.NewReplacer(".", "_", "-", "_").Replace(key)
+	return prefix + "_" + strings.ToUpper(name)
+}
+
+func (loader *Loader) lookupEnv(name string) (string, bool) {
+	if loader.LookupEnv != nil {
+		return loader.LookupEnv(name)
+	}
+	return os.LookupEnv(name)
+}
+
+// NewBot constructs a bot from the configuration, and loads the plugins which
+// have a section under "plugins". plugins lists the plugins which may be
+// configured; a section for any other plugin is an error.
+func (cfg *Config) NewBot(plugins ...Plugin) (*slack.Bot, error) {
+	loader := cfg.loader
+	if loader == nil {
+		loader = &Loader{}
+	}
+
+	bot := slack.NewBot(cfg.Slack.Token)
+	bot.Aliases = cfg.Slack.Aliases
+	bot.Prefixes = cfg.Slack.Prefixes
+	bot.Redact.Text = cfg.Slack.RedactText
+	bot.AdminAddr = cfg.Admin.Addr
+	if cfg.Brain.Path != "" {
+		store, err := brain.NewFile(cfg.Brain.Path)
+		if err != nil {
+			return nil, &Error{"brain.path", err.Error()}
+		}
+		bot.Brain = store
+	}
+	if cfg.Slack.Record != "" {
+		recorder, err := slack.CreateRecording(cfg.Slack.Record)
+		if err != nil {
+			return nil, &Error{"slack.record", err.Error()}
+		}
+		bot.Recorder = recorder
+	}
+
+	available := make(map[string]Plugin, len(plugins))
+	for _, plugin := range plugins {
+		available[plugin.Name] = plugin
+	}
+	names := make([]string, 0, len(cfg.Plugins))
+	for name := range cfg.Plugins {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	specs := make([]slack.PluginSpec, 0, len(names))
+	for _, name := range names {
+		key := join("plugins", name)
+		plugin, ok := available[name]
+		if !ok {
+			return nil, &Error{key, "unknown plugin"}
+		}
+		spec := slack.PluginSpec{Plugin: plugin.Plugin}
+		if plugin.Config == nil {
+			if section, ok := cfg.Plugins[name].(map[string]interface{}); ok && len(section) > 0 {
+				return nil, &Error{key, "plugin takes no configuration"}
+			}
+		} else {
+			pluginConfig := plugin.Config()
+			if err := loader.apply(cfg.Plugins[name], pluginConfig, key); err != nil {
+				return nil, err
+			}
+			if validator, ok := pluginConfig.(slack.Validator); ok {
+				if err := validator.Validate(); err != nil {
+					return nil, &Error{key, err.Error()}
+				}
+			}
+			spec.Args = []interface{}{pluginConfig}
+		}
+		specs = append(specs, spec)
+	}
+	if err := bot.UsePlugins(specs...); err != nil {
+		return nil, err
+	}
+	return bot, nil
+}
@@ -0,0 +1,356 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ajm188/slack"
+)
+
+type testPluginConfig struct {
+	Token   string        `config:"token,required"`
+	Repos   []string      `config:"repos"`
+	Timeout time.Duration `config:"timeout"`
+	Retries int           `config:"retries"`
+	Verbose bool
+	Limits  map[string]int `config:"limits"`
+}
+
+func (config *testPluginConfig) Validate() error {
+	if config.Retries < 0 {
+		return &Error{"", "retries must not be negative"}
+	}
+	return nil
+}
+
+type testPlugin struct {
+	name   string
+	config *testPluginConfig
+	loaded bool
+}
+
+func (p *testPlugin) Name() string  { return p.name }
+func (p *testPlugin) CanLoad() bool { return true }
+func (p *testPlugin) Load(bot *slack.Bot, args ...interface{}) error {
+	p.loaded = true
+	for _, arg := range args {
+		if config, ok := arg.(*testPluginConfig); ok {
+			p.config = config
+		}
+	}
+	return nil
+}
+
+func env(vars map[string]string) *Loader {
+	return &Loader{
+		LookupEnv: func(name string) (string, bool) {
+			value, ok := vars[name]
+			return value, ok
+		},
+	}
+}
+
+const (
+	testYAML = `
+slack:
+  token: xoxb-token
+  aliases: [jarvis]
+  prefixes: ["!"]
+plugins:
+  test:
+    token: abc
+    repos: [a/b, c/d]
+    timeout: 5s
+    retries: 3
+    verbose: true
+`
+	testTOML = `
+[slack]
+token = "xoxb-token"
+aliases = ["jarvis"]
+prefixes = ["!"]
+
+[plugins.test]
+token = "abc"
+repos = ["a/b", "c/d"]
+timeout = "5s"
+retries = 3
+verbose = true
+`
+	testJSON = `{
+  "slack": {"token": "xoxb-token", "aliases": ["jarvis"], "prefixes": ["!"]},
+  "plugins": {
+    "test": {"token": "abc", "repos": ["a/b", "c/d"], "timeout": "5s", "retries": 3, "verbose": true}
+  }
+}`
+)
+
+func TestParseFormats(t *testing.T) {
+	tests := []struct {
+		data   string
+		format Format
+	}{
+		{testYAML, YAML},
+		{testTOML, TOML},
+		{testJSON, JSON},
+	}
+	expected := &testPluginConfig{
+		Token:   "abc",
+		Repos:   []string{"a/b", "c/d"},
+		Timeout: 5 * time.Second,
+		Retries: 3,
+		Verbose: true,
+	}
+
+	for _, test := range tests {
+		cfg, err := env(nil).Parse([]byte(test.data), test.format)
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", test.format, err)
+			continue
+		}
+		if cfg.Slack.Token != "xoxb-token" ||
+			!reflect.DeepEqual(cfg.Slack.Aliases, []string{"jarvis"}) ||
+			!reflect.DeepEqual(cfg.Slack.Prefixes, []string{"!"}) {
+			t.Errorf("%s: unexpected slack config %+v", test.format, cfg.Slack)
+		}
+
+		plugin := &testPlugin{name: "test"}
+		bot, err := cfg.NewBot(Plugin{
+			Name:   "test",
+			Plugin: func() slack.Plugin { return plugin },
+			Config: func() interface{} { return &testPluginConfig{} },
+		})
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", test.format, err)
+			continue
+		}
+		if bot.Token != "xoxb-token" || bot.Aliases[0] != "jarvis" || bot.Prefixes[0] != "!" {
+			t.Errorf("%s: bot was not configured", test.format)
+		}
+		if !plugin.loaded || !reflect.DeepEqual(plugin.config, expected) {
+			t.Errorf("%s: expected plugin config %+v, got %+v", test.format, expected, plugin.config)
+		}
+	}
+}
+
+func TestEnvOverrides(t *testing.T) {
+	loader := env(map[string]string{
+		"SLACKBOT_SLACK_TOKEN":          "from-env",
+		"SLACKBOT_SLACK_ALIASES":        "jarvis, friday",
+		"SLACKBOT_PLUGINS_TEST_TIMEOUT": "1m",
+		"SLACKBOT_PLUGINS_TEST_RETRIES": "7",
+		"SLACKBOT_PLUGINS_TEST_VERBOSE": "false",
+	})
+	cfg, err := loader.Parse([]byte(testYAML), YAML)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Slack.Token != "from-env" {
+		t.Errorf("expected token from env, got %s", cfg.Slack.Token)
+	}
+	if !reflect.DeepEqual(cfg.Slack.Aliases, []string{"jarvis", "friday"}) {
+		t.Errorf("unexpected aliases %v", cfg.Slack.Aliases)
+	}
+
+	plugin := &testPlugin{name: "test"}
+	_, err = cfg.NewBot(Plugin{
+		Name:   "test",
+		Plugin: func() slack.Plugin { return plugin },
+		Config: func() interface{} { return &testPluginConfig{} },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plugin.config.Timeout != time.Minute || plugin.config.Retries != 7 || plugin.config.Verbose {
+		t.Errorf("env overrides were not applied: %+v", plugin.config)
+	}
+
+	// The configuration may come entirely from the environment.
+	cfg, err = (&Loader{
+		EnvPrefix: "MYBOT",
+		LookupEnv: loader.LookupEnv,
+	}).Parse(nil, YAML)
+	if err == nil || err.(*Error).Key != "slack.token" {
+		t.Errorf("expected missing slack.token, got %v", err)
+	}
+	cfg, err = env(map[string]string{"SLACKBOT_SLACK_TOKEN": "t"}).Parse(nil, YAML)
+	if err != nil || cfg.Slack.Token != "t" {
+		t.Errorf("expected token from env, got %v", err)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	tests := []struct {
+		data string
+		vars map[string]string
+		key  string
+	}{
+		{"slack: {token: t, tokn: u}", nil, "slack.tokn"},
+		{"slack: {token: 1}", nil, "slack.token"},
+		{"slack: {token: t, aliases: jarvis}", nil, "slack.aliases"},
+		{"slack: {token: t, aliases: [jarvis, 2]}", nil, "slack.aliases[1]"},
+		{"slack: {}", nil, "slack.token"},
+		{"slack: []", nil, "slack"},
+		{"slack: {token: t}\nplugins: {nope: {}}", nil, "plugins.nope"},
+		{"slack: {token: t}\nplugins: {test: {}}", nil, "plugins.test.token"},
+		{"slack: {token: t}\nplugins: {test: {token: a, timeout: 5}}", nil, "plugins.test.timeout"},
+		{"slack: {token: t}\nplugins: {test: {token: a, retries: 1.5}}", nil, "plugins.test.retries"},
+		{"slack: {token: t}\nplugins: {test: {token: a, retries: -1}}", nil, "plugins.test"},
+		{"slack: {token: t}\nplugins: {test: {token: a, limits: {x: y}}}", nil, "plugins.test.limits.x"},
+		{
+			"slack: {token: t}\nplugins: {test: {token: a}}",
+			map[string]string{"SLACKBOT_PLUGINS_TEST_RETRIES": "lots"},
+			"plugins.test.retries",
+		},
+	}
+
+	for _, test := range tests {
+		cfg, err := env(test.vars).Parse([]byte(test.data), YAML)
+		if err == nil {
+			_, err = cfg.NewBot(Plugin{
+				Name:   "test",
+				Plugin: func() slack.Plugin { return &testPlugin{name: "test"} },
+				Config: func() interface{} { return &testPluginConfig{} },
+			})
+		}
+		configErr, ok := err.(*Error)
+		if !ok {
+			t.Errorf("%q: expected *Error, got %v", test.data, err)
+			continue
+		}
+		if configErr.Key != test.key {
+			t.Errorf("%q: expected error for %s, got %v", test.data, test.key, err)
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"bot.yml":  testYAML,
+		"bot.toml": testTOML,
+		"bot.json": testJSON,
+		"bot.ini":  "",
+		"bad.json": "{",
+	}
+	for name, data := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(data), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, name := range []string{"bot.yml", "bot.toml", "bot.json"} {
+		cfg, err := Load(filepath.Join(dir, name))
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", name, err)
+		} else if cfg.Slack.Token == "" {
+			t.Errorf("%s: expected a token", name)
+		}
+	}
+	for _, name := range []string{"bot.ini", "bad.json", "missing.json"} {
+		if _, err := Load(filepath.Join(dir, name)); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestBrain(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "brain.json")
+	cfg, err := env(nil).Parse([]byte(`{"slack": {"token": "t"}, "brain": {"path": "`+path+`"}}`), JSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bot, err := cfg.NewBot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := bot.Brain.Set("key", []byte("value"), 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("expected the brain to be saved to %s: %v", path, err)
+	}
+}
+
+func TestRecord(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "session.jsonl")
+	cfg, err := env(nil).Parse([]byte(`{"slack": {"token": "t", "record": "`+path+`"}}`), JSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bot, err := cfg.NewBot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bot.Recorder == nil {
+		t.Fatal("expected the bot to have a recorder")
+	}
+	defer bot.Recorder.Close()
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("expected the recording to be created at %s: %v", path, err)
+	}
+
+	cfg.Slack.Record = filepath.Join(dir, "missing", "session.jsonl")
+	if _, err := cfg.NewBot(); err == nil {
+		t.Error("expected an error for a recording in a missing directory")
+	}
+}
+
+func TestRedactText(t *testing.T) {
+	var tests = []struct {
+		input    string
+		expected bool
+	}{
+		{`{"slack": {"token": "t"}}`, false},
+		{`{"slack": {"token": "t", "redact_text": true}}`, true},
+	}
+
+	for _, test := range tests {
+		cfg, err := env(nil).Parse([]byte(test.input), JSON)
+		if err != nil {
+			t.Fatal(err)
+		}
+		bot, err := cfg.NewBot()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if bot.Redact.Text != test.expected || !bot.Redact.Tokens {
+			t.Errorf("expected text redaction %v and token redaction for %s, got %+v", test.expected, test.input, bot.Redact)
+		}
+	}
+}
+
+func TestAdmin(t *testing.T) {
+	cfg, err := env(nil).Parse([]byte(`{"slack": {"token": "t"}, "admin": {"addr": "localhost:8080"}}`), JSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bot, err := cfg.NewBot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bot.AdminAddr != "localhost:8080" {
+		t.Errorf("expected the admin address to be localhost:8080, got %q", bot.AdminAddr)
+	}
+}
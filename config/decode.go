@@ -0,0 +1,356 @@
+package config
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// fieldTag parses the `config` tag of a struct field. The tag holds the key
+// name, optionally followed by ",required". Fields without a tag use their
+// name in lower case, and fields tagged "-" are skipped.
+func fieldTag(field reflect.StructField) (name string, required bool, skip bool) {
+	if field.PkgPath != "" {
+		return "", false, true
+	}
+	tag := field.Tag.Get("config")
+	if tag == "-" {
+		return "", false, true
+	}
+	parts := strings.Split(tag, ",")
+	name = parts[0]
+	if name == "" {
+		name = strings.ToLower(field.Name)
+	}
+	for _, option := range parts[1:] {
+		if option == "required" {
+			required = true
+		}
+	}
+	return name, required, false
+}
+
+func join(key, name string) string {
+	if key == "" {
+		return name
+	}
+	return key + "." + name
+}
+
+// normalize converts the values produced by the YAML and TOML parsers into the
+// types produced by encoding/json, so that decode only has to deal with one
+// set of types.
+func normalize(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for key, elem := range v {
+			m[fmt.Sprint(key)] = normalize(elem)
+		}
+		return m
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for key, elem := range v {
+			m[key] = normalize(elem)
+		}
+		return m
+	case []map[string]interface{}:
+		s := make([]interface{}, len(v))
+		for i, elem := range v {
+			s[i] = normalize(elem)
+		}
+		return s
+	case []interface{}:
+		s := make([]interface{}, len(v))
+		for i, elem := range v {
+			s[i] = normalize(elem)
+		}
+		return s
+	}
+	return value
+}
+
+// decode stores value in target, which must be settable. key is the path of
+// value in the configuration, and is used to report errors.
+func decode(value interface{}, target reflect.Value, key string) error {
+	if value == nil {
+		return nil
+	}
+	if target.Type() == durationType {
+		return decodeDuration(value, target, key)
+	}
+
+	switch target.Kind() {
+	case reflect.Ptr:
+		if target.IsNil() {
+			target.Set(reflect.New(target.Type().Elem()))
+		}
+		return decode(value, target.Elem(), key)
+	case reflect.Interface:
+		if target.NumMethod() != 0 {
+			return &Error{key, fmt.Sprintf("cannot decode into %s", target.Type())}
+		}
+		target.Set(reflect.ValueOf(value))
+	case reflect.Struct:
+		m, ok := value.(map[string]interface{})
+		if !ok {
+			return typeError(key, "a table", value)
+		}
+		return decodeStruct(m, target, key)
+	case reflect.Map:
+		m, ok := value.(map[string]interface{})
+		if !ok {
+			return typeError(key, "a table", value)
+		}
+		if target.Type().Key().Kind() != reflect.String {
+			return &Error{key, fmt.Sprintf("cannot decode into %s", target.Type())}
+		}
+		if target.IsNil() {
+			target.Set(reflect.MakeMap(target.Type()))
+		}
+		for name, elem := range m {
+			v := reflect.New(target.Type().Elem()).Elem()
+			if err := decode(elem, v, join(key, name)); err != nil {
+				return err
+			}
+			target.SetMapIndex(reflect.ValueOf(name).Convert(target.Type().Key()), v)
+		}
+	case reflect.Slice:
+		s, ok := value.([]interface{})
+		if !ok {
+			return typeError(key, "a list", value)
+		}
+		slice := reflect.MakeSlice(target.Type(), len(s), len(s))
+		for i, elem := range s {
+			if err := decode(elem, slice.Index(i), fmt.Sprintf("%s[%d]", key, i)); err != nil {
+				return err
+			}
+		}
+		target.Set(slice)
+	case reflect.String:
+		s, ok := value.(string)
+		if !ok {
+			return typeError(key, "a string", value)
+		}
+		target.SetString(s)
+	case reflect.Bool:
+		b, ok := value.(bool)
+		if !ok {
+			return typeError(key, "a boolean", value)
+		}
+		target.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, ok := number(value)
+		if !ok || n != math.Trunc(n) {
+			return typeError(key, "an integer", value)
+		}
+		if target.OverflowInt(int64(n)) {
+			return &Error{key, fmt.Sprintf("%v is out of range", value)}
+		}
+		target.SetInt(int64(n))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, ok := number(value)
+		if !ok || n != math.Trunc(n) || n < 0 {
+			return typeError(key, "a non-negative integer", value)
+		}
+		if target.OverflowUint(uint64(n)) {
+			return &Error{key, fmt.Sprintf("%v is out of range", value)}
+		}
+		target.SetUint(uint64(n))
+	case reflect.Float32, reflect.Float64:
+		n, ok := number(value)
+		if !ok {
+			return typeError(key, "a number", value)
+		}
+		target.SetFloat(n)
+	default:
+		return &Error{key, fmt.Sprintf("cannot decode into %s", target.Type())}
+	}
+	return nil
+}
+
+func decodeStruct(m map[string]interface{}, target reflect.Value, key string) error {
+	fields := make(map[string]reflect.Value)
+	t := target.Type()
+	for i := 0; i < t.NumField(); i++ {
+		name, _, skip := fieldTag(t.Field(i))
+		if !skip {
+			fields[name] = target.Field(i)
+		}
+	}
+	// Decode in a fixed order, so that the same mistake always produces the
+	// same error.
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		field, ok := fields[name]
+		if !ok {
+			return &Error{join(key, name), "unknown key"}
+		}
+		if err := decode(m[name], field, join(key, name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func decodeDuration(value interface{}, target reflect.Value, key string) error {
+	s, ok := value.(string)
+	if !ok {
+		return typeError(key, `a duration (like "30s")`, value)
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return &Error{key, fmt.Sprintf("invalid duration %q", s)}
+	}
+	target.SetInt(int64(d))
+	return nil
+}
+
+func number(value interface{}) (float64, bool) {
+	switch n := value.(type) {
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	case float64:
+		return n, true
+	}
+	return 0, false
+}
+
+func typeError(key, expected string, value interface{}) error {
+	return &Error{key, fmt.Sprintf("expected %s, got %s", expected, describe(value))}
+}
+
+func describe(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return fmt.Sprintf("%q", v)
+	case map[string]interface{}:
+		return "a table"
+	case []interface{}:
+		return "a list"
+	}
+	return fmt.Sprint(value)
+}
+
+// env applies environment variable overrides to target. Each scalar field
+// (and each list of strings, given as a comma-separated value) can be
+// overridden by the variable named by envName.
+func (loader *Loader) env(target reflect.Value, key string) error {
+	switch target.Kind() {
+	case reflect.Ptr:
+		if target.IsNil() {
+			return nil
+		}
+		return loader.env(target.Elem(), key)
+	case reflect.Struct:
+		if target.Type() == durationType {
+			break
+		}
+		t := target.Type()
+		for i := 0; i < t.NumField(); i++ {
+			name, _, skip := fieldTag(t.Field(i))
+			if skip {
+				continue
+			}
+			if err := loader.env(target.Field(i), join(key, name)); err != nil {
+				return err
+			}
+		}
+		return nil
+	case reflect.Map, reflect.Interface, reflect.Array:
+		return nil
+	case reflect.Slice:
+		if target.Type().Elem().Kind() != reflect.String {
+			return nil
+		}
+	}
+
+	name := loader.envName(key)
+	s, ok := loader.lookupEnv(name)
+	if !ok {
+		return nil
+	}
+	value, err := parseEnv(s, target.Type())
+	if err != nil {
+		return &Error{key, fmt.Sprintf("invalid value %q in %s", s, name)}
+	}
+	return decode(value, target, key)
+}
+
+// parseEnv converts the value of an environment variable to the type decode
+// expects for t.
+func parseEnv(s string, t reflect.Type) (interface{}, error) {
+	if t == durationType {
+		return s, nil
+	}
+	switch t.Kind() {
+	case reflect.Slice:
+		var list []interface{}
+		for _, elem := range strings.Split(s, ",") {
+			if elem = strings.TrimSpace(elem); elem != "" {
+				list = append(list, elem)
+			}
+		}
+		return list, nil
+	case reflect.Bool:
+		return strconv.ParseBool(s)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return strconv.ParseFloat(s, 64)
+	}
+	return s, nil
+}
+
+// required returns an error for the first required field of target that is
+// not set.
+func (loader *Loader) required(target reflect.Value, key string) error {
+	switch target.Kind() {
+	case reflect.Ptr:
+		if target.IsNil() {
+			return nil
+		}
+		return loader.required(target.Elem(), key)
+	case reflect.Struct:
+		t := target.Type()
+		for i := 0; i < t.NumField(); i++ {
+			name, required, skip := fieldTag(t.Field(i))
+			if skip {
+				continue
+			}
+			field := target.Field(i)
+			if required && isZero(field) {
+				return &Error{
+					join(key, name),
+					fmt.Sprintf("is required (set it in the file or with %s)", loader.envName(join(key, name))),
+				}
+			}
+			if err := loader.required(field, join(key, name)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func isZero(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface, reflect.Map, reflect.Slice:
+		return v.IsNil() || (v.Kind() != reflect.Ptr && v.Kind() != reflect.Interface && v.Len() == 0)
+	}
+	return reflect.DeepEqual(v.Interface(), reflect.Zero(v.Type()).Interface())
+}
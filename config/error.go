@@ -0,0 +1,16 @@
+package config
+
+// Error is returned when a configuration cannot be loaded. Key is the dotted
+// path of the offending key (like "plugins.github.access_token"), and is empty
+// if the error does not concern a particular key.
+type Error struct {
+	Key     string
+	Message string
+}
+
+func (err *Error) Error() string {
+	if err.Key == "" {
+		return err.Message
+	}
+	return err.Key + ": " + err.Message
+}
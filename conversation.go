@@ -0,0 +1,269 @@
+package slack
+
+import (
+	"regexp"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrConversationInProgress is returned by StartConversation when the
+	// user already has a conversation in progress with the bot in the same
+	// channel or thread.
+	ErrConversationInProgress = &Error{"conversation already in progress"}
+	// ErrConversationTimeout is returned by Await when no reply arrives in
+	// time. The conversation is expired when this happens.
+	ErrConversationTimeout = &Error{"conversation timed out"}
+	// ErrConversationCanceled is returned by Await when the conversation is
+	// canceled.
+	ErrConversationCanceled = &Error{"conversation canceled"}
+	// ErrConversationEnded is returned by Await when the conversation has
+	// already ended.
+	ErrConversationEnded = &Error{"conversation ended"}
+)
+
+// Conversation is a multi-turn dialog between the bot and a single user in a
+// single channel (or thread). It allows a handler to ask a question and wait
+// for the user's next message.
+//
+// Since BotActions run on the bot's main loop, a handler must not wait on a
+// Conversation directly, or the bot would never read the reply. Instead, start
+// the conversation in the handler and wait on it from a new goroutine, using
+// Bot.Send to send messages:
+//
+//	bot.Respond("^incident$", func(b *slack.Bot, event map[string]interface{}) (*slack.Message, slack.Status) {
+//		convo, err := b.StartConversation(event)
+//		if err != nil {
+//			return nil, slack.Continue
+//		}
+//		go func() {
+//			defer convo.End()
+//			b.Send(convo.Reply("Which environment?"))
+//			answer, err := convo.Await(time.Minute)
+//			if err != nil {
+//				return
+//			}
+//			// ...
+//		}()
+//		return nil, slack.Continue
+//	})
+//
+// While the conversation is waiting for a reply, the user's messages in the
+// conversation's channel (or thread) are delivered to the conversation instead
+// of the bot's other handlers.
+type Conversation struct {
+	User    string
+	Channel string
+	Thread  string
+
+	key     string
+	manager *conversations
+	replies chan map[string]interface{}
+	done    chan struct{}
+
+	mu      sync.Mutex
+	waiting bool
+	pattern *regexp.Regexp
+	err     error
+}
+
+// StartConversation starts a conversation with the user who sent event, in
+// the channel (and thread, if any) that event was sent in. It returns
+// ErrConversationInProgress if that user already has a conversation in
+// progress there.
+func (bot *Bot) StartConversation(event map[string]interface{}) (*Conversation, error) {
+	user, _ := event["user"].(string)
+	channel, _ := event["channel"].(string)
+	thread, _ := event["thread_ts"].(string)
+	return bot.conversations.start(user, channel, thread)
+}
+
+// ConversationFor returns the conversation in progress that event belongs to,
+// if there is one. This can be used, for example, to cancel a conversation
+// when the user asks to.
+func (bot *Bot) ConversationFor(event map[string]interface{}) (*Conversation, bool) {
+	user, _ := event["user"].(string)
+	channel, _ := event["channel"].(string)
+	thread, _ := event["thread_ts"].(string)
+	return bot.conversations.get(conversationKey(user, channel, thread))
+}
+
+// Reply constructs a Message to send to the conversation's channel (or
+// thread).
+func (convo *Conversation) Reply(text string) *Message {
+	message := NewMessage(text, convo.Channel)
+	if convo.Thread != "" {
+		message.InThread(convo.Thread)
+	}
+	return message
+}
+
+// Await waits for the user's next message in the conversation, and returns
+// the message event. If no message arrives within timeout, the conversation
+// expires and ErrConversationTimeout is returned. If the conversation is
+// canceled or ended, the corresponding error is returned.
+func (convo *Conversation) Await(timeout time.Duration) (map[string]interface{}, error) {
+	return convo.AwaitMatch(nil, timeout)
+}
+
+// AwaitMatch functions exactly as Await, but only waits for a message whose
+// text matches re. Messages that do not match are handled by the bot's other
+// handlers as usual.
+func (convo *Conversation) AwaitMatch(re *regexp.Regexp, timeout time.Duration) (map[string]interface{}, error) {
+	convo.mu.Lock()
+	if convo.err != nil {
+		err := convo.err
+		convo.mu.Unlock()
+		return nil, err
+	}
+	convo.waiting = true
+	convo.pattern = re
+	convo.mu.Unlock()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case event := <-convo.replies:
+		return event, nil
+	case <-convo.done:
+		return nil, convo.finalErr()
+	case <-timer.C:
+		convo.mu.Lock()
+		if !convo.waiting {
+			// a reply was delivered, or the conversation finished, just as
+			// the timer fired
+			err := convo.err
+			convo.mu.Unlock()
+			select {
+			case event := <-convo.replies:
+				return event, nil
+			default:
+				return nil, err
+			}
+		}
+		convo.mu.Unlock()
+		convo.finish(ErrConversationTimeout)
+		return nil, ErrConversationTimeout
+	}
+}
+
+// Cancel cancels the conversation. Any pending Await returns
+// ErrConversationCanceled.
+func (convo *Conversation) Cancel() {
+	convo.finish(ErrConversationCanceled)
+}
+
+// End ends the conversation, allowing a new conversation to be started with
+// the same user in the same channel.
+func (convo *Conversation) End() {
+	convo.finish(ErrConversationEnded)
+}
+
+// Done returns a channel which is closed when the conversation ends for any
+// reason.
+func (convo *Conversation) Done() <-chan struct{} {
+	return convo.done
+}
+
+func (convo *Conversation) finish(err error) {
+	convo.mu.Lock()
+	if convo.err != nil {
+		convo.mu.Unlock()
+		return
+	}
+	convo.err = err
+	convo.waiting = false
+	close(convo.done)
+	convo.mu.Unlock()
+	convo.manager.remove(convo)
+}
+
+func (convo *Conversation) finalErr() error {
+	convo.mu.Lock()
+	defer convo.mu.Unlock()
+	return convo.err
+}
+
+// offer delivers event to the conversation if it is waiting for a message
+// which event satisfies.
+func (convo *Conversation) offer(event map[string]interface{}, text string) bool {
+	convo.mu.Lock()
+	defer convo.mu.Unlock()
+	if !convo.waiting || convo.err != nil {
+		return false
+	}
+	if convo.pattern != nil && !convo.pattern.MatchString(text) {
+		return false
+	}
+	convo.waiting = false
+	convo.replies <- event
+	return true
+}
+
+type conversations struct {
+	mu     sync.Mutex
+	active map[string]*Conversation
+}
+
+func newConversations() *conversations {
+	return &conversations{active: make(map[string]*Conversation)}
+}
+
+func conversationKey(user, channel, thread string) string {
+	return user + "/" + channel + "/" + thread
+}
+
+func (manager *conversations) start(user, channel, thread string) (*Conversation, error) {
+	key := conversationKey(user, channel, thread)
+	manager.mu.Lock()
+	defer manager.mu.Unlock()
+	if _, ok := manager.active[key]; ok {
+		return nil, ErrConversationInProgress
+	}
+	convo := &Conversation{
+		User:    user,
+		Channel: channel,
+		Thread:  thread,
+		key:     key,
+		manager: manager,
+		replies: make(chan map[string]interface{}, 1),
+		done:    make(chan struct{}),
+	}
+	manager.active[key] = convo
+	return convo, nil
+}
+
+func (manager *conversations) get(key string) (*Conversation, bool) {
+	manager.mu.Lock()
+	defer manager.mu.Unlock()
+	convo, ok := manager.active[key]
+	return convo, ok
+}
+
+func (manager *conversations) remove(convo *Conversation) {
+	manager.mu.Lock()
+	defer manager.mu.Unlock()
+	if manager.active[convo.key] == convo {
+		delete(manager.active, convo.key)
+	}
+}
+
+// deliver hands a "message" event to the conversation it belongs to, if that
+// conversation is waiting for it. It returns true if the event was consumed.
+func (manager *conversations) deliver(event map[string]interface{}) bool {
+	if eventType, _ := event["type"].(string); eventType != "message" {
+		return false
+	}
+	if _, hasSubtype := event["subtype"]; hasSubtype {
+		return false
+	}
+	user, _ := event["user"].(string)
+	channel, _ := event["channel"].(string)
+	thread, _ := event["thread_ts"].(string)
+	text, _ := event["text"].(string)
+	convo, ok := manager.get(conversationKey(user, channel, thread))
+	if !ok {
+		return false
+	}
+	return convo.offer(event, text)
+}
@@ -0,0 +1,142 @@
+package slack
+
+import (
+	"regexp"
+	"testing"
+	"time"
+)
+
+func messageEvent(user, channel, text string) map[string]interface{} {
+	return map[string]interface{}{
+		"type":    "message",
+		"user":    user,
+		"channel": channel,
+		"text":    text,
+	}
+}
+
+func TestStartConversation(t *testing.T) {
+	bot := NewBot("token")
+	convo, err := bot.StartConversation(messageEvent("U1", "C1", "incident"))
+	if err != nil {
+		t.Fatalf("Error. Was not expecting an error, but found %v", err)
+	}
+	_, err = bot.StartConversation(messageEvent("U1", "C1", "incident"))
+	assert(err == ErrConversationInProgress, t)
+
+	found, ok := bot.ConversationFor(messageEvent("U1", "C1", ""))
+	assert(ok && found == convo, t)
+	_, ok = bot.ConversationFor(messageEvent("U2", "C1", ""))
+	assert(!ok, t)
+
+	convo.End()
+	_, ok = bot.ConversationFor(messageEvent("U1", "C1", ""))
+	assert(!ok, t)
+	_, err = bot.StartConversation(messageEvent("U1", "C1", "incident"))
+	assert(err == nil, t)
+}
+
+func TestConversationAwait(t *testing.T) {
+	bot := NewBot("token")
+	fired := 0
+	bot.OnEvent("message", func(self *Bot, event map[string]interface{}) (*Message, Status) {
+		fired++
+		return shutdownHandler(self, event)
+	})
+	convo, _ := bot.StartConversation(messageEvent("U1", "C1", "incident"))
+
+	// not waiting yet, so messages go to the regular handlers
+	assert(len(bot.handle(messageEvent("U1", "C1", "too early"))) == 1, t)
+	assert(fired == 1, t)
+
+	replies := make(chan map[string]interface{})
+	go func() {
+		event, err := convo.Await(time.Second)
+		if err != nil {
+			t.Errorf("Error. Was not expecting an error, but found %v", err)
+		}
+		replies <- event
+	}()
+
+	awaitWaiting(convo)
+	assert(len(bot.handle(messageEvent("U1", "C1", "prod"))) == 0, t)
+	assert(fired == 1, t)
+	event := <-replies
+	assert(event["text"] == "prod", t)
+
+	// other users are unaffected
+	assert(len(bot.handle(messageEvent("U2", "C1", "prod"))) == 1, t)
+	assert(fired == 2, t)
+}
+
+func TestConversationAwaitMatch(t *testing.T) {
+	bot := NewBot("token")
+	bot.OnEvent("message", shutdownHandler)
+	convo, _ := bot.StartConversation(messageEvent("U1", "C1", "incident"))
+
+	replies := make(chan map[string]interface{})
+	go func() {
+		event, _ := convo.AwaitMatch(regexp.MustCompile("^(prod|staging)$"), time.Second)
+		replies <- event
+	}()
+
+	awaitWaiting(convo)
+	assert(len(bot.handle(messageEvent("U1", "C1", "what?"))) == 1, t)
+	assert(len(bot.handle(messageEvent("U1", "C1", "staging"))) == 0, t)
+	event := <-replies
+	assert(event["text"] == "staging", t)
+}
+
+func TestConversationAwaitTimeout(t *testing.T) {
+	bot := NewBot("token")
+	convo, _ := bot.StartConversation(messageEvent("U1", "C1", "incident"))
+	_, err := convo.Await(time.Millisecond)
+	assert(err == ErrConversationTimeout, t)
+	_, ok := bot.ConversationFor(messageEvent("U1", "C1", ""))
+	assert(!ok, t)
+	_, err = convo.Await(time.Second)
+	assert(err == ErrConversationTimeout, t)
+}
+
+func TestConversationCancel(t *testing.T) {
+	bot := NewBot("token")
+	convo, _ := bot.StartConversation(messageEvent("U1", "C1", "incident"))
+	errs := make(chan error)
+	go func() {
+		_, err := convo.Await(time.Minute)
+		errs <- err
+	}()
+	convo.Cancel()
+	assert(<-errs == ErrConversationCanceled, t)
+	<-convo.Done()
+}
+
+func TestConversationReply(t *testing.T) {
+	bot := NewBot("token")
+	event := messageEvent("U1", "C1", "incident")
+	event["thread_ts"] = "123.456"
+	convo, _ := bot.StartConversation(event)
+	compareMessages(
+		map[string]string{
+			"type":      "message",
+			"channel":   "C1",
+			"text":      "Which environment?",
+			"thread_ts": "123.456",
+		},
+		convo.Reply("Which environment?").toMap(),
+		t,
+	)
+}
+
+// awaitWaiting returns once convo is waiting for a message.
+func awaitWaiting(convo *Conversation) {
+	for {
+		convo.mu.Lock()
+		waiting := convo.waiting
+		convo.mu.Unlock()
+		if waiting {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -1,48 +1,156 @@
 package slack
 
 import (
+	"fmt"
 	"net/url"
-
-	log "github.com/Sirupsen/logrus"
+	"sort"
+	"strings"
+	"sync"
 )
 
 // DirectMessage constructs a Message object to send to userID. The channel is
-// obtained by opening a direct message with the given user.
-func (bot *Bot) DirectMessage(userID, text string) *Message {
+// obtained by opening a direct message with the given user, and the error
+// says why if it could not be opened.
+func (bot *Bot) DirectMessage(userID, text string) (*Message, error) {
 	dm, err := bot.OpenDirectMessage(userID)
 	if err != nil {
-		return nil
+		return nil, err
+	}
+	return NewMessage(text, dm), nil
+}
+
+// GroupMessage constructs a Message object to send to the group direct
+// message between the bot and all of userIDs.
+func (bot *Bot) GroupMessage(text string, userIDs ...string) (*Message, error) {
+	channel, err := bot.OpenGroupMessage(userIDs...)
+	if err != nil {
+		return nil, err
 	}
-	return NewMessage(text, dm)
+	return NewMessage(text, channel), nil
 }
 
 // OpenDirectMessage opens a direct message with the given user. The newly
 // created channel ID is returned, or an error in the case of error. If a
 // direct message is already open between the bot and userID, then the API call
 // still succeeds and returns the ID for the pre-existing direct message.
+//
+// The channel ID is cached, so the Web API is only called the first time the
+// bot opens a direct message with each user.
 func (bot *Bot) OpenDirectMessage(userID string) (string, error) {
+	if channel, ok := bot.dms.get(userID); ok {
+		return channel, nil
+	}
 	payload, err := bot.Call("im.open", url.Values{"user": []string{userID}})
 	if err != nil {
 		return "", err
 	}
-	success := payload["ok"].(bool)
-	if !success {
+	if ok, _ := payload["ok"].(bool); !ok {
 		var nick string
 		user, ok := bot.Users[userID]
 		if ok {
 			nick = user.Nick
 		}
-		logOpenDMError(payload, userID, nick)
-		return "", &Error{"could not open direct message"}
+		bot.logOpenDMError(payload, userID, nick)
+		return "", &Error{fmt.Sprintf("could not open direct message with %s: %v", userID, payload["error"])}
+	}
+	channel, _ := payload["channel"].(map[string]interface{})
+	id, _ := channel["id"].(string)
+	if id == "" {
+		bot.logOpenDMError(payload, userID, "")
+		return "", &Error{fmt.Sprintf("could not open direct message with %s: no channel in response", userID)}
+	}
+	bot.dms.set(userID, id)
+	return id, nil
+}
+
+// OpenGroupMessage opens a group direct message between the bot and all of
+// userIDs, with the conversations.open Web API method, and returns its channel
+// ID. Like OpenDirectMessage, it returns the existing channel if there is one,
+// and caches the channel ID for the same set of users.
+func (bot *Bot) OpenGroupMessage(userIDs ...string) (string, error) {
+	if len(userIDs) == 0 {
+		return "", &Error{"could not open group message: no users"}
+	}
+	users := append([]string(nil), userIDs...)
+	sort.Strings(users)
+	key := strings.Join(users, ",")
+	if channel, ok := bot.dms.get(key); ok {
+		return channel, nil
+	}
+	payload, err := bot.Call("conversations.open", url.Values{"users": []string{key}})
+	if err != nil {
+		return "", err
+	}
+	if ok, _ := payload["ok"].(bool); !ok {
+		bot.logOpenDMError(payload, key, "")
+		return "", &Error{fmt.Sprintf("could not open group message with %s: %v", key, payload["error"])}
+	}
+	channel, _ := payload["channel"].(map[string]interface{})
+	id, _ := channel["id"].(string)
+	if id == "" {
+		bot.logOpenDMError(payload, key, "")
+		return "", &Error{fmt.Sprintf("could not open group message with %s: no channel in response", key)}
+	}
+	bot.dms.set(key, id)
+	return id, nil
+}
+
+// OnDirectMessage registers handler to fire on every message users send the
+// bot in a direct message. Messages with a subtype (like edits) and the bot's
+// own messages are ignored. Group direct messages are not direct messages;
+// use Listen or OnEvent for those.
+func (bot *Bot) OnDirectMessage(handler BotAction) *Handler {
+	closure := func(self *Bot, event map[string]interface{}) (*Message, Status) {
+		channel, _ := event["channel"].(string)
+		if !isDirectMessageChannel(channel) {
+			return nil, Continue
+		}
+		if _, ok := event["subtype"]; ok {
+			return nil, Continue
+		}
+		if user, _ := event["user"].(string); user == "" || user == bot.ID {
+			return nil, Continue
+		}
+		return handler(self, event)
 	}
-	channel := payload["channel"].(map[string]interface{})
-	return channel["id"].(string), nil
+	return bot.OnEvent("message", closure)
 }
 
-func logOpenDMError(payload map[string]interface{}, userID, nick string) {
-	log.WithFields(log.Fields{
+func (bot *Bot) logOpenDMError(payload map[string]interface{}, userID, nick string) {
+	bot.Log().Error("Failed to open direct message.", Fields{
 		"payload": payload,
 		"userID":  userID,
 		"nick":    nick,
-	}).Error("Failed to open direct message.")
+	})
+}
+
+// directMessages caches the IDs of the direct message channels the bot has
+// opened, by user ID, or by the comma-separated, sorted IDs of the users of a
+// group direct message.
+type directMessages struct {
+	mu       sync.Mutex
+	channels map[string]string
+}
+
+func newDirectMessages() *directMessages {
+	return &directMessages{channels: make(map[string]string)}
+}
+
+func (dms *directMessages) get(key string) (string, bool) {
+	dms.mu.Lock()
+	defer dms.mu.Unlock()
+	channel, ok := dms.channels[key]
+	return channel, ok
+}
+
+func (dms *directMessages) set(key, channel string) {
+	dms.mu.Lock()
+	defer dms.mu.Unlock()
+	dms.channels[key] = channel
+}
+
+func (dms *directMessages) len() int {
+	dms.mu.Lock()
+	defer dms.mu.Unlock()
+	return len(dms.channels)
 }
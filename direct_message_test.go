@@ -1,6 +1,8 @@
 package slack
 
 import (
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	log "github.com/Sirupsen/logrus"
@@ -9,13 +11,69 @@ import (
 func TestDirectMessage_failsWithNoToken(t *testing.T) {
 	log.SetLevel(log.PanicLevel)
 	bot := NewBot("")
-	message := bot.DirectMessage("andrew", "hello")
+	message, err := bot.DirectMessage("andrew", "hello")
 	if message != nil {
 		t.Errorf("Error. Expecting nil. Got %v.", message)
 	}
+	if err == nil {
+		t.Error("Error. Expecting error. Got nil")
+	}
+}
+
+func TestOpenDirectMessage_malformedResponses(t *testing.T) {
+	log.SetLevel(log.PanicLevel)
+	responses := []string{
+		`{"ok": true}`,
+		`{"ok": true, "channel": "D1"}`,
+		`{"ok": true, "channel": {}}`,
+		`{"ok": true, "channel": {"id": 1}}`,
+	}
+
+	for _, response := range responses {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(response))
+		}))
+		bot := NewBot("token")
+		bot.APIURL = server.URL
+		if _, err := bot.OpenDirectMessage("U1"); err == nil {
+			t.Errorf("Error. Expected an error for %s.", response)
+		}
+		if _, err := bot.OpenGroupMessage("U1", "U2"); err == nil {
+			t.Errorf("Error. Expected an error opening a group message for %s.", response)
+		}
+		server.Close()
+		assert(bot.dms.len() == 0, t)
+	}
 }
 
 func TestPrivate_logOpenDMError(t *testing.T) {
 	log.SetLevel(log.PanicLevel)
-	logOpenDMError(nil, "", "") // smoke test that this doesn't panic
+	NewBot("token").logOpenDMError(nil, "", "") // smoke test that this doesn't panic
+}
+
+func TestOnDirectMessage(t *testing.T) {
+	var tests = []struct {
+		event map[string]interface{}
+		fired bool
+	}{
+		{map[string]interface{}{"type": "message", "channel": "D1", "user": "U1", "text": "hi"}, true},
+		{map[string]interface{}{"type": "message", "channel": "C1", "user": "U1", "text": "hi"}, false},
+		{map[string]interface{}{"type": "message", "channel": "G1", "user": "U1", "text": "hi"}, false},
+		{map[string]interface{}{"type": "message", "channel": "D1", "user": "UBOT", "text": "hi"}, false},
+		{map[string]interface{}{"type": "message", "channel": "D1", "subtype": "message_changed"}, false},
+	}
+
+	for _, test := range tests {
+		bot := NewBot("token")
+		bot.ID = "UBOT"
+		fired := false
+		bot.OnDirectMessage(func(_ *Bot, _ map[string]interface{}) (*Message, Status) {
+			fired = true
+			return nil, Continue
+		})
+		bot.Dispatch(test.event)
+		if fired != test.fired {
+			t.Errorf("Error. Expected %v for %v. Got %v.", test.fired, test.event, fired)
+		}
+	}
 }
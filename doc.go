@@ -56,6 +56,19 @@ calls any handlers that are registered to handle that kind of event. It then
 writes any non-nil responses into the websocket, and - depending on the various
 status values - may terminate or continue looping.
 
+All writes to the websocket are made by a single writer goroutine. Code running
+outside of the main loop (for example, in a goroutine started by a handler) can
+queue messages for the writer with Send. The writer gives each message the
+next ID on the connection, and Slack acknowledges each ID with the timestamp of
+the message; use SendAndWait to wait for the acknowledgement, or to find out
+that the message was rejected.
+
+Conversations
+
+Handlers are stateless, but a handler can start a Conversation with the user
+who triggered it in order to ask a question and wait for the user's next
+message. See the documentation on Conversation for an example.
+
 Events
 
 The Slack RTM API defines a large number of events, which are listed at
@@ -82,11 +95,49 @@ expression rather than a string pattern.
 Respond also takes a pattern and a BotAction, and only invokes the given
 handler if the message text "mentions" the bot, and the rest of the text
 matches the regular expression defined by the pattern. For a message to
-"mention" the bot, the message must begin with the bot's name. The leading "@"
-that is commonly used in Slack is optional, as is the trailing ": ". The text
-without the portion that was considered part of the "mention" is then compared
-against the pattern. Respond also has a variant, RespondRegexp, which does
-exactly what you would expect.
+"mention" the bot, the message must begin with the bot's name, one of its
+Aliases, one of its Prefixes, or a Slack mention of the bot (like "<@U1234>").
+The leading "@" that is commonly used in Slack is optional, as is the trailing
+":". A Slack mention of the bot anywhere else in the message also counts, and
+any message sent to the bot in a direct message is considered to mention it.
+The text without the portion that was considered part of the "mention" is then
+compared against the pattern. Respond also has a variant, RespondRegexp, which
+does exactly what you would expect.
+
+	bot.Aliases = []string{"jarvis"}
+	bot.Prefixes = []string{"!"}
+	// Fires on "mybot deploy", "jarvis: deploy", "!deploy", and "deploy" in a
+	// direct message with the bot.
+	bot.Respond("^deploy", deployHandler)
+
+Each of these methods returns a *Handler, which can be used to disable,
+replace or remove the handler while the bot is running:
+
+	h := bot.Listen("^ping", pingHandler)
+	h.Disable()
+	h.Enable()
+	h.Remove()
+
+Scheduled Jobs
+
+Jobs can be scheduled to run while the bot is running, using a cron
+expression, a fixed interval, or a one-time delay. A job may return a Message,
+which is sent through the same writer as the responses from handlers. Its
+context is cancelled when the bot shuts down:
+
+	bot.Scheduler.Add("standup", slack.MustCron("0 9 * * mon-fri"), func(ctx context.Context, b *slack.Bot) *slack.Message {
+		return slack.NewMessage("Standup time!", b.Channels["general"])
+	})
+
+Plugins
+
+Reusable functionality can be packaged as a Plugin and loaded with UsePlugin.
+Plugins may declare dependencies on other plugins, validate typed
+configuration, and hook into the bot's lifecycle; see the documentation on
+Plugin for details. A plugin passes the handlers it registers to Own, so that
+they belong to it. UsePlugins loads several plugins in dependency order, and
+Plugins lists the loaded plugins along with the handlers each owns.
+DisablePlugin and EnablePlugin toggle all of a plugin's handlers at once.
 
 Common BotActions
 
@@ -98,8 +149,11 @@ specified text. So, if a user named "@example" triggers the handler, the bot
 will say "@example: <text>".
 
 "React" creates a handler which will post a Slack reaction to a "message" event
-with the specified emoji name. Note that you do not need to put the colons
-around the emoji name, unlike what you would need to manually do in Slack to
-produce the emoji.
+with each of the specified emoji names. Note that you do not need to put the
+colons around the emoji name, unlike what you would need to manually do in Slack
+to produce the emoji. AddReaction, RemoveReaction and Reactions manage the
+reactions on any message, and report the errors Slack returns (like
+"already_reacted"). OnReactionAdded and OnReactionRemoved register handlers for
+reactions with a given emoji, in a given channel.
 */
 package slack
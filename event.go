@@ -1,23 +1,58 @@
 package slack
 
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
 type messageWrapper struct {
 	message *Message
 	status  Status
 }
 
-// OnEvent registers handler to fire on the given type of event.
-func (bot *Bot) OnEvent(event string, handler BotAction) {
+// Response is what a single handler returned for an event: the message to
+// send, which may be nil, and the Status.
+type Response struct {
+	Message *Message
+	Status  Status
+}
+
+// Dispatch fires the handlers registered for event, exactly as the bot's main
+// loop does, and returns their responses in order, without sending anything.
+// It is meant for testing handlers; see package
+// github.com/ajm188/slack/slacktest.
+func (bot *Bot) Dispatch(event map[string]interface{}) []Response {
+	wrappers := bot.handle(event)
+	responses := make([]Response, len(wrappers))
+	for i, wrapper := range wrappers {
+		responses[i] = Response{wrapper.message, wrapper.status}
+	}
+	return responses
+}
+
+// OnEvent registers handler to fire on the given type of event. The returned
+// Handler can be used to disable, replace or remove the handler later.
+func (bot *Bot) OnEvent(event string, handler BotAction) *Handler {
+	h := newHandler(bot, event, "", handler)
+	bot.handlersMu.Lock()
 	handlers, ok := bot.Handlers[event]
 	if !ok {
 		handlers = make([]BotAction, 0)
 	}
-	handlers = append(handlers, handler)
+	handlers = append(handlers, h.fire)
 	bot.Handlers[event] = handlers
+	bot.handles[h.key()] = append(bot.handles[h.key()], h)
+	bot.handlersMu.Unlock()
+	return h
 }
 
 // OnEventWithSubtype registers handler to fire on the given type and subtype
-// of event.
-func (bot *Bot) OnEventWithSubtype(event, subtype string, handler BotAction) {
+// of event. The returned Handler can be used to disable, replace or remove the
+// handler later.
+func (bot *Bot) OnEventWithSubtype(event, subtype string, handler BotAction) *Handler {
+	h := newHandler(bot, event, subtype, handler)
+	bot.handlersMu.Lock()
 	subtypeMap, ok := bot.Subhandlers[event]
 	if !ok {
 		subtypeMap = make(map[string]([]BotAction))
@@ -27,35 +62,84 @@ func (bot *Bot) OnEventWithSubtype(event, subtype string, handler BotAction) {
 	if !ok {
 		handlers = make([]BotAction, 0)
 	}
-	handlers = append(handlers, handler)
+	handlers = append(handlers, h.fire)
 	bot.Subhandlers[event][subtype] = handlers
+	bot.handles[h.key()] = append(bot.handles[h.key()], h)
+	bot.handlersMu.Unlock()
+	return h
 }
 
 func (bot *Bot) handle(event map[string]interface{}) (wrappers []messageWrapper) {
+	if bot.conversations.deliver(event) {
+		return
+	}
 	eventType, hasType := event["type"].(string)
 	eventSubtype, hasSubtype := event["subtype"].(string)
 
+	// Take a snapshot of the handlers, so that handlers can register and
+	// remove handlers without deadlocking.
+	var subhandlers, handlers []BotAction
+	bot.handlersMu.RLock()
 	if hasSubtype {
-		subhandlerMap, ok := bot.Subhandlers[eventType]
-		if ok {
-			subhandlers, ok := subhandlerMap[eventSubtype]
-			if ok {
-				for _, subhandler := range subhandlers {
-					message, status := subhandler(bot, event)
-					wrappers = append(wrappers,
-						messageWrapper{message, status})
-				}
-			}
-		}
+		subhandlers = bot.Subhandlers[eventType][eventSubtype]
 	}
 	if hasType {
-		handlers, ok := bot.Handlers[eventType]
-		if ok {
-			for _, handler := range handlers {
-				message, status := handler(bot, event)
-				wrappers = append(wrappers, messageWrapper{message, status})
-			}
-		}
+		handlers = bot.Handlers[eventType]
 	}
+	bot.handlersMu.RUnlock()
+
+	ctx, span := bot.StartSpan(context.Background(), "slack.event")
+	defer span.End()
+	span.SetAttribute("slack.event.type", eventType)
+	span.SetAttribute("slack.event.subtype", eventSubtype)
+	if channel, ok := event["channel"].(string); ok {
+		span.SetAttribute("slack.channel", channel)
+	}
+	span.SetAttribute("slack.handlers", len(subhandlers)+len(handlers))
+	// The handlers are given a copy of the event which carries its context,
+	// so the caller's event is left as it was.
+	event = copyEvent(event)
+	withContext(event, ctx)
+
+	for _, subhandler := range subhandlers {
+		message, status := bot.invoke(subhandler, eventType, eventSubtype, event)
+		wrappers = append(wrappers, messageWrapper{message, status})
+	}
+	for _, handler := range handlers {
+		message, status := bot.invoke(handler, eventType, "", event)
+		wrappers = append(wrappers, messageWrapper{message, status})
+	}
+	return
+}
+
+// invoke fires a handler registered for eventType and subtype, and measures
+// and traces it. While it runs, the context of event carries the handler's
+// span. A handler which panics is treated as returning no message and
+// Continue, so that one broken handler does not bring down the bot.
+func (bot *Bot) invoke(action BotAction, eventType, subtype string, event map[string]interface{}) (message *Message, status Status) {
+	ctx, span := bot.StartSpan(bot.Context(event), "slack.handler")
+	span.SetAttribute("slack.event.type", eventType)
+	span.SetAttribute("slack.event.subtype", subtype)
+	restore := withContext(event, ctx)
+	start := time.Now()
+	panicked := true
+	defer func() {
+		bot.metrics().HandlerInvoked(eventType, subtype, time.Since(start), panicked)
+		if panicked {
+			r := recover()
+			bot.Log().Error("Handler panicked.", Fields{
+				"event":   eventType,
+				"subtype": subtype,
+				"panic":   r,
+			})
+			span.RecordError(&Error{fmt.Sprintf("handler panicked: %v", r)})
+			message, status = nil, Continue
+		}
+		span.SetAttribute("slack.status", status.String())
+		restore()
+		span.End()
+	}()
+	message, status = action(bot, event)
+	panicked = false
 	return
 }
@@ -152,3 +152,17 @@ func TestPrivate_handle_noType(t *testing.T) {
 		t.Errorf("Error. Expecting 0 wrappers. Found %i.", len(wrappers))
 	}
 }
+
+func TestDispatch(t *testing.T) {
+	bot := NewBot("token")
+	message := NewMessage("hi", "C1")
+	bot.OnEvent("message", func(_ *Bot, _ map[string]interface{}) (*Message, Status) {
+		return message, Continue
+	})
+	bot.OnEvent("message", shutdownHandler)
+	responses := bot.Dispatch(map[string]interface{}{"type": "message"})
+	assert(len(responses) == 2, t)
+	assert(responses[0] == Response{message, Continue}, t)
+	assert(responses[1] == Response{shutdownMessage, Shutdown}, t)
+	assert(len(bot.outgoing) == 0, t)
+}
@@ -0,0 +1,134 @@
+/*
+Package format provides helpers for building and parsing text in Slack's
+message formatting language ("mrkdwn").
+
+Slack treats "&", "<" and ">" as control characters, so any text which did not
+come from your bot (such as text echoed back from a user's message) should be
+passed through Escape before it is sent. Otherwise, a user could cause the bot
+to post something like "<!channel>".
+
+	reply := fmt.Sprintf("%s said %s", format.User(userID), format.Escape(text))
+
+The builders in this package (User, Channel, Link, Date, etc) return text which
+is already escaped where necessary, so their results should not be escaped
+again. Bold, Italic, Strike and Quote are the exception: they wrap text which is
+already mrkdwn, so that they can be used around the other builders, and do not
+escape it. Text from elsewhere must be escaped before it is wrapped:
+
+	reply := format.Bold(format.Escape(title))
+*/
+package format
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	// BroadcastHere notifies every active member of a channel.
+	BroadcastHere = "<!here>"
+	// BroadcastChannel notifies every member of a channel.
+	BroadcastChannel = "<!channel>"
+	// BroadcastEveryone notifies every member of the team. It may only be
+	// used in the #general channel.
+	BroadcastEveryone = "<!everyone>"
+)
+
+var (
+	escaper   = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+	unescaper = strings.NewReplacer("&amp;", "&", "&lt;", "<", "&gt;", ">")
+)
+
+// Escape replaces the control characters "&", "<" and ">" with their HTML
+// entities, so that text is displayed literally by Slack.
+func Escape(text string) string {
+	return escaper.Replace(text)
+}
+
+// Unescape reverses Escape, replacing the HTML entities for "&", "<" and ">"
+// with the characters themselves.
+func Unescape(text string) string {
+	return unescaper.Replace(text)
+}
+
+// User returns a mention of the user with the given ID.
+func User(id string) string {
+	return fmt.Sprintf("<@%s>", id)
+}
+
+// Channel returns a link to the channel with the given ID.
+func Channel(id string) string {
+	return fmt.Sprintf("<#%s>", id)
+}
+
+// UserGroup returns a mention of the user group with the given ID.
+func UserGroup(id string) string {
+	return fmt.Sprintf("<!subteam^%s>", id)
+}
+
+// Special returns a special mention, such as "here" or "channel". See the
+// Broadcast constants for the common ones.
+func Special(keyword string) string {
+	return fmt.Sprintf("<!%s>", keyword)
+}
+
+// Link returns a link to url. If text is not empty, it is displayed in place
+// of the url.
+func Link(url, text string) string {
+	if text == "" {
+		return fmt.Sprintf("<%s>", Escape(url))
+	}
+	return fmt.Sprintf("<%s|%s>", Escape(url), Escape(text))
+}
+
+// Date returns text which Slack will display as t in the reader's own
+// timezone. layout is a Slack date format string such as "{date_short} at
+// {time}", and fallback is shown to clients which can't format the date.
+func Date(t time.Time, layout, fallback string) string {
+	return fmt.Sprintf("<!date^%d^%s|%s>", t.Unix(), layout, Escape(fallback))
+}
+
+// DateLink functions exactly as Date, but the displayed date links to url.
+func DateLink(t time.Time, layout, url, fallback string) string {
+	return fmt.Sprintf(
+		"<!date^%d^%s^%s|%s>",
+		t.Unix(), layout, Escape(url), Escape(fallback),
+	)
+}
+
+// Bold returns text in bold. text is mrkdwn, and is not escaped.
+func Bold(text string) string {
+	return fmt.Sprintf("*%s*", text)
+}
+
+// Italic returns text in italics. text is mrkdwn, and is not escaped.
+func Italic(text string) string {
+	return fmt.Sprintf("_%s_", text)
+}
+
+// Strike returns text with a strikethrough. text is mrkdwn, and is not
+// escaped.
+func Strike(text string) string {
+	return fmt.Sprintf("~%s~", text)
+}
+
+// Code returns text as inline code.
+func Code(text string) string {
+	return fmt.Sprintf("`%s`", Escape(text))
+}
+
+// CodeBlock returns text as a preformatted block of code.
+func CodeBlock(text string) string {
+	return fmt.Sprintf("```\n%s\n```", Escape(text))
+}
+
+// Quote returns text as a block quote. Every line of text is quoted. text is
+// mrkdwn, and is not escaped.
+func Quote(text string) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = "> " + line
+	}
+	return strings.Join(lines, "\n")
+}
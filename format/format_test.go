@@ -0,0 +1,61 @@
+package format
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEscape(t *testing.T) {
+	var tests = []struct {
+		text, expected string
+	}{
+		{"hello", "hello"},
+		{"a & b", "a &amp; b"},
+		{"<!channel>", "&lt;!channel&gt;"},
+		{"&lt;", "&amp;lt;"},
+	}
+
+	for _, test := range tests {
+		actual := Escape(test.text)
+		if actual != test.expected {
+			t.Errorf("Error. Expected %q. Got %q.", test.expected, actual)
+		}
+		if Unescape(actual) != test.text {
+			t.Errorf("Error. Expected %q to round trip. Got %q.",
+				test.text, Unescape(actual))
+		}
+	}
+}
+
+func TestBuilders(t *testing.T) {
+	date := time.Unix(1392734382, 0)
+	var tests = []struct {
+		actual, expected string
+	}{
+		{User("U1"), "<@U1>"},
+		{Channel("C1"), "<#C1>"},
+		{UserGroup("S1"), "<!subteam^S1>"},
+		{Special("here"), BroadcastHere},
+		{Link("https://example.com", ""), "<https://example.com>"},
+		{Link("https://example.com", "a <b>"), "<https://example.com|a &lt;b&gt;>"},
+		{Date(date, "{date}", "Feb 18"), "<!date^1392734382^{date}|Feb 18>"},
+		{
+			DateLink(date, "{date}", "https://example.com", "Feb 18"),
+			"<!date^1392734382^{date}^https://example.com|Feb 18>",
+		},
+		{Bold("hi"), "*hi*"},
+		{Bold(Link("https://example.com", "a&b")), "*<https://example.com|a&amp;b>*"},
+		{Bold(Escape("<!channel>")), "*&lt;!channel&gt;*"},
+		{Italic("hi"), "_hi_"},
+		{Strike("hi"), "~hi~"},
+		{Code("a<b"), "`a&lt;b`"},
+		{CodeBlock("x"), "```\nx\n```"},
+		{Quote("a\nb"), "> a\n> b"},
+	}
+
+	for _, test := range tests {
+		if test.actual != test.expected {
+			t.Errorf("Error. Expected %q. Got %q.", test.expected, test.actual)
+		}
+	}
+}
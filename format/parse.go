@@ -0,0 +1,113 @@
+package format
+
+import (
+	"bytes"
+	"strings"
+)
+
+// Kind identifies the type of an Entity.
+type Kind int
+
+const (
+	// UserEntity is a mention of a user, like "<@U1234>".
+	UserEntity Kind = iota
+	// ChannelEntity is a link to a channel, like "<#C1234>".
+	ChannelEntity
+	// UserGroupEntity is a mention of a user group, like "<!subteam^S1234>".
+	UserGroupEntity
+	// SpecialEntity is a special mention, like "<!here>".
+	SpecialEntity
+	// DateEntity is a formatted date, like "<!date^1392734382^{date}|...>".
+	DateEntity
+	// LinkEntity is a link to a url, like "<https://example.com>".
+	LinkEntity
+)
+
+// Entity is a reference to a user, channel, link, etc found in the text of a
+// message.
+//
+// ID is the ID of the user, channel or user group, the keyword of a special
+// mention, the url of a link, or the unix timestamp of a date. Label is the
+// text that was displayed for the entity, if the message included one. Offset
+// is the byte offset of the entity in the plain text returned by Parse, and
+// Length is the length of the entity's plain text.
+type Entity struct {
+	Kind   Kind
+	ID     string
+	Label  string
+	Offset int
+	Length int
+}
+
+// Parse converts the text of an incoming message into plain text, and returns
+// the entities that were referenced in the text. Users and user groups are
+// rendered as "@name", channels as "#name", dates as their fallback text, and
+// links as their label (or url, if they have no label). Escaped characters are
+// unescaped.
+func Parse(text string) (string, []Entity) {
+	var plain bytes.Buffer
+	var entities []Entity
+	for {
+		start := strings.IndexByte(text, '<')
+		if start < 0 {
+			break
+		}
+		end := strings.IndexByte(text[start:], '>')
+		if end < 0 {
+			break
+		}
+		end += start
+		plain.WriteString(Unescape(text[:start]))
+		entity, rendered := parseEntity(text[start+1 : end])
+		entity.Offset = plain.Len()
+		entity.Length = len(rendered)
+		plain.WriteString(rendered)
+		entities = append(entities, entity)
+		text = text[end+1:]
+	}
+	plain.WriteString(Unescape(text))
+	return plain.String(), entities
+}
+
+func parseEntity(inner string) (Entity, string) {
+	body, label := inner, ""
+	if i := strings.IndexByte(inner, '|'); i >= 0 {
+		body, label = inner[:i], Unescape(inner[i+1:])
+	}
+
+	switch {
+	case strings.HasPrefix(body, "@"):
+		return named(UserEntity, body[1:], label, "@")
+	case strings.HasPrefix(body, "#"):
+		return named(ChannelEntity, body[1:], label, "#")
+	case strings.HasPrefix(body, "!subteam^"):
+		return named(UserGroupEntity, body[len("!subteam^"):], label, "@")
+	case strings.HasPrefix(body, "!date^"):
+		parts := strings.SplitN(body[len("!date^"):], "^", 2)
+		return Entity{Kind: DateEntity, ID: parts[0], Label: label}, label
+	case strings.HasPrefix(body, "!"):
+		keyword := body[1:]
+		rendered := label
+		if rendered == "" {
+			rendered = "@" + keyword
+		}
+		return Entity{Kind: SpecialEntity, ID: keyword, Label: label}, rendered
+	}
+	url := Unescape(body)
+	rendered := label
+	if rendered == "" {
+		rendered = url
+	}
+	return Entity{Kind: LinkEntity, ID: url, Label: label}, rendered
+}
+
+func named(kind Kind, id, label, sigil string) (Entity, string) {
+	rendered := label
+	if rendered == "" {
+		rendered = id
+	}
+	if !strings.HasPrefix(rendered, sigil) {
+		rendered = sigil + rendered
+	}
+	return Entity{Kind: kind, ID: id, Label: label}, rendered
+}
@@ -0,0 +1,61 @@
+package format
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	var tests = []struct {
+		text             string
+		expectedText     string
+		expectedEntities []Entity
+	}{
+		{"hello", "hello", nil},
+		{"a &amp; b &lt;3", "a & b <3", nil},
+		{
+			"<@U1> hi",
+			"@U1 hi",
+			[]Entity{{UserEntity, "U1", "", 0, 3}},
+		},
+		{
+			"hi <@U1|bob>, see <#C1|general>",
+			"hi @bob, see #general",
+			[]Entity{
+				{UserEntity, "U1", "bob", 3, 4},
+				{ChannelEntity, "C1", "general", 13, 8},
+			},
+		},
+		{
+			"<!here> <!subteam^S1|@ops>",
+			"@here @ops",
+			[]Entity{
+				{SpecialEntity, "here", "", 0, 5},
+				{UserGroupEntity, "S1", "@ops", 6, 4},
+			},
+		},
+		{
+			"<!date^1392734382^{date}|Feb 18> &amp; <https://a.com?x=1&amp;y=2>",
+			"Feb 18 & https://a.com?x=1&y=2",
+			[]Entity{
+				{DateEntity, "1392734382", "Feb 18", 0, 6},
+				{LinkEntity, "https://a.com?x=1&y=2", "", 9, 21},
+			},
+		},
+		{
+			"<https://example.com|example> <unclosed",
+			"example <unclosed",
+			[]Entity{{LinkEntity, "https://example.com", "example", 0, 7}},
+		},
+	}
+
+	for _, test := range tests {
+		text, entities := Parse(test.text)
+		if text != test.expectedText {
+			t.Errorf("Error. Expected %q. Got %q.", test.expectedText, text)
+		}
+		if !reflect.DeepEqual(entities, test.expectedEntities) {
+			t.Errorf("Error. Expected %v. Got %v.", test.expectedEntities, entities)
+		}
+	}
+}
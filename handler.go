@@ -0,0 +1,110 @@
+package slack
+
+// Handler is a handle on a registered BotAction, returned by OnEvent and the
+// other registration methods. It can be used to disable, replace or remove the
+// handler while the bot is running. All of its methods are safe to call from
+// any goroutine, including from within a handler while the bot is dispatching
+// an event.
+type Handler struct {
+	Event   string
+	Subtype string
+
+	bot     *Bot
+	action  BotAction
+	enabled bool
+	removed bool
+}
+
+func newHandler(bot *Bot, event, subtype string, action BotAction) *Handler {
+	return &Handler{
+		Event:   event,
+		Subtype: subtype,
+		bot:     bot,
+		action:  action,
+		enabled: true,
+	}
+}
+
+func (h *Handler) key() string {
+	return h.Event + "/" + h.Subtype
+}
+
+// fire is the BotAction which is actually registered with the bot. It invokes
+// the handler's current action, if the handler is enabled.
+func (h *Handler) fire(bot *Bot, event map[string]interface{}) (*Message, Status) {
+	h.bot.handlersMu.RLock()
+	action, enabled := h.action, h.enabled
+	h.bot.handlersMu.RUnlock()
+	if !enabled {
+		return nil, Continue
+	}
+	return action(bot, event)
+}
+
+// Enable causes the handler to fire again after it was disabled. It has no
+// effect on a removed handler.
+func (h *Handler) Enable() {
+	h.bot.handlersMu.Lock()
+	defer h.bot.handlersMu.Unlock()
+	if !h.removed {
+		h.enabled = true
+	}
+}
+
+// Disable stops the handler from firing until it is enabled again.
+func (h *Handler) Disable() {
+	h.bot.handlersMu.Lock()
+	defer h.bot.handlersMu.Unlock()
+	h.enabled = false
+}
+
+// Enabled returns true if the handler will fire.
+func (h *Handler) Enabled() bool {
+	h.bot.handlersMu.RLock()
+	defer h.bot.handlersMu.RUnlock()
+	return h.enabled
+}
+
+// Replace swaps the BotAction which the handler invokes, keeping its place in
+// the order that handlers fire.
+func (h *Handler) Replace(action BotAction) {
+	h.bot.handlersMu.Lock()
+	defer h.bot.handlersMu.Unlock()
+	h.action = action
+}
+
+// Remove unregisters the handler. It returns false if the handler was already
+// removed. If the bot is dispatching an event when Remove is called, the
+// handler will not fire for the rest of that event.
+func (h *Handler) Remove() bool {
+	bot := h.bot
+	bot.handlersMu.Lock()
+	defer bot.handlersMu.Unlock()
+	if h.removed {
+		return false
+	}
+	h.removed = true
+	h.enabled = false
+
+	key := h.key()
+	var remaining []*Handler
+	for _, other := range bot.handles[key] {
+		if other != h {
+			remaining = append(remaining, other)
+		}
+	}
+	bot.handles[key] = remaining
+	// The registered handlers are authoritative, so the actions are rebuilt
+	// from them. A new slice is made so that snapshots taken by handle are
+	// unaffected.
+	actions := make([]BotAction, len(remaining))
+	for i, other := range remaining {
+		actions[i] = other.fire
+	}
+	if h.Subtype == "" {
+		bot.Handlers[h.Event] = actions
+	} else if subhandlers, ok := bot.Subhandlers[h.Event]; ok {
+		subhandlers[h.Subtype] = actions
+	}
+	return true
+}
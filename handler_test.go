@@ -0,0 +1,108 @@
+package slack
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestHandlerRemove(t *testing.T) {
+	bot := NewBot("token")
+	event := map[string]interface{}{"type": "message", "subtype": "channel_join"}
+	h1 := bot.OnEvent("message", shutdownHandler)
+	h2 := bot.OnEvent("message", shutdownHandler)
+	h3 := bot.OnEventWithSubtype("message", "channel_join", shutdownHandler)
+	assert(len(bot.handle(event)) == 3, t)
+
+	assert(h1.Remove(), t)
+	assert(!h1.Remove(), t)
+	assert(len(bot.Handlers["message"]) == 1, t)
+	assert(h3.Remove(), t)
+	assert(len(bot.Subhandlers["message"]["channel_join"]) == 0, t)
+	assert(len(bot.handle(event)) == 1, t)
+
+	// the remaining handler is still h2
+	h2.Disable()
+	wrappers := bot.handle(event)
+	assert(wrappers[0].message == nil, t)
+}
+
+func TestHandlerRemoveRebuildsActions(t *testing.T) {
+	bot := NewBot("token")
+	event := map[string]interface{}{"type": "message"}
+	h1 := bot.OnEvent("message", shutdownHandler)
+	h2 := bot.OnEvent("message", shutdownHandler)
+	h3 := bot.OnEvent("message", shutdownHandler)
+
+	actions := bot.Handlers["message"]
+	bot.Handlers["message"] = []BotAction{actions[2], actions[1]}
+	assert(h1.Remove(), t)
+	assert(len(bot.Handlers["message"]) == 2, t)
+
+	// the actions are h2's and h3's, in the order they were registered
+	h3.Disable()
+	wrappers := bot.handle(event)
+	assert(len(wrappers) == 2, t)
+	assert(wrappers[0].status == Shutdown && wrappers[1].status == Continue, t)
+	h3.Enable()
+	assert(h2.Remove() && h3.Remove(), t)
+	assert(len(bot.handle(event)) == 0, t)
+}
+
+func TestHandlerEnableDisableReplace(t *testing.T) {
+	bot := NewBot("token")
+	event := map[string]interface{}{"type": "message"}
+	h := bot.Listen("", shutdownHandler)
+	assert(h.Enabled(), t)
+
+	h.Disable()
+	assert(!h.Enabled(), t)
+	wrappers := bot.handle(event)
+	assert(len(wrappers) == 1 && wrappers[0].message == nil, t)
+	assert(wrappers[0].status == Continue, t)
+
+	h.Enable()
+	h.Replace(func(_ *Bot, _ map[string]interface{}) (*Message, Status) {
+		return nil, ShutdownNow
+	})
+	wrappers = bot.handle(event)
+	assert(wrappers[0].status == ShutdownNow, t)
+
+	h.Remove()
+	h.Enable()
+	assert(!h.Enabled(), t)
+}
+
+func TestHandlerRemoveDuringDispatch(t *testing.T) {
+	bot := NewBot("token")
+	event := map[string]interface{}{"type": "message"}
+	var self *Handler
+	self = bot.OnEvent("message", func(b *Bot, _ map[string]interface{}) (*Message, Status) {
+		self.Remove()
+		b.OnEvent("message", shutdownHandler)
+		return nil, Continue
+	})
+	assert(len(bot.handle(event)) == 1, t)
+	assert(len(bot.handle(event)) == 1, t)
+	assert(len(bot.Handlers["message"]) == 1, t)
+}
+
+func TestHandlerConcurrentModification(t *testing.T) {
+	bot := NewBot("token")
+	event := map[string]interface{}{"type": "message"}
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			h := bot.OnEvent("message", shutdownHandler)
+			h.Disable()
+			h.Remove()
+		}()
+		go func() {
+			defer wg.Done()
+			bot.handle(event)
+		}()
+	}
+	wg.Wait()
+	assert(len(bot.Handlers["message"]) == 0, t)
+}
@@ -0,0 +1,222 @@
+package slack
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// DefaultPingInterval is how often NewBot's bots ping Slack over the RTM
+// websocket.
+const DefaultPingInterval = 30 * time.Second
+
+// State is a snapshot of what a bot is doing, as its admin server serves it
+// at /debug/state.
+type State struct {
+	Name       string          `json:"name"`
+	ID         string          `json:"id"`
+	Connection ConnectionState `json:"connection"`
+	Plugins    []PluginInfo    `json:"plugins"`
+	// Handlers counts the handlers registered for each type of event, and
+	// for each "<type>/<subtype>".
+	Handlers map[string]int `json:"handlers"`
+	// Caches counts what the bot is holding on to: the users and channels it
+	// learned when it connected, the direct messages it has opened, its
+	// ongoing conversations, its scheduled jobs and the messages waiting to
+	// be sent.
+	Caches map[string]int `json:"caches"`
+}
+
+// ConnectionState describes a bot's connection to the RTM API. The times are
+// zero until the thing they describe first happens.
+type ConnectionState struct {
+	Connected   bool      `json:"connected"`
+	ConnectedAt time.Time `json:"connected_at"`
+	Hello       time.Time `json:"hello"`
+	LastEvent   time.Time `json:"last_event"`
+	LastPing    time.Time `json:"last_ping"`
+	LastPong    time.Time `json:"last_pong"`
+	Reconnects  int       `json:"reconnects"`
+	// Unacknowledged counts the messages sent on the connection which Slack
+	// has not yet acknowledged.
+	Unacknowledged int `json:"unacknowledged"`
+}
+
+// connection tracks a bot's connection to the RTM API. The main loop updates
+// it, and the admin server reads it, so it has its own lock.
+//
+// Every message and ping sent on the connection gets the next ID, and the
+// messages are pending until Slack acknowledges them.
+type connection struct {
+	mu       sync.Mutex
+	state    ConnectionState
+	name     string
+	id       string
+	users    int
+	channels int
+	lastID   int
+	pending  map[string]*Message
+}
+
+func (c *connection) authenticated(name, id string, users, channels int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.name, c.id = name, id
+	c.users, c.channels = users, channels
+}
+
+func (c *connection) connected(now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.state.ConnectedAt.IsZero() {
+		c.state.Reconnects++
+	}
+	c.state.Connected = true
+	c.state.ConnectedAt = now
+	c.lastID = 0
+	c.state.Hello = time.Time{}
+	c.state.LastPing = time.Time{}
+	c.state.LastPong = time.Time{}
+}
+
+func (c *connection) disconnected() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.state.Connected = false
+}
+
+// received notes that event was received at now.
+func (c *connection) received(event map[string]interface{}, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.state.LastEvent = now
+	switch event["type"] {
+	case "hello":
+		c.state.Hello = now
+	case "pong":
+		c.state.LastPong = now
+	}
+}
+
+// ping returns the next ping to send, and notes that it was sent at now.
+func (c *connection) ping(now time.Time) map[string]interface{} {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.lastID++
+	c.state.LastPing = now
+	return map[string]interface{}{"id": c.lastID, "type": "ping"}
+}
+
+func (c *connection) snapshot() (ConnectionState, string, string, int, int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	state := c.state
+	state.Unacknowledged = len(c.pending)
+	return state, c.name, c.id, c.users, c.channels
+}
+
+// Ready returns nil if the bot is connected to Slack, Slack has said hello,
+// and Slack has answered the bot's pings. A ping is unanswered once twice the
+// bot's PingInterval has passed without a pong.
+func (bot *Bot) Ready() error {
+	state, _, _, _, _ := bot.connection.snapshot()
+	if !state.Connected {
+		return &Error{"not connected to Slack"}
+	}
+	if state.Hello.IsZero() {
+		return &Error{"waiting for Slack to say hello"}
+	}
+	if state.LastPing.After(state.LastPong) && bot.PingInterval > 0 {
+		if waited := time.Since(state.LastPing); waited > 2*bot.PingInterval {
+			return &Error{fmt.Sprintf("no pong from Slack for %s", waited.Round(time.Second))}
+		}
+	}
+	return nil
+}
+
+// State returns a snapshot of what the bot is doing.
+func (bot *Bot) State() State {
+	connection, name, id, users, channels := bot.connection.snapshot()
+	state := State{
+		Name:       name,
+		ID:         id,
+		Connection: connection,
+		Plugins:    bot.Plugins(),
+		Handlers:   make(map[string]int),
+		Caches: map[string]int{
+			"users":           users,
+			"channels":        channels,
+			"direct_messages": bot.dms.len(),
+			"scheduled_jobs":  len(bot.Scheduler.Jobs()),
+			"outgoing":        len(bot.outgoing),
+		},
+	}
+
+	bot.handlersMu.RLock()
+	for eventType, handlers := range bot.Handlers {
+		state.Handlers[eventType] = len(handlers)
+	}
+	for eventType, subhandlers := range bot.Subhandlers {
+		for subtype, handlers := range subhandlers {
+			state.Handlers[eventType+"/"+subtype] = len(handlers)
+		}
+	}
+	bot.handlersMu.RUnlock()
+
+	bot.conversations.mu.Lock()
+	state.Caches["conversations"] = len(bot.conversations.active)
+	bot.conversations.mu.Unlock()
+	return state
+}
+
+// AdminHandler returns the handler of the bot's admin server, which serves:
+//
+//	/healthz      200 as long as the process is up
+//	/readyz       200 if the bot is Ready, and 503 with the reason otherwise
+//	/debug/state  the bot's State as JSON
+//
+// More handlers, like a metrics endpoint, can be added to the returned mux.
+func (bot *Bot) AdminHandler() *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, "ok")
+	})
+	mux.HandleFunc("/readyz", func(w http.ResponseWriter, r *http.Request) {
+		if err := bot.Ready(); err != nil {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprintln(w, "ok")
+	})
+	mux.HandleFunc("/debug/state", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		encoder.Encode(bot.State())
+	})
+	return mux
+}
+
+// serveAdmin starts serving the bot's AdminHandler at addr. The returned
+// server should be closed once the bot stops.
+func (bot *Bot) serveAdmin(addr string) (*http.Server, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	server := &http.Server{Handler: bot.AdminHandler()}
+	go func() {
+		if err := server.Serve(listener); err != nil && err != http.ErrServerClosed {
+			bot.Log().Error("Admin server failed.", Fields{
+				"error": err,
+			})
+		}
+	}()
+	bot.Log().Info("Serving admin endpoints.", Fields{
+		"addr": listener.Addr().String(),
+	})
+	return server, nil
+}
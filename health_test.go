@@ -0,0 +1,144 @@
+package slack
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReady(t *testing.T) {
+	now := time.Now()
+	var tests = []struct {
+		update   func(*connection)
+		expected string
+	}{
+		{func(c *connection) {}, "not connected to Slack"},
+		{func(c *connection) { c.connected(now) }, "waiting for Slack to say hello"},
+		{
+			func(c *connection) {
+				c.connected(now)
+				c.received(map[string]interface{}{"type": "hello"}, now)
+			},
+			"",
+		},
+		{
+			func(c *connection) {
+				c.connected(now)
+				c.received(map[string]interface{}{"type": "hello"}, now)
+				c.ping(now.Add(-time.Minute))
+			},
+			"no pong from Slack for 1m0s",
+		},
+		{
+			func(c *connection) {
+				c.connected(now)
+				c.received(map[string]interface{}{"type": "hello"}, now)
+				c.ping(now.Add(-time.Minute))
+				c.received(map[string]interface{}{"type": "pong", "reply_to": 1}, now)
+			},
+			"",
+		},
+		{
+			func(c *connection) {
+				c.connected(now)
+				c.received(map[string]interface{}{"type": "hello"}, now)
+				c.ping(now)
+			},
+			"",
+		},
+		{
+			func(c *connection) {
+				c.connected(now)
+				c.received(map[string]interface{}{"type": "hello"}, now)
+				c.disconnected()
+			},
+			"not connected to Slack",
+		},
+	}
+
+	for i, test := range tests {
+		bot := NewBot("token")
+		bot.PingInterval = 10 * time.Second
+		test.update(bot.connection)
+		err := bot.Ready()
+		if test.expected == "" && err != nil {
+			t.Errorf("Error. Expected case %d to be ready. Got %v.", i, err)
+		} else if test.expected != "" && (err == nil || err.Error() != test.expected) {
+			t.Errorf("Error. Expected %q for case %d. Got %v.", test.expected, i, err)
+		}
+	}
+}
+
+func TestPrivate_connection_reconnects(t *testing.T) {
+	c := &connection{}
+	now := time.Now()
+	c.connected(now)
+	c.received(map[string]interface{}{"type": "hello"}, now)
+	c.disconnected()
+	c.connected(now.Add(time.Second))
+	state, _, _, _, _ := c.snapshot()
+	assert(state.Connected && state.Reconnects == 1, t)
+	assert(state.Hello.IsZero(), t)
+	assert(state.ConnectedAt.Equal(now.Add(time.Second)), t)
+	assert(state.LastEvent.Equal(now), t)
+}
+
+func TestPrivate_connection_ping(t *testing.T) {
+	c := &connection{}
+	now := time.Now()
+	first := c.ping(now)
+	second := c.ping(now)
+	assert(first["type"] == "ping" && first["id"] == 1, t)
+	assert(second["id"] == 2, t)
+}
+
+func TestState(t *testing.T) {
+	bot := NewBot("token")
+	bot.connection.authenticated("bot", "UBOT", 2, 3)
+	bot.OnEvent("message", shutdownHandler)
+	bot.OnEvent("message", shutdownHandler)
+	bot.OnEventWithSubtype("message", "bot_message", shutdownHandler)
+	bot.Scheduler.Add("job", Every(time.Hour), func(context.Context, *Bot) *Message { return nil })
+
+	state := bot.State()
+	assert(state.Name == "bot" && state.ID == "UBOT", t)
+	assert(state.Handlers["message"] == 2, t)
+	assert(state.Handlers["message/bot_message"] == 1, t)
+	assert(state.Caches["users"] == 2 && state.Caches["channels"] == 3, t)
+	assert(state.Caches["scheduled_jobs"] == 1, t)
+	assert(state.Caches["conversations"] == 0 && state.Caches["outgoing"] == 0, t)
+}
+
+func TestAdminHandler(t *testing.T) {
+	bot := NewBot("token")
+	handler := bot.AdminHandler()
+	var tests = []struct {
+		path     string
+		status   int
+		contains string
+	}{
+		{"/healthz", http.StatusOK, "ok"},
+		{"/readyz", http.StatusServiceUnavailable, "not connected to Slack"},
+		{"/debug/state", http.StatusOK, `"connected": false`},
+	}
+
+	for _, test := range tests {
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest("GET", test.path, nil))
+		if recorder.Code != test.status || !strings.Contains(recorder.Body.String(), test.contains) {
+			t.Errorf("Error. Expected %d and %q from %s. Got %d and %q.",
+				test.status, test.contains, test.path, recorder.Code, recorder.Body.String())
+		}
+	}
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest("GET", "/debug/state", nil))
+	var state State
+	if err := json.Unmarshal(recorder.Body.Bytes(), &state); err != nil {
+		t.Errorf("Error. Expected the state to be JSON. Got %v.", err)
+	}
+}
@@ -0,0 +1,63 @@
+package slack
+
+import (
+	"fmt"
+	"net/url"
+)
+
+// repliesPageSize is the number of messages requested per page of
+// conversations.replies.
+const repliesPageSize = 200
+
+// Replies returns the message with the timestamp ts in channel, followed by
+// its replies if it is the parent of a thread, with the conversations.replies
+// Web API method. Each message is a JSON-like map, like the events passed to
+// handlers.
+func (bot *Bot) Replies(channel, ts string) ([]map[string]interface{}, error) {
+	var messages []map[string]interface{}
+	cursor := ""
+	for {
+		params := url.Values{}
+		params.Set("channel", channel)
+		params.Set("ts", ts)
+		params.Set("limit", fmt.Sprint(repliesPageSize))
+		if cursor != "" {
+			params.Set("cursor", cursor)
+		}
+		payload, err := bot.Call("conversations.replies", params)
+		if err != nil {
+			return nil, err
+		}
+		if ok, _ := payload["ok"].(bool); !ok {
+			return nil, &Error{fmt.Sprintf("conversations.replies failed: %v", payload["error"])}
+		}
+		page, _ := payload["messages"].([]interface{})
+		for _, message := range page {
+			if message, ok := message.(map[string]interface{}); ok {
+				messages = append(messages, message)
+			}
+		}
+		metadata, _ := payload["response_metadata"].(map[string]interface{})
+		cursor, _ = metadata["next_cursor"].(string)
+		if cursor == "" {
+			return messages, nil
+		}
+	}
+}
+
+// Permalink returns a link to the message with the timestamp ts in channel,
+// with the chat.getPermalink Web API method.
+func (bot *Bot) Permalink(channel, ts string) (string, error) {
+	params := url.Values{}
+	params.Set("channel", channel)
+	params.Set("message_ts", ts)
+	payload, err := bot.Call("chat.getPermalink", params)
+	if err != nil {
+		return "", err
+	}
+	if ok, _ := payload["ok"].(bool); !ok {
+		return "", &Error{fmt.Sprintf("chat.getPermalink failed: %v", payload["error"])}
+	}
+	permalink, _ := payload["permalink"].(string)
+	return permalink, nil
+}
@@ -2,35 +2,33 @@ package slack
 
 import (
 	"regexp"
-
-	log "github.com/Sirupsen/logrus"
 )
 
 // ListenRegexp functions exactly as Listen, but instead takes a compiled
 // regexp instead of a string.
-func (bot *Bot) ListenRegexp(re *regexp.Regexp, handler BotAction) {
+func (bot *Bot) ListenRegexp(re *regexp.Regexp, handler BotAction) *Handler {
 	closure := func(self *Bot, event map[string]interface{}) (*Message, Status) {
 		text, ok := event["text"].(string)
 		if !ok {
 			return nil, Continue
 		}
-		logger := log.WithFields(log.Fields{
+		fields := Fields{
 			"text":  text,
-			"regex": re,
-		})
+			"regex": re.String(),
+		}
 		if re.MatchString(text) {
-			logger.Info("MATCH. Invoking handler.")
+			bot.Log().Info("MATCH. Invoking handler.", fields)
 			return handler(self, event)
 		}
-		logger.Info("NO MATCH. Not invoking handler.")
+		bot.Log().Debug("NO MATCH. Not invoking handler.", fields)
 		return nil, Continue
 	}
-	bot.OnEvent("message", closure)
+	return bot.OnEvent("message", closure)
 }
 
 // Listen registers the given handler to fire on "message" events with no
 // subtype which match the regexp specified in pattern.
-func (bot *Bot) Listen(pattern string, handler BotAction) {
+func (bot *Bot) Listen(pattern string, handler BotAction) *Handler {
 	re := regexp.MustCompile(pattern)
-	bot.ListenRegexp(re, handler)
+	return bot.ListenRegexp(re, handler)
 }
@@ -0,0 +1,149 @@
+package slack
+
+import (
+	"regexp"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+// Fields are the structured data logged with a message.
+type Fields map[string]interface{}
+
+// Logger is where a bot writes its logs. Set a bot's Logger to send them
+// somewhere other than the standard logrus logger; LogrusLogger adapts a logrus
+// logger, and package github.com/ajm188/slack/slogadapter adapts a log/slog
+// logger.
+//
+// Routine work, like each event received and each message which does not
+// match a handler's pattern, is logged at Debug.
+type Logger interface {
+	Debug(message string, fields Fields)
+	Info(message string, fields Fields)
+	Warn(message string, fields Fields)
+	Error(message string, fields Fields)
+}
+
+// LogrusLogger adapts a logrus logger to a Logger. A bot with no Logger logs
+// to logrus.StandardLogger().
+func LogrusLogger(logger *log.Logger) Logger {
+	return logrusLogger{logger}
+}
+
+type logrusLogger struct {
+	logger *log.Logger
+}
+
+func (l logrusLogger) Debug(message string, fields Fields) {
+	l.logger.WithFields(log.Fields(fields)).Debug(message)
+}
+
+func (l logrusLogger) Info(message string, fields Fields) {
+	l.logger.WithFields(log.Fields(fields)).Info(message)
+}
+
+func (l logrusLogger) Warn(message string, fields Fields) {
+	l.logger.WithFields(log.Fields(fields)).Warn(message)
+}
+
+func (l logrusLogger) Error(message string, fields Fields) {
+	l.logger.WithFields(log.Fields(fields)).Error(message)
+}
+
+// Redaction says what to leave out of a bot's logs. NewBot redacts tokens, but
+// not message text.
+type Redaction struct {
+	// Text replaces the text of messages (and their blocks and attachments)
+	// with "[redacted]", including the text of events which are logged.
+	Text bool
+	// Tokens replaces anything which looks like a Slack token with
+	// "[redacted]".
+	Tokens bool
+}
+
+const redacted = "[redacted]"
+
+var tokenRe = regexp.MustCompile(`\bxox[a-z]-[A-Za-z0-9-]+|\bxapp-[A-Za-z0-9-]+`)
+
+// textKeys are the fields which hold the text of messages.
+var textKeys = map[string]bool{
+	"text":        true,
+	"blocks":      true,
+	"attachments": true,
+	"raw bytes":   true,
+}
+
+// Log returns the bot's Logger, which leaves out what the bot's Redact says
+// to. Plugins should log through it, so that their logs go to the same place
+// as the bot's.
+func (bot *Bot) Log() Logger {
+	logger := bot.Logger
+	if logger == nil {
+		logger = LogrusLogger(log.StandardLogger())
+	}
+	if !bot.Redact.Text && !bot.Redact.Tokens {
+		return logger
+	}
+	return redactingLogger{logger, bot.Redact}
+}
+
+type redactingLogger struct {
+	logger    Logger
+	redaction Redaction
+}
+
+func (l redactingLogger) Debug(message string, fields Fields) {
+	l.logger.Debug(message, l.redaction.fields(fields))
+}
+
+func (l redactingLogger) Info(message string, fields Fields) {
+	l.logger.Info(message, l.redaction.fields(fields))
+}
+
+func (l redactingLogger) Warn(message string, fields Fields) {
+	l.logger.Warn(message, l.redaction.fields(fields))
+}
+
+func (l redactingLogger) Error(message string, fields Fields) {
+	l.logger.Error(message, l.redaction.fields(fields))
+}
+
+// fields returns a copy of fields with the redactions applied. Events and
+// payloads are copied as well, rather than changed.
+func (r Redaction) fields(fields Fields) Fields {
+	redactedFields := make(Fields, len(fields))
+	for key, value := range fields {
+		redactedFields[key] = r.value(key, value)
+	}
+	return redactedFields
+}
+
+func (r Redaction) value(key string, value interface{}) interface{} {
+	if r.Text && textKeys[key] || r.Tokens && key == "token" {
+		return redacted
+	}
+	switch value := value.(type) {
+	case string:
+		if r.Tokens {
+			return tokenRe.ReplaceAllString(value, redacted)
+		}
+	case error:
+		if r.Tokens {
+			return tokenRe.ReplaceAllString(value.Error(), redacted)
+		}
+	case map[string]interface{}:
+		return map[string]interface{}(r.fields(Fields(value)))
+	case map[string]string:
+		m := make(map[string]string, len(value))
+		for k, v := range value {
+			m[k] = r.value(k, v).(string)
+		}
+		return m
+	case []interface{}:
+		s := make([]interface{}, len(value))
+		for i, v := range value {
+			s[i] = r.value("", v)
+		}
+		return s
+	}
+	return value
+}
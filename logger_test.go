@@ -0,0 +1,133 @@
+package slack
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+// fakeLogger records the lines it is given.
+type fakeLogger struct {
+	mu    sync.Mutex
+	lines []string
+	last  Fields
+}
+
+func (l *fakeLogger) log(level, message string, fields Fields) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.lines = append(l.lines, level+" "+message)
+	l.last = fields
+}
+
+func (l *fakeLogger) Debug(message string, fields Fields) { l.log("debug", message, fields) }
+func (l *fakeLogger) Info(message string, fields Fields)  { l.log("info", message, fields) }
+func (l *fakeLogger) Warn(message string, fields Fields)  { l.log("warn", message, fields) }
+func (l *fakeLogger) Error(message string, fields Fields) { l.log("error", message, fields) }
+
+func TestLog_levels(t *testing.T) {
+	logger := &fakeLogger{}
+	bot := NewBot("token")
+	bot.Name = "bot"
+	bot.ID = "UBOT"
+	bot.Logger = logger
+	bot.Listen("^ping$", func(_ *Bot, _ map[string]interface{}) (*Message, Status) {
+		return nil, Continue
+	})
+	bot.Respond("^ping$", func(_ *Bot, _ map[string]interface{}) (*Message, Status) {
+		return nil, Continue
+	})
+
+	var tests = []struct {
+		text     string
+		expected []string
+	}{
+		{"hello", []string{"debug NO MATCH. Not invoking handler.", "debug NO MENTION. Not invoking handler."}},
+		{"ping", []string{"info MATCH. Invoking handler.", "debug NO MENTION. Not invoking handler."}},
+		{"<@UBOT> hello", []string{"debug NO MATCH. Not invoking handler.", "debug NO MATCH. Not invoking handler."}},
+		{"<@UBOT> ping", []string{"debug NO MATCH. Not invoking handler.", "info MATCH. Invoking handler."}},
+	}
+
+	for _, test := range tests {
+		logger.lines = nil
+		bot.Dispatch(map[string]interface{}{"type": "message", "text": test.text, "user": "U1", "channel": "C1"})
+		if !reflect.DeepEqual(logger.lines, test.expected) {
+			t.Errorf("Error. Expected %q for %q. Got %q.", test.expected, test.text, logger.lines)
+		}
+	}
+}
+
+func TestRedaction(t *testing.T) {
+	event := map[string]interface{}{
+		"type": "message",
+		"text": "my token is xoxb-1234-abcd",
+		"attachments": []interface{}{
+			map[string]interface{}{"text": "more"},
+		},
+	}
+	var tests = []struct {
+		redaction Redaction
+		field     string
+		value     interface{}
+		expected  interface{}
+	}{
+		{Redaction{}, "text", "xoxb-1234-abcd", "xoxb-1234-abcd"},
+		{Redaction{Tokens: true}, "text", "token xoxb-1234-abcd here", "token [redacted] here"},
+		{Redaction{Tokens: true}, "token", "secret", "[redacted]"},
+		{Redaction{Tokens: true}, "error", errors.New("bad token xoxp-9"), "bad token [redacted]"},
+		{Redaction{Tokens: true}, "url", "wss://example.com/?app=xapp-1-2", "wss://example.com/?app=[redacted]"},
+		{Redaction{Text: true}, "text", "hello", "[redacted]"},
+		{Redaction{Text: true}, "channel", "C1", "C1"},
+		{
+			Redaction{Text: true},
+			"event",
+			event,
+			map[string]interface{}{"type": "message", "text": "[redacted]", "attachments": "[redacted]"},
+		},
+		{
+			Redaction{Tokens: true},
+			"event",
+			event,
+			map[string]interface{}{
+				"type": "message",
+				"text": "my token is [redacted]",
+				"attachments": []interface{}{
+					map[string]interface{}{"text": "more"},
+				},
+			},
+		},
+		{
+			Redaction{Text: true},
+			"message",
+			map[string]string{"text": "hi", "channel": "C1"},
+			map[string]string{"text": "[redacted]", "channel": "C1"},
+		},
+	}
+
+	for _, test := range tests {
+		actual := test.redaction.fields(Fields{test.field: test.value})[test.field]
+		if !reflect.DeepEqual(actual, test.expected) {
+			t.Errorf("Error. Expected %v for %v with %+v. Got %v.", test.expected, test.value, test.redaction, actual)
+		}
+	}
+	if event["text"] != "my token is xoxb-1234-abcd" {
+		t.Error("Error. Expected the event to be copied, not changed.")
+	}
+}
+
+func TestLog_redacts(t *testing.T) {
+	logger := &fakeLogger{}
+	bot := NewBot("token")
+	bot.Logger = logger
+	bot.Log().Error("Failed.", Fields{"error": "token xoxb-1", "text": "hi"})
+	if logger.last["error"] != "token [redacted]" || logger.last["text"] != "hi" {
+		t.Errorf("Error. Expected only tokens to be redacted by default. Got %v.", logger.last)
+	}
+
+	bot.Redact = Redaction{}
+	bot.Log().Error("Failed.", Fields{"error": "token xoxb-1"})
+	if logger.last["error"] != "token xoxb-1" {
+		t.Errorf("Error. Expected nothing to be redacted. Got %v.", logger.last)
+	}
+}
@@ -2,11 +2,14 @@ package slack
 
 import (
 	"fmt"
+
+	"github.com/ajm188/slack/format"
 )
 
 // Mention constructs a Message which mentions nick with text in channel and
-// returns a reference to it.
+// returns a reference to it. text is sent as-is, so any text that came from a
+// user should be escaped with format.Escape first.
 func (bot *Bot) Mention(nick, text, channel string) *Message {
-	fullText := fmt.Sprintf("<@%s>: %s", nick, text)
+	fullText := fmt.Sprintf("%s: %s", format.User(nick), text)
 	return NewMessage(fullText, channel)
 }
@@ -1,7 +1,7 @@
 package slack
 
 import (
-	"time"
+	"github.com/ajm188/slack/blocks"
 )
 
 // Message represents a message to be sent to the Slack RTM API. Messages are
@@ -11,25 +11,63 @@ type Message struct {
 	messageType string
 	channel     string
 	text        string
+	threadTS    string
+	blocks      []blocks.Block
+	acks        chan ackResult
 }
 
 // NewMessage constructs a new message object which will send text to channel.
-// The Slack RTM API uses IDs to identify messages, so NewMessage uses the
-// current time as the identifier.
+// The Slack RTM API uses IDs to identify messages, so the bot gives the
+// message the next ID on its connection when it sends it.
 func NewMessage(text, channel string) *Message {
 	return &Message{
-		id:          formatTime(time.Now()),
 		messageType: "message",
 		channel:     channel,
 		text:        text,
 	}
 }
 
+// InThread makes the message a reply in the thread whose parent message has
+// the timestamp ts, and returns the message so that calls can be chained.
+func (m *Message) InThread(ts string) *Message {
+	m.threadTS = ts
+	return m
+}
+
+// Text returns the text of the message.
+func (m *Message) Text() string {
+	return m.text
+}
+
+// Channel returns the ID of the channel the message will be sent to.
+func (m *Message) Channel() string {
+	return m.channel
+}
+
+// Thread returns the timestamp of the parent of the thread the message will
+// be sent to, or "" if it is not a reply in a thread.
+func (m *Message) Thread() string {
+	return m.threadTS
+}
+
 func (m *Message) toMap() map[string]string {
-	return map[string]string{
+	fields := map[string]string{
 		"id":      m.id,
 		"type":    m.messageType,
 		"channel": m.channel,
 		"text":    m.text,
 	}
+	if m.threadTS != "" {
+		fields["thread_ts"] = m.threadTS
+	}
+	return fields
+}
+
+// WithBlocks attaches Block Kit blocks to the message, and returns the message
+// so that calls can be chained. The RTM API cannot send blocks, so messages
+// with blocks are sent with the chat.postMessage Web API method instead, and
+// the message's text is used as the notification fallback.
+func (m *Message) WithBlocks(b ...blocks.Block) *Message {
+	m.blocks = append(m.blocks, b...)
+	return m
 }
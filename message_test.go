@@ -2,6 +2,8 @@ package slack
 
 import (
 	"testing"
+
+	"github.com/ajm188/slack/blocks"
 )
 
 func TestCreatingANewMessageWithNoError(t *testing.T) {
@@ -59,3 +61,20 @@ func compareMapsString(expected, actual map[string]string, t *testing.T) {
 		}
 	}
 }
+
+func TestWithBlocks(t *testing.T) {
+	m := NewMessage("text", "channel")
+	if m.WithBlocks(&blocks.Divider{}, &blocks.Divider{}) != m {
+		t.Error("Error. Expected WithBlocks to return the message.")
+	}
+	if len(m.blocks) != 2 {
+		t.Errorf("Error. Expected 2 blocks. Got %d.", len(m.blocks))
+	}
+}
+
+func TestMessageAccessors(t *testing.T) {
+	message := NewMessage("hi", "C1")
+	assert(message.Text() == "hi" && message.Channel() == "C1" && message.Thread() == "", t)
+	message.InThread("1.0")
+	assert(message.Thread() == "1.0", t)
+}
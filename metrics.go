@@ -0,0 +1,52 @@
+package slack
+
+import (
+	"time"
+)
+
+// Metrics receives measurements of what a bot is doing. Set a bot's Metrics to
+// collect them; package github.com/ajm188/slack/metrics has an implementation
+// which serves them to Prometheus. The methods are called from several of the
+// bot's goroutines, so they must be safe for concurrent use.
+type Metrics interface {
+	// EventReceived is called for each event the bot receives from Slack.
+	// subtype is empty if the event has none.
+	EventReceived(eventType, subtype string)
+	// HandlerInvoked is called each time a handler fires, with the type and
+	// subtype it was registered for, how long it ran, and whether it
+	// panicked.
+	HandlerInvoked(eventType, subtype string, duration time.Duration, panicked bool)
+	// MessageSent is called for each message the bot sends. via is "rtm" for
+	// messages written to the RTM websocket, and "web" for messages sent with
+	// chat.postMessage.
+	MessageSent(via string)
+	// APICalled is called after each Web API call, with the method, its
+	// status and how long it took. The status is "ok", the error Slack
+	// returned (like "channel_not_found"), "rate_limited", or "failed" if no
+	// response was received.
+	APICalled(method, status string, latency time.Duration)
+	// RateLimited is called when Slack rate limits a Web API call, with how
+	// long Slack asked the bot to wait before calling again.
+	RateLimited(method string, wait time.Duration)
+	// Reconnected is called when the bot reconnects to the RTM API.
+	Reconnected()
+}
+
+// nopMetrics is used when a bot has no Metrics.
+type nopMetrics struct{}
+
+func (nopMetrics) EventReceived(string, string)                       {}
+func (nopMetrics) HandlerInvoked(string, string, time.Duration, bool) {}
+func (nopMetrics) MessageSent(string)                                 {}
+func (nopMetrics) APICalled(string, string, time.Duration)            {}
+func (nopMetrics) RateLimited(string, time.Duration)                  {}
+func (nopMetrics) Reconnected()                                       {}
+
+// metrics returns the bot's Metrics, or a Metrics which discards everything if
+// it has none.
+func (bot *Bot) metrics() Metrics {
+	if bot.Metrics == nil {
+		return nopMetrics{}
+	}
+	return bot.Metrics
+}
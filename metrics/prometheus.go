@@ -0,0 +1,251 @@
+/*
+Package metrics collects a bot's metrics and serves them to Prometheus.
+
+A Prometheus is a slack.Metrics which keeps counters and histograms in memory,
+and serves them in the Prometheus text exposition format. It is an
+http.Handler, so it can be served by any HTTP server:
+
+	registry := metrics.NewPrometheus()
+	bot.Metrics = registry
+	http.Handle("/metrics", registry)
+	go http.ListenAndServe(":9090", nil)
+
+The metrics are:
+
+	slack_events_received_total{type, subtype}
+	slack_handler_invocations_total{event, subtype}
+	slack_handler_panics_total{event, subtype}
+	slack_handler_duration_seconds{event, subtype} (histogram)
+	slack_messages_sent_total{via}
+	slack_api_calls_total{method, status}
+	slack_api_call_duration_seconds{method} (histogram)
+	slack_rate_limits_total{method}
+	slack_rate_limit_retry_after_seconds_total{method}
+	slack_reconnects_total
+*/
+package metrics
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ajm188/slack"
+)
+
+// DefaultBuckets are the upper bounds, in seconds, of the buckets of the
+// duration histograms.
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// contentType is the content type of the Prometheus text exposition format.
+const contentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// Prometheus is a slack.Metrics which serves the metrics it collects in the
+// Prometheus text exposition format. Construct one with NewPrometheus.
+type Prometheus struct {
+	mu       sync.Mutex
+	families []*family
+
+	events          *family
+	invocations     *family
+	panics          *family
+	handlerDuration *family
+	messages        *family
+	calls           *family
+	callDuration    *family
+	rateLimits      *family
+	rateLimitAfter  *family
+	reconnects      *family
+}
+
+var _ slack.Metrics = &Prometheus{}
+
+// NewPrometheus constructs a Prometheus with no measurements.
+func NewPrometheus() *Prometheus {
+	p := &Prometheus{}
+	p.events = p.counter("slack_events_received_total", "Events received from Slack.", "type", "subtype")
+	p.invocations = p.counter("slack_handler_invocations_total", "Handler invocations.", "event", "subtype")
+	p.panics = p.counter("slack_handler_panics_total", "Handler invocations which panicked.", "event", "subtype")
+	p.handlerDuration = p.histogram("slack_handler_duration_seconds", "How long handlers ran.", "event", "subtype")
+	p.messages = p.counter("slack_messages_sent_total", "Messages sent to Slack.", "via")
+	p.calls = p.counter("slack_api_calls_total", "Web API calls.", "method", "status")
+	p.callDuration = p.histogram("slack_api_call_duration_seconds", "How long Web API calls took.", "method")
+	p.rateLimits = p.counter("slack_rate_limits_total", "Web API calls rate limited by Slack.", "method")
+	p.rateLimitAfter = p.counter("slack_rate_limit_retry_after_seconds_total", "Time Slack asked the bot to wait after rate limiting a Web API call.", "method")
+	p.reconnects = p.counter("slack_reconnects_total", "Reconnections to the RTM API.")
+	return p
+}
+
+// EventReceived counts an event received from Slack.
+func (p *Prometheus) EventReceived(eventType, subtype string) {
+	p.add(p.events, 1, eventType, subtype)
+}
+
+// HandlerInvoked counts a handler invocation, and observes its duration.
+func (p *Prometheus) HandlerInvoked(eventType, subtype string, duration time.Duration, panicked bool) {
+	p.add(p.invocations, 1, eventType, subtype)
+	if panicked {
+		p.add(p.panics, 1, eventType, subtype)
+	}
+	p.observe(p.handlerDuration, duration.Seconds(), eventType, subtype)
+}
+
+// MessageSent counts a message sent to Slack.
+func (p *Prometheus) MessageSent(via string) {
+	p.add(p.messages, 1, via)
+}
+
+// APICalled counts a Web API call, and observes its latency.
+func (p *Prometheus) APICalled(method, status string, latency time.Duration) {
+	p.add(p.calls, 1, method, status)
+	p.observe(p.callDuration, latency.Seconds(), method)
+}
+
+// RateLimited counts a rate limited Web API call, and how long Slack asked the
+// bot to wait.
+func (p *Prometheus) RateLimited(method string, wait time.Duration) {
+	p.add(p.rateLimits, 1, method)
+	p.add(p.rateLimitAfter, wait.Seconds(), method)
+}
+
+// Reconnected counts a reconnection to the RTM API.
+func (p *Prometheus) Reconnected() {
+	p.add(p.reconnects, 1)
+}
+
+// ServeHTTP writes the metrics in the Prometheus text exposition format.
+func (p *Prometheus) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", contentType)
+	p.WriteTo(w)
+}
+
+// WriteTo writes the metrics to w in the Prometheus text exposition format.
+func (p *Prometheus) WriteTo(w io.Writer) (int64, error) {
+	var buffer bytes.Buffer
+	p.mu.Lock()
+	for _, f := range p.families {
+		f.write(&buffer)
+	}
+	p.mu.Unlock()
+	return buffer.WriteTo(w)
+}
+
+func (p *Prometheus) counter(name, help string, labels ...string) *family {
+	f := &family{name: name, help: help, kind: "counter", labels: labels, series: make(map[string]*series)}
+	p.families = append(p.families, f)
+	return f
+}
+
+func (p *Prometheus) histogram(name, help string, labels ...string) *family {
+	f := p.counter(name, help, labels...)
+	f.kind = "histogram"
+	f.buckets = DefaultBuckets
+	return f
+}
+
+func (p *Prometheus) add(f *family, delta float64, values ...string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	f.get(values).sum += delta
+}
+
+func (p *Prometheus) observe(f *family, value float64, values ...string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	s := f.get(values)
+	if s.counts == nil {
+		s.counts = make([]uint64, len(f.buckets))
+	}
+	for i, bound := range f.buckets {
+		if value <= bound {
+			s.counts[i]++
+		}
+	}
+	s.count++
+	s.sum += value
+}
+
+// family is a metric and all of its series, one for each combination of label
+// values.
+type family struct {
+	name    string
+	help    string
+	kind    string
+	labels  []string
+	buckets []float64
+	series  map[string]*series
+}
+
+// series is a counter, or a histogram if counts is set. A counter's value is
+// kept in sum.
+type series struct {
+	values []string
+	sum    float64
+	count  uint64
+	counts []uint64
+}
+
+func (f *family) get(values []string) *series {
+	key := strings.Join(values, "\xff")
+	s, ok := f.series[key]
+	if !ok {
+		s = &series{values: values}
+		f.series[key] = s
+	}
+	return s
+}
+
+func (f *family) write(w io.Writer) {
+	fmt.Fprintf(w, "# HELP %s %s\n", f.name, f.help)
+	fmt.Fprintf(w, "# TYPE %s %s\n", f.name, f.kind)
+	keys := make([]string, 0, len(f.series))
+	for key := range f.series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		s := f.series[key]
+		labels := f.labelPairs(s.values)
+		if f.kind == "counter" {
+			fmt.Fprintf(w, "%s%s %s\n", f.name, braces(labels), formatFloat(s.sum))
+			continue
+		}
+		for i, bound := range f.buckets {
+			le := append(labels, pair("le", formatFloat(bound)))
+			fmt.Fprintf(w, "%s_bucket%s %d\n", f.name, braces(le), s.counts[i])
+		}
+		fmt.Fprintf(w, "%s_bucket%s %d\n", f.name, braces(append(labels, pair("le", "+Inf"))), s.count)
+		fmt.Fprintf(w, "%s_sum%s %s\n", f.name, braces(labels), formatFloat(s.sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", f.name, braces(labels), s.count)
+	}
+}
+
+func (f *family) labelPairs(values []string) []string {
+	pairs := make([]string, len(f.labels))
+	for i, label := range f.labels {
+		pairs[i] = pair(label, values[i])
+	}
+	return pairs
+}
+
+func pair(label, value string) string {
+	value = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+	return fmt.Sprintf(`%s="%s"`, label, value)
+}
+
+func braces(pairs []string) string {
+	if len(pairs) == 0 {
+		return ""
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
@@ -0,0 +1,96 @@
+package metrics
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPrometheus(t *testing.T) {
+	p := NewPrometheus()
+	p.EventReceived("message", "")
+	p.EventReceived("message", "")
+	p.EventReceived("message", "bot_message")
+	p.HandlerInvoked("message", "", 20*time.Millisecond, false)
+	p.HandlerInvoked("message", "", 3*time.Second, true)
+	p.MessageSent("rtm")
+	p.APICalled("chat.postMessage", "channel_not_found", time.Millisecond)
+	p.RateLimited("chat.postMessage", 2*time.Second)
+	p.RateLimited("chat.postMessage", 500*time.Millisecond)
+	p.Reconnected()
+
+	var buffer bytes.Buffer
+	p.WriteTo(&buffer)
+	output := buffer.String()
+	var tests = []string{
+		"# TYPE slack_events_received_total counter\n",
+		`slack_events_received_total{type="message",subtype=""} 2` + "\n",
+		`slack_events_received_total{type="message",subtype="bot_message"} 1` + "\n",
+		`slack_handler_invocations_total{event="message",subtype=""} 2` + "\n",
+		`slack_handler_panics_total{event="message",subtype=""} 1` + "\n",
+		"# TYPE slack_handler_duration_seconds histogram\n",
+		`slack_handler_duration_seconds_bucket{event="message",subtype="",le="0.01"} 0` + "\n",
+		`slack_handler_duration_seconds_bucket{event="message",subtype="",le="0.025"} 1` + "\n",
+		`slack_handler_duration_seconds_bucket{event="message",subtype="",le="5"} 2` + "\n",
+		`slack_handler_duration_seconds_bucket{event="message",subtype="",le="+Inf"} 2` + "\n",
+		`slack_handler_duration_seconds_sum{event="message",subtype=""} 3.02` + "\n",
+		`slack_handler_duration_seconds_count{event="message",subtype=""} 2` + "\n",
+		`slack_messages_sent_total{via="rtm"} 1` + "\n",
+		`slack_api_calls_total{method="chat.postMessage",status="channel_not_found"} 1` + "\n",
+		`slack_api_call_duration_seconds_count{method="chat.postMessage"} 1` + "\n",
+		`slack_rate_limits_total{method="chat.postMessage"} 2` + "\n",
+		`slack_rate_limit_retry_after_seconds_total{method="chat.postMessage"} 2.5` + "\n",
+		"slack_reconnects_total 1\n",
+	}
+
+	for _, expected := range tests {
+		if !strings.Contains(output, expected) {
+			t.Errorf("Error. Expected output to contain %q. Got:\n%s", expected, output)
+		}
+	}
+}
+
+func TestPrometheus_emptyFamilies(t *testing.T) {
+	var buffer bytes.Buffer
+	NewPrometheus().WriteTo(&buffer)
+	output := buffer.String()
+	if !strings.Contains(output, "# TYPE slack_reconnects_total counter\n") {
+		t.Errorf("Error. Expected every metric to be described. Got:\n%s", output)
+	}
+	if strings.Contains(output, "\nslack_reconnects_total ") {
+		t.Errorf("Error. Expected no samples before any measurements. Got:\n%s", output)
+	}
+}
+
+func TestPrivate_pair(t *testing.T) {
+	var tests = []struct {
+		value    string
+		expected string
+	}{
+		{"plain", `label="plain"`},
+		{`say "hi"`, `label="say \"hi\""`},
+		{`back\slash`, `label="back\\slash"`},
+		{"two\nlines", `label="two\nlines"`},
+	}
+
+	for _, test := range tests {
+		if actual := pair("label", test.value); actual != test.expected {
+			t.Errorf("Error. Expected %s. Got %s.", test.expected, actual)
+		}
+	}
+}
+
+func TestServeHTTP(t *testing.T) {
+	p := NewPrometheus()
+	p.MessageSent("web")
+	recorder := httptest.NewRecorder()
+	p.ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+	if contentType := recorder.Header().Get("Content-Type"); !strings.HasPrefix(contentType, "text/plain; version=0.0.4") {
+		t.Errorf("Error. Unexpected content type %s.", contentType)
+	}
+	if !strings.Contains(recorder.Body.String(), `slack_messages_sent_total{via="web"} 1`) {
+		t.Errorf("Error. Unexpected body:\n%s", recorder.Body.String())
+	}
+}
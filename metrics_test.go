@@ -0,0 +1,158 @@
+package slack
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+// fakeMetrics records the measurements it receives as strings.
+type fakeMetrics struct {
+	mu       sync.Mutex
+	measured []string
+}
+
+func (m *fakeMetrics) add(format string, args ...interface{}) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.measured = append(m.measured, fmt.Sprintf(format, args...))
+}
+
+func (m *fakeMetrics) EventReceived(eventType, subtype string) {
+	m.add("event %s/%s", eventType, subtype)
+}
+
+func (m *fakeMetrics) HandlerInvoked(eventType, subtype string, _ time.Duration, panicked bool) {
+	m.add("handler %s/%s %v", eventType, subtype, panicked)
+}
+
+func (m *fakeMetrics) MessageSent(via string) {
+	m.add("sent %s", via)
+}
+
+func (m *fakeMetrics) APICalled(method, status string, _ time.Duration) {
+	m.add("call %s %s", method, status)
+}
+
+func (m *fakeMetrics) RateLimited(method string, wait time.Duration) {
+	m.add("rate limited %s %v", method, wait)
+}
+
+func (m *fakeMetrics) Reconnected() {
+	m.add("reconnected")
+}
+
+func TestPrivate_invoke_recoversPanics(t *testing.T) {
+	log.SetLevel(log.PanicLevel)
+	metrics := &fakeMetrics{}
+	bot := NewBot("token")
+	bot.Metrics = metrics
+	bot.OnEventWithSubtype("message", "bot_message", func(_ *Bot, _ map[string]interface{}) (*Message, Status) {
+		panic("broken")
+	})
+	bot.OnEvent("message", shutdownHandler)
+
+	responses := bot.Dispatch(map[string]interface{}{"type": "message", "subtype": "bot_message"})
+	assert(len(responses) == 2, t)
+	assert(responses[0] == Response{nil, Continue}, t)
+	assert(responses[1] == Response{shutdownMessage, Shutdown}, t)
+	expected := []string{"handler message/bot_message true", "handler message/ false"}
+	if fmt.Sprint(metrics.measured) != fmt.Sprint(expected) {
+		t.Errorf("Error. Expected %v. Got %v.", expected, metrics.measured)
+	}
+}
+
+func TestCall_doesNotRetryRateLimitedCalls(t *testing.T) {
+	log.SetLevel(log.PanicLevel)
+	var tests = []struct {
+		limited  bool
+		expected []string
+		status   string
+	}{
+		{false, []string{"call auth.test ok"}, "ok"},
+		{true, []string{"call auth.test rate_limited", "rate limited auth.test 30s"}, "rate_limited"},
+	}
+
+	for _, test := range tests {
+		calls := 0
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			if test.limited {
+				w.Header().Set("Retry-After", "30")
+				w.WriteHeader(http.StatusTooManyRequests)
+				return
+			}
+			w.Write([]byte(`{"ok": true}`))
+		}))
+		metrics := &fakeMetrics{}
+		bot := NewBot("token")
+		bot.APIURL = server.URL
+		bot.Metrics = metrics
+
+		payload, err := bot.Call("auth.test", url.Values{})
+		server.Close()
+		if err != nil || callStatus(payload, err) != test.status || calls != 1 {
+			t.Errorf("Error. Expected one call with status %q. Got %d calls, %v and %v.", test.status, calls, payload, err)
+		}
+		if test.limited && payload["retry_after"] != 30.0 {
+			t.Errorf("Error. Expected to be told to retry after 30 seconds. Got %v.", payload["retry_after"])
+		}
+		if fmt.Sprint(metrics.measured) != fmt.Sprint(test.expected) {
+			t.Errorf("Error. Expected %v. Got %v.", test.expected, metrics.measured)
+		}
+	}
+}
+
+func TestCallContextCancelled(t *testing.T) {
+	done := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-done:
+		}
+	}))
+	defer server.Close()
+	defer close(done)
+	bot := NewBot("token")
+	bot.APIURL = server.URL
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, err := bot.CallContext(ctx, "auth.test", url.Values{}); err == nil {
+		t.Error("Error. Expected the call to fail when ctx was done.")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Error. Expected the call to stop when ctx was done. Waited %v.", elapsed)
+	}
+}
+
+func TestPrivate_retryAfter(t *testing.T) {
+	var tests = []struct {
+		header   string
+		expected time.Duration
+	}{
+		{"", defaultRetryAfter},
+		{"0", 0},
+		{"30", 30 * time.Second},
+		{"soon", defaultRetryAfter},
+		{"-1", defaultRetryAfter},
+	}
+
+	for _, test := range tests {
+		response := &http.Response{Header: http.Header{}}
+		if test.header != "" {
+			response.Header.Set("Retry-After", test.header)
+		}
+		if actual := retryAfter(response); actual != test.expected {
+			t.Errorf("Error. Expected %v for %q. Got %v.", test.expected, test.header, actual)
+		}
+	}
+}
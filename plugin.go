@@ -1,7 +1,10 @@
 package slack
 
 import (
-	log "github.com/Sirupsen/logrus"
+	"fmt"
+	"sync"
+
+	"github.com/ajm188/slack/brain"
 )
 
 // PluginFunc is a function which returns a Plugin. This can be used if your
@@ -10,7 +13,8 @@ type PluginFunc func() Plugin
 
 // The Plugin interface is used to implement a plugin for a Slack bot.
 //
-// Name should return the name of the plugin. This is useful for logging.
+// Name should return the name of the plugin. This is useful for logging, and
+// is how other plugins refer to the plugin as a dependency.
 //
 // CanLoad should return true if the plugin can be loaded without error. If
 // CanLoad returns false, the plugin will not be loaded.
@@ -20,26 +24,539 @@ type PluginFunc func() Plugin
 // else the plugin may need to do to load itself into the bot. It also receives
 // a variable-length list of arbitrary arguments that the plugin may need to
 // load correctly, such as configuration options. Load should return an error
-// if there were any issues loading the plugin.
+// if there were any issues loading the plugin. Plugins pass the handlers they
+// register to Bot.Own, so that the handlers belong to the plugin.
+//
+// Plugins may also implement any of the optional lifecycle interfaces
+// (Dependent, Initializer, Starter, Stopper and Unloader). The full lifecycle
+// of a plugin is:
+//
+//	Dependencies -> (config validation) -> Init -> Load -> Start -> Stop -> Unload
+//
+// Start and Stop are called when the bot connects and shuts down, and Unload
+// is called when the plugin is removed with UnloadPlugin, or if Load fails.
 type Plugin interface {
 	Name() string
 	CanLoad() bool
 	Load(*Bot, ...interface{}) error
 }
 
+// Dependent is implemented by plugins which require other plugins to be loaded
+// first. Dependencies should return the names of those plugins.
+type Dependent interface {
+	Dependencies() []string
+}
+
+// Initializer is implemented by plugins which need to prepare resources (such
+// as API clients) before they are loaded. Init is called after the plugin's
+// configuration has been validated, and before Load.
+type Initializer interface {
+	Init(*Bot) error
+}
+
+// Starter is implemented by plugins which need to do work when the bot
+// connects to Slack. Start is called once the bot has authenticated, in the
+// order the plugins were loaded. If a plugin is loaded while the bot is
+// running, Start is called right after Load.
+type Starter interface {
+	Start(*Bot) error
+}
+
+// Stopper is implemented by plugins which need to clean up when the bot shuts
+// down. Stop is called in the reverse of the order the plugins were loaded.
+type Stopper interface {
+	Stop(*Bot) error
+}
+
+// Unloader is implemented by plugins which need to clean up when they are
+// removed from the bot with UnloadPlugin. Unload is also called if Load fails,
+// to clean up whatever Init and Load prepared.
+type Unloader interface {
+	Unload(*Bot) error
+}
+
+// Validator is implemented by typed plugin configuration. Any argument passed
+// to UsePlugin which implements Validator is validated before the plugin is
+// initialized, and the plugin is not loaded if validation fails.
+type Validator interface {
+	Validate() error
+}
+
+// PluginSpec pairs a plugin with the arguments that should be passed to its
+// Load function. See UsePlugins.
+type PluginSpec struct {
+	Plugin PluginFunc
+	Args   []interface{}
+}
+
+// PluginInfo describes a loaded plugin.
+type PluginInfo struct {
+	Name         string        `json:"name"`
+	Dependencies []string      `json:"dependencies"`
+	Handlers     []HandlerInfo `json:"handlers"`
+}
+
+// HandlerInfo describes a handler that was registered by a plugin. Subtype is
+// empty for handlers registered with OnEvent.
+type HandlerInfo struct {
+	Event   string `json:"event"`
+	Subtype string `json:"subtype,omitempty"`
+}
+
 // UsePlugin will load a plugin if the plugin can load. `args` is a
 // variable-length list of arguments that will be passed to the plugin's Load
 // function.
+//
+// An error is returned if the plugin cannot load, if a plugin with the same
+// name is already loaded, if any of its dependencies are not loaded, if any of
+// args fails validation, or if any of the plugin's lifecycle methods return an
+// error. If Load fails, or if the bot is running and the plugin fails to
+// start, the plugin is unloaded again.
 func (bot *Bot) UsePlugin(pluginF PluginFunc, args ...interface{}) error {
-	plugin := pluginF()
-	if plugin.CanLoad() {
-		if err := plugin.Load(bot, args...); err != nil {
+	return bot.usePlugin(pluginF(), args)
+}
+
+// UsePlugins loads several plugins, in an order which ensures that each plugin
+// is loaded after its dependencies. Dependencies may be satisfied either by
+// another plugin in specs, or by a plugin that is already loaded. An error is
+// returned if a dependency is missing or if the dependencies form a cycle, in
+// which case none of the plugins are loaded. Otherwise, plugins are loaded
+// until one fails to load.
+func (bot *Bot) UsePlugins(specs ...PluginSpec) error {
+	plugins := make(map[string]Plugin, len(specs))
+	args := make(map[string][]interface{}, len(specs))
+	var names []string
+	for _, spec := range specs {
+		plugin := spec.Plugin()
+		name := plugin.Name()
+		if _, ok := plugins[name]; ok {
+			return &Error{fmt.Sprintf("plugin %s is listed more than once", name)}
+		}
+		plugins[name] = plugin
+		args[name] = spec.Args
+		names = append(names, name)
+	}
+
+	order, err := bot.plugins.sort(names, plugins)
+	if err != nil {
+		return err
+	}
+	for _, name := range order {
+		if err := bot.usePlugin(plugins[name], args[name]); err != nil {
 			return err
 		}
-	} else {
-		log.WithFields(log.Fields{
-			"plugin": plugin.Name(),
-		}).Error("Failed to load plugin.")
 	}
 	return nil
 }
+
+// UnloadPlugin removes the plugin with the given name from the bot. Its
+// handlers are removed, and its Stop (if the bot is running) and Unload
+// methods are called. A plugin cannot be unloaded while other loaded plugins
+// depend on it.
+func (bot *Bot) UnloadPlugin(name string) error {
+	loaded, err := bot.plugins.remove(name)
+	if err != nil {
+		return err
+	}
+	for _, h := range loaded.handles {
+		h.Remove()
+	}
+	if stopper, ok := loaded.plugin.(Stopper); ok && loaded.started {
+		if err := stopper.Stop(bot); err != nil {
+			bot.logPluginError(name, "stop", err)
+		}
+	}
+	if unloader, ok := loaded.plugin.(Unloader); ok {
+		if err := unloader.Unload(bot); err != nil {
+			return pluginError(name, "unload", err)
+		}
+	}
+	return nil
+}
+
+// Own makes plugin the owner of handlers, so that they are removed when the
+// plugin is unloaded, disabled and enabled along with the plugin, and listed
+// by Plugins. Plugins call it with the handlers they register, usually from
+// Load:
+//
+//	func (p *karma) Load(bot *slack.Bot, _ ...interface{}) error {
+//		return bot.Own(p, bot.Listen(`(\w+)\+\+`, p.increment))
+//	}
+//
+// It returns an error if plugin is neither loading nor loaded.
+func (bot *Bot) Own(plugin Plugin, handlers ...*Handler) error {
+	return bot.plugins.own(plugin.Name(), handlers)
+}
+
+// DisablePlugin disables all of the handlers registered by the plugin with the
+// given name, without unloading it. This can be used to let admins toggle
+// plugins from chat.
+func (bot *Bot) DisablePlugin(name string) error {
+	handles, err := bot.plugins.handles(name)
+	if err != nil {
+		return err
+	}
+	for _, h := range handles {
+		h.Disable()
+	}
+	return nil
+}
+
+// EnablePlugin re-enables the handlers of a plugin disabled with
+// DisablePlugin.
+func (bot *Bot) EnablePlugin(name string) error {
+	handles, err := bot.plugins.handles(name)
+	if err != nil {
+		return err
+	}
+	for _, h := range handles {
+		h.Enable()
+	}
+	return nil
+}
+
+// Plugins returns information about each loaded plugin, in the order they
+// were loaded.
+func (bot *Bot) Plugins() []PluginInfo {
+	return bot.plugins.info()
+}
+
+// BrainFor returns the bot's Brain, namespaced by the name of plugin, so that
+// the plugin's keys do not collide with those of other plugins.
+func (bot *Bot) BrainFor(plugin Plugin) brain.Store {
+	return brain.Namespace(bot.Brain, plugin.Name())
+}
+
+func (bot *Bot) usePlugin(plugin Plugin, args []interface{}) error {
+	name := plugin.Name()
+	if !plugin.CanLoad() {
+		bot.Log().Error("Failed to load plugin.", Fields{
+			"plugin": name,
+		})
+		return &Error{fmt.Sprintf("plugin %s cannot load", name)}
+	}
+	if err := bot.plugins.check(plugin); err != nil {
+		return err
+	}
+	for _, arg := range args {
+		if validator, ok := arg.(Validator); ok {
+			if err := validator.Validate(); err != nil {
+				return pluginError(name, "validate config for", err)
+			}
+		}
+	}
+	if initializer, ok := plugin.(Initializer); ok {
+		if err := initializer.Init(bot); err != nil {
+			return pluginError(name, "initialize", err)
+		}
+	}
+
+	loaded, err := bot.plugins.begin(plugin)
+	if err != nil {
+		return err
+	}
+	err = plugin.Load(bot, args...)
+	bot.plugins.end(loaded, err == nil)
+	if err != nil {
+		for _, h := range loaded.handles {
+			h.Remove()
+		}
+		bot.unloadPlugin(loaded)
+		return err
+	}
+	if bot.plugins.isRunning() {
+		if err := bot.startPlugin(loaded); err != nil {
+			bot.unregisterPlugin(loaded)
+			return err
+		}
+	}
+	return nil
+}
+
+// unregisterPlugin unloads a plugin which loaded but could not start.
+func (bot *Bot) unregisterPlugin(loaded *loadedPlugin) {
+	name := loaded.plugin.Name()
+	if _, err := bot.plugins.remove(name); err != nil {
+		bot.logPluginError(name, "unregister", err)
+		return
+	}
+	for _, h := range loaded.handles {
+		h.Remove()
+	}
+	bot.unloadPlugin(loaded)
+}
+
+// unloadPlugin calls Unload on a plugin which is being removed because it
+// failed to load or start, logging any error.
+func (bot *Bot) unloadPlugin(loaded *loadedPlugin) {
+	if unloader, ok := loaded.plugin.(Unloader); ok {
+		if err := unloader.Unload(bot); err != nil {
+			bot.logPluginError(loaded.plugin.Name(), "unload", err)
+		}
+	}
+}
+
+// startPlugins calls Start on each loaded plugin. If any plugin fails to
+// start, the plugins which did start are stopped.
+func (bot *Bot) startPlugins() error {
+	bot.plugins.setRunning(true)
+	for _, loaded := range bot.plugins.loadedPlugins() {
+		if err := bot.startPlugin(loaded); err != nil {
+			bot.stopPlugins()
+			return err
+		}
+	}
+	return nil
+}
+
+func (bot *Bot) startPlugin(loaded *loadedPlugin) error {
+	if starter, ok := loaded.plugin.(Starter); ok {
+		if err := starter.Start(bot); err != nil {
+			return pluginError(loaded.plugin.Name(), "start", err)
+		}
+	}
+	bot.plugins.mu.Lock()
+	loaded.started = true
+	bot.plugins.mu.Unlock()
+	return nil
+}
+
+// stopPlugins calls Stop on each started plugin, in reverse load order.
+func (bot *Bot) stopPlugins() {
+	bot.plugins.setRunning(false)
+	plugins := bot.plugins.loadedPlugins()
+	for i := len(plugins) - 1; i >= 0; i-- {
+		loaded := plugins[i]
+		bot.plugins.mu.Lock()
+		started := loaded.started
+		loaded.started = false
+		bot.plugins.mu.Unlock()
+		if stopper, ok := loaded.plugin.(Stopper); ok && started {
+			if err := stopper.Stop(bot); err != nil {
+				bot.logPluginError(loaded.plugin.Name(), "stop", err)
+			}
+		}
+	}
+}
+
+func pluginError(name, action string, err error) error {
+	return &Error{fmt.Sprintf("could not %s plugin %s: %v", action, name, err)}
+}
+
+func (bot *Bot) logPluginError(name, action string, err error) {
+	bot.Log().Error(fmt.Sprintf("Failed to %s plugin.", action), Fields{
+		"plugin": name,
+		"error":  err,
+	})
+}
+
+type loadedPlugin struct {
+	plugin  Plugin
+	handles []*Handler
+	started bool
+}
+
+// pluginRegistry keeps track of the plugins loaded into a bot, and the
+// handlers each of them owns.
+type pluginRegistry struct {
+	mu      sync.Mutex
+	order   []*loadedPlugin
+	byName  map[string]*loadedPlugin
+	loading map[string]*loadedPlugin
+	running bool
+}
+
+func newPluginRegistry() *pluginRegistry {
+	return &pluginRegistry{
+		byName:  make(map[string]*loadedPlugin),
+		loading: make(map[string]*loadedPlugin),
+	}
+}
+
+func dependencies(plugin Plugin) []string {
+	if dependent, ok := plugin.(Dependent); ok {
+		return dependent.Dependencies()
+	}
+	return nil
+}
+
+// check returns an error if a plugin with the same name as plugin is already
+// loaded, or if any of its dependencies are not loaded.
+func (registry *pluginRegistry) check(plugin Plugin) error {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	if _, ok := registry.byName[plugin.Name()]; ok {
+		return &Error{fmt.Sprintf("plugin %s is already loaded", plugin.Name())}
+	}
+	for _, dependency := range dependencies(plugin) {
+		if _, ok := registry.byName[dependency]; !ok {
+			return &Error{fmt.Sprintf(
+				"plugin %s depends on %s, which is not loaded",
+				plugin.Name(), dependency,
+			)}
+		}
+	}
+	return nil
+}
+
+// sort returns names in an order where every plugin comes after its
+// dependencies.
+func (registry *pluginRegistry) sort(names []string, plugins map[string]Plugin) ([]string, error) {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make(map[string]int, len(names))
+	var order []string
+	var visit func(name string, path []string) error
+	visit = func(name string, path []string) error {
+		switch state[name] {
+		case visiting:
+			return &Error{fmt.Sprintf("plugin dependency cycle: %v", append(path, name))}
+		case visited:
+			return nil
+		}
+		state[name] = visiting
+		for _, dependency := range dependencies(plugins[name]) {
+			if _, ok := plugins[dependency]; ok {
+				if err := visit(dependency, append(path, name)); err != nil {
+					return err
+				}
+			} else if _, ok := registry.byName[dependency]; !ok {
+				return &Error{fmt.Sprintf(
+					"plugin %s depends on %s, which is not loaded", name, dependency,
+				)}
+			}
+		}
+		state[name] = visited
+		order = append(order, name)
+		return nil
+	}
+	for _, name := range names {
+		if err := visit(name, nil); err != nil {
+			return nil, err
+		}
+	}
+	return order, nil
+}
+
+// begin marks plugin as loading, so that it can own handlers during its Load.
+func (registry *pluginRegistry) begin(plugin Plugin) (*loadedPlugin, error) {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	name := plugin.Name()
+	if _, ok := registry.byName[name]; ok {
+		return nil, &Error{fmt.Sprintf("plugin %s is already loaded", name)}
+	}
+	if _, ok := registry.loading[name]; ok {
+		return nil, &Error{fmt.Sprintf("plugin %s is already loading", name)}
+	}
+	loaded := &loadedPlugin{plugin: plugin}
+	registry.loading[name] = loaded
+	return loaded, nil
+}
+
+// end finishes loading a plugin. Plugins which failed to load are not
+// registered.
+func (registry *pluginRegistry) end(loaded *loadedPlugin, ok bool) {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	delete(registry.loading, loaded.plugin.Name())
+	if !ok {
+		return
+	}
+	registry.order = append(registry.order, loaded)
+	registry.byName[loaded.plugin.Name()] = loaded
+}
+
+func (registry *pluginRegistry) remove(name string) (*loadedPlugin, error) {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	loaded, ok := registry.byName[name]
+	if !ok {
+		return nil, &Error{fmt.Sprintf("plugin %s is not loaded", name)}
+	}
+	for _, other := range registry.order {
+		for _, dependency := range dependencies(other.plugin) {
+			if dependency == name {
+				return nil, &Error{fmt.Sprintf(
+					"plugin %s is required by %s", name, other.plugin.Name(),
+				)}
+			}
+		}
+	}
+	delete(registry.byName, name)
+	for i, other := range registry.order {
+		if other == loaded {
+			registry.order = append(registry.order[:i], registry.order[i+1:]...)
+			break
+		}
+	}
+	return loaded, nil
+}
+
+// own adds handlers to those owned by the plugin with the given name, which
+// may be loading or loaded.
+func (registry *pluginRegistry) own(name string, handlers []*Handler) error {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	loaded, ok := registry.loading[name]
+	if !ok {
+		loaded, ok = registry.byName[name]
+	}
+	if !ok {
+		return &Error{fmt.Sprintf("plugin %s is not loaded", name)}
+	}
+	loaded.handles = append(loaded.handles, handlers...)
+	return nil
+}
+
+func (registry *pluginRegistry) handles(name string) ([]*Handler, error) {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	loaded, ok := registry.byName[name]
+	if !ok {
+		return nil, &Error{fmt.Sprintf("plugin %s is not loaded", name)}
+	}
+	return append([]*Handler(nil), loaded.handles...), nil
+}
+
+func (registry *pluginRegistry) info() []PluginInfo {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	infos := make([]PluginInfo, len(registry.order))
+	for i, loaded := range registry.order {
+		handlers := make([]HandlerInfo, len(loaded.handles))
+		for j, h := range loaded.handles {
+			handlers[j] = HandlerInfo{h.Event, h.Subtype}
+		}
+		infos[i] = PluginInfo{
+			Name:         loaded.plugin.Name(),
+			Dependencies: dependencies(loaded.plugin),
+			Handlers:     handlers,
+		}
+	}
+	return infos
+}
+
+func (registry *pluginRegistry) loadedPlugins() []*loadedPlugin {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	return append([]*loadedPlugin(nil), registry.order...)
+}
+
+func (registry *pluginRegistry) isRunning() bool {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	return registry.running
+}
+
+func (registry *pluginRegistry) setRunning(running bool) {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	registry.running = running
+}
@@ -36,7 +36,7 @@ func TestUsePlugin(t *testing.T) {
 	b := NewBot("token")
 
 	err := b.UsePlugin(cannotLoadF, t)
-	assert(err == nil, t)
+	assert(err != nil, t)
 
 	err = b.UsePlugin(loadsWithErrorF)
 	assert(err != nil, t)
@@ -44,3 +44,206 @@ func TestUsePlugin(t *testing.T) {
 	err = b.UsePlugin(loadsCleanlyF)
 	assert(err == nil, t)
 }
+
+func TestBrainFor(t *testing.T) {
+	b := NewBot("token")
+	store := b.BrainFor(loadsCleanlyF())
+	store.Set("key", []byte("value"), 0)
+	value, ok, _ := b.Brain.Get("loads cleanly/key")
+	assert(ok && string(value) == "value", t)
+}
+
+type lifecycle struct {
+	name  string
+	deps  []string
+	calls *[]string
+}
+
+func (p *lifecycle) Name() string           { return p.name }
+func (p *lifecycle) CanLoad() bool          { return true }
+func (p *lifecycle) Dependencies() []string { return p.deps }
+func (p *lifecycle) record(call string) error {
+	*p.calls = append(*p.calls, p.name+"."+call)
+	return nil
+}
+func (p *lifecycle) Init(_ *Bot) error   { return p.record("init") }
+func (p *lifecycle) Start(_ *Bot) error  { return p.record("start") }
+func (p *lifecycle) Stop(_ *Bot) error   { return p.record("stop") }
+func (p *lifecycle) Unload(_ *Bot) error { return p.record("unload") }
+func (p *lifecycle) Load(b *Bot, _ ...interface{}) error {
+	err := b.Own(p,
+		b.OnEvent("message", shutdownHandler),
+		b.OnEventWithSubtype("message", "channel_join", shutdownHandler),
+	)
+	if err != nil {
+		return err
+	}
+	return p.record("load")
+}
+
+func lifecycleF(name string, calls *[]string, deps ...string) PluginFunc {
+	return func() Plugin { return &lifecycle{name, deps, calls} }
+}
+
+func compareCalls(expected, actual []string, t *testing.T) {
+	if len(expected) != len(actual) {
+		t.Errorf("Error. Expected calls %v. Got %v.", expected, actual)
+		return
+	}
+	for i := range expected {
+		if expected[i] != actual[i] {
+			t.Errorf("Error. Expected calls %v. Got %v.", expected, actual)
+			return
+		}
+	}
+}
+
+func TestPluginLifecycle(t *testing.T) {
+	b := NewBot("token")
+	var calls []string
+	err := b.UsePlugins(
+		PluginSpec{Plugin: lifecycleF("c", &calls, "b")},
+		PluginSpec{Plugin: lifecycleF("a", &calls)},
+		PluginSpec{Plugin: lifecycleF("b", &calls, "a")},
+	)
+	assert(err == nil, t)
+	compareCalls([]string{"a.init", "a.load", "b.init", "b.load", "c.init", "c.load"}, calls, t)
+
+	infos := b.Plugins()
+	assert(len(infos) == 3, t)
+	assert(infos[0].Name == "a" && infos[2].Name == "c", t)
+	assert(len(infos[1].Handlers) == 2, t)
+	assert(infos[1].Handlers[1] == HandlerInfo{"message", "channel_join"}, t)
+
+	calls = nil
+	assert(b.startPlugins() == nil, t)
+	b.stopPlugins()
+	compareCalls([]string{"a.start", "b.start", "c.start", "c.stop", "b.stop", "a.stop"}, calls, t)
+
+	assert(b.UnloadPlugin("a") != nil, t)
+	assert(b.UnloadPlugin("missing") != nil, t)
+	calls = nil
+	assert(b.UnloadPlugin("c") == nil, t)
+	compareCalls([]string{"c.unload"}, calls, t)
+	assert(len(b.Plugins()) == 2, t)
+
+	event := map[string]interface{}{"type": "message", "subtype": "channel_join"}
+	// c's handlers were removed
+	assert(len(b.handle(event)) == 4, t)
+
+	assert(b.DisablePlugin("b") == nil, t)
+	messages := 0
+	for _, wrapper := range b.handle(event) {
+		if wrapper.message != nil {
+			messages++
+		}
+	}
+	assert(messages == 2, t)
+	assert(b.EnablePlugin("b") == nil, t)
+	assert(b.DisablePlugin("c") != nil, t)
+}
+
+func TestPluginDependencyErrors(t *testing.T) {
+	b := NewBot("token")
+	var calls []string
+	assert(b.UsePlugin(lifecycleF("b", &calls, "a")) != nil, t)
+	assert(b.UsePlugins(PluginSpec{Plugin: lifecycleF("b", &calls, "a")}) != nil, t)
+	err := b.UsePlugins(
+		PluginSpec{Plugin: lifecycleF("a", &calls, "b")},
+		PluginSpec{Plugin: lifecycleF("b", &calls, "a")},
+	)
+	assert(err != nil, t)
+	assert(len(calls) == 0, t)
+
+	assert(b.UsePlugin(lifecycleF("a", &calls)) == nil, t)
+	// a duplicate is refused before it is initialized
+	calls = nil
+	assert(b.UsePlugin(lifecycleF("a", &calls)) != nil, t)
+	assert(len(calls) == 0, t)
+	assert(b.UsePlugin(lifecycleF("b", &calls, "a")) == nil, t)
+}
+
+type badConfig struct{}
+
+func (_ badConfig) Validate() error { return &Error{"missing token"} }
+
+func TestPluginConfigValidation(t *testing.T) {
+	b := NewBot("token")
+	var calls []string
+	assert(b.UsePlugin(lifecycleF("a", &calls), badConfig{}) != nil, t)
+	assert(len(calls) == 0, t)
+	assert(len(b.Plugins()) == 0, t)
+}
+
+func TestPluginLoadedWhileRunning(t *testing.T) {
+	b := NewBot("token")
+	var calls []string
+	b.startPlugins()
+	assert(b.UsePlugin(lifecycleF("a", &calls)) == nil, t)
+	compareCalls([]string{"a.init", "a.load", "a.start"}, calls, t)
+}
+
+type unstartable struct {
+	lifecycle
+}
+
+func (p *unstartable) Start(_ *Bot) error {
+	p.record("start")
+	return &Error{"no port"}
+}
+
+func TestPluginStartFailsWhileRunning(t *testing.T) {
+	b := NewBot("token")
+	var calls []string
+	b.startPlugins()
+	err := b.UsePlugin(func() Plugin { return &unstartable{lifecycle{"a", nil, &calls}} })
+	assert(err != nil, t)
+	compareCalls([]string{"a.init", "a.load", "a.start", "a.unload"}, calls, t)
+	assert(len(b.Plugins()) == 0, t)
+	assert(len(b.handle(map[string]interface{}{"type": "message"})) == 0, t)
+}
+
+type unloadable struct {
+	lifecycle
+}
+
+func (p *unloadable) Load(b *Bot, _ ...interface{}) error {
+	b.Own(p, b.OnEvent("message", shutdownHandler))
+	p.record("load")
+	return &Error{"no config"}
+}
+
+func TestPluginLoadFails(t *testing.T) {
+	b := NewBot("token")
+	var calls []string
+	err := b.UsePlugin(func() Plugin { return &unloadable{lifecycle{"a", nil, &calls}} })
+	assert(err != nil, t)
+	compareCalls([]string{"a.init", "a.load", "a.unload"}, calls, t)
+	assert(len(b.Plugins()) == 0, t)
+	assert(len(b.handle(map[string]interface{}{"type": "message"})) == 0, t)
+}
+
+type sharesBot struct {
+	lifecycle
+}
+
+func (p *sharesBot) Load(b *Bot, _ ...interface{}) error {
+	b.Own(p, b.OnEvent("message", shutdownHandler))
+	// as if another goroutine registered a handler while p was loading
+	b.OnEvent("hello", shutdownHandler)
+	return p.record("load")
+}
+
+func TestPluginOwnsOnlyItsHandlers(t *testing.T) {
+	b := NewBot("token")
+	var calls []string
+	assert(b.UsePlugin(func() Plugin { return &sharesBot{lifecycle{"a", nil, &calls}} }) == nil, t)
+	infos := b.Plugins()
+	assert(len(infos) == 1 && len(infos[0].Handlers) == 1, t)
+	assert(infos[0].Handlers[0] == HandlerInfo{"message", ""}, t)
+
+	assert(b.UnloadPlugin("a") == nil, t)
+	assert(len(b.handle(map[string]interface{}{"type": "message"})) == 0, t)
+	assert(len(b.handle(map[string]interface{}{"type": "hello"})) == 1, t)
+	assert(b.Own(&loadsCleanly{}, b.OnEvent("hello", shutdownHandler)) != nil, t)
+}
@@ -0,0 +1,289 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ajm188/slack"
+	"github.com/ajm188/slack/brain"
+	"github.com/ajm188/slack/format"
+	"github.com/google/go-github/github"
+)
+
+const (
+	// DefaultIssueEmoji is the reaction which opens an issue from a message,
+	// unless another one is given to CaptureIssues.
+	DefaultIssueEmoji = "ticket"
+
+	// capturedNamespace is the namespace of the plugin's Brain which
+	// remembers the issues opened from messages and threads.
+	capturedNamespace = "captured"
+	// titleLimit is the most characters of a message used as an issue title.
+	titleLimit = 80
+	// defaultTitle is the title of an issue opened from a message without
+	// any text.
+	defaultTitle = "Issue from Slack"
+)
+
+var captureRe = regexp.MustCompile(`^issue this(?:\s+([^/\s"]+)/([^/\s"]+))?(?:\s+"(.*)")?\s*$`)
+
+// CaptureIssues registers handlers which open Github issues from Slack
+// messages and threads, so that bug reports do not need to be retyped. It
+// returns the registered handlers.
+//
+// Reacting to a message with emoji (DefaultIssueEmoji if it is empty) opens an
+// issue from that message in the channel's default repository. defaultRepos
+// maps channel names or IDs to the "<owner>/<repo>" of their default
+// repository, as it does for Unfurl.
+//
+// Mentioning the bot in a thread with 'issue this (<owner>/<repo>)?
+// ("<title>")?' opens an issue from the whole thread, in the given repository
+// or the channel's default one.
+//
+// Unless a title is given, the issue is titled with the first line of the
+// message (or of the thread's first message). Its body contains a transcript of
+// the message or thread, and a permalink to it. The bot replies in the thread
+// with a link to the issue. A message or thread is only opened as an issue
+// once; asking again replies with the link to the existing issue. captured
+// remembers the issues which have been opened; the Plugin passes a namespace of
+// its Bot.BrainFor, so that differently named instances do not share it.
+func CaptureIssues(bot *slack.Bot, client *github.Client, captured brain.Store, emoji string, defaultRepos map[string]string) []*slack.Handler {
+	if emoji == "" {
+		emoji = DefaultIssueEmoji
+	}
+	c := &capturer{
+		client:       client,
+		captured:     captured,
+		emoji:        strings.Trim(emoji, ":"),
+		defaultRepos: defaultRepos,
+	}
+	return []*slack.Handler{
+		bot.OnReactionAdded(c.emoji, "", c.reaction),
+		bot.RespondRegexp(captureRe, c.thread),
+	}
+}
+
+type capturer struct {
+	client       *github.Client
+	captured     brain.Store
+	emoji        string
+	defaultRepos map[string]string
+}
+
+func (c *capturer) reaction(b *slack.Bot, event map[string]interface{}) (*slack.Message, slack.Status) {
+	userID, _ := event["user"].(string)
+	item, _ := event["item"].(map[string]interface{})
+	itemType, _ := item["type"].(string)
+	if itemType != "message" || userID == b.ID {
+		return nil, slack.Continue
+	}
+	channel, _ := item["channel"].(string)
+	ts, _ := item["ts"].(string)
+
+	messages, err := b.Replies(channel, ts)
+	if err != nil {
+		logCaptureError(b, channel, ts, err)
+		return nil, slack.Continue
+	}
+	var message map[string]interface{}
+	for _, m := range messages {
+		if m["ts"] == ts {
+			message = m
+		}
+	}
+	if message == nil {
+		return nil, slack.Continue
+	}
+	thread, ok := message["thread_ts"].(string)
+	if !ok {
+		thread = ts
+	}
+
+	owner, repo, ok := defaultRepo(b, c.defaultRepos, channel)
+	if !ok {
+		text := fmt.Sprintf(
+			"I don't know which repository issues from %s belong in. Mention me in the thread with \"issue this <owner>/<repo>\" instead.",
+			format.Channel(channel),
+		)
+		return b.Mention(userID, text, channel).InThread(thread), slack.Continue
+	}
+	return c.open(b.Context(event), b, userID, owner, repo, "", channel, ts, thread, []map[string]interface{}{message}), slack.Continue
+}
+
+func (c *capturer) thread(b *slack.Bot, event map[string]interface{}) (*slack.Message, slack.Status) {
+	userID, _ := event["user"].(string)
+	channel, _ := event["channel"].(string)
+	ts, _ := event["ts"].(string)
+	thread, ok := event["thread_ts"].(string)
+	if !ok {
+		return b.Mention(userID, "Ask me in a thread, and I'll open an issue from it.", channel), slack.Continue
+	}
+	rest, _ := b.Addressed(event)
+	m := captureRe.FindStringSubmatch(rest)
+	owner, repo, title := m[1], m[2], format.Unescape(m[3])
+	if owner == "" {
+		if owner, repo, ok = defaultRepo(b, c.defaultRepos, channel); !ok {
+			text := "I don't know which repository issues from here belong in. Try \"issue this <owner>/<repo>\"."
+			return b.Mention(userID, text, channel).InThread(thread), slack.Continue
+		}
+	}
+
+	messages, err := b.Replies(channel, thread)
+	if err != nil {
+		logCaptureError(b, channel, thread, err)
+		text := fmt.Sprintf("I couldn't read this thread. Here was the error I got:\n%v", err)
+		return b.Mention(userID, text, channel).InThread(thread), slack.Continue
+	}
+	// The request to open the issue is not part of the transcript.
+	var transcript []map[string]interface{}
+	for _, message := range messages {
+		if message["ts"] != ts {
+			transcript = append(transcript, message)
+		}
+	}
+	return c.open(b.Context(event), b, userID, owner, repo, title, channel, thread, thread, transcript), slack.Continue
+}
+
+// open opens an issue from messages, which were captured from the message or
+// thread with the timestamp ts, unless it has already been opened as an issue.
+// It returns the reply to send in thread. ctx is the context of the event
+// being handled.
+func (c *capturer) open(ctx context.Context, b *slack.Bot, userID, owner, repo, title, channel, ts, thread string, messages []map[string]interface{}) *slack.Message {
+	reply := func(text string) *slack.Message {
+		return b.Mention(userID, text, channel).InThread(thread)
+	}
+	if len(messages) == 0 {
+		return reply("There's nothing here to open an issue from.")
+	}
+	key := channel + "/" + ts
+	if link, ok, _ := c.captured.Get(key); ok {
+		return reply("This has already been opened as an issue: " + string(link))
+	}
+
+	permalink, err := b.Permalink(channel, ts)
+	if err != nil {
+		// The issue is still worth opening without the link.
+		logCaptureError(b, channel, ts, err)
+	}
+	if title == "" {
+		text, _ := messages[0]["text"].(string)
+		title = issueTitle(plainText(b, text))
+	}
+	name, ok := displayName(b, userID)
+	if !ok {
+		name = userID
+	}
+	body := captureBody(b, messages, permalink, name)
+	state := "open"
+	var issue *github.Issue
+	err = traced(ctx, b, "github.issues.create", func(ctx context.Context) (err error) {
+		issue, _, err = c.client.Issues.Create(ctx, owner, repo, &github.IssueRequest{
+			Title: &title,
+			Body:  &body,
+			State: &state,
+		})
+		return
+	})
+	if err != nil {
+		return reply(fmt.Sprintf("I had some trouble opening an issue. Here was the error I got:\n%v", err))
+	}
+	link := stringValue(issue.HTMLURL)
+	c.captured.Set(key, []byte(link), 0)
+	return reply("I opened an issue for this: " + link)
+}
+
+// captureBody formats the body of an issue opened by the user called name from
+// messages, which can be found at permalink.
+func captureBody(b *slack.Bot, messages []map[string]interface{}, permalink, name string) string {
+	source := "Slack"
+	if permalink != "" {
+		source = fmt.Sprintf("[Slack](%s)", permalink)
+	}
+	parts := []string{fmt.Sprintf("Opened from %s on behalf of %s.", source, name)}
+	for _, message := range messages {
+		text, _ := message["text"].(string)
+		header := "**" + author(b, message) + "**"
+		if ts, ok := message["ts"].(string); ok {
+			if t, ok := parseTimestamp(ts); ok {
+				header += " at " + t.UTC().Format("2006-01-02 15:04 MST")
+			}
+		}
+		lines := strings.Split(plainText(b, text), "\n")
+		for i, line := range lines {
+			lines[i] = strings.TrimRight("> "+line, " ")
+		}
+		parts = append(parts, header+"\n"+strings.Join(lines, "\n"))
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// issueTitle returns the first line of text, shortened to at most titleLimit
+// characters.
+func issueTitle(text string) string {
+	title := strings.TrimSpace(strings.SplitN(strings.TrimSpace(text), "\n", 2)[0])
+	if title == "" {
+		return defaultTitle
+	}
+	if runes := []rune(title); len(runes) > titleLimit {
+		title = strings.TrimSpace(string(runes[:titleLimit-1])) + "…"
+	}
+	return title
+}
+
+// plainText converts the text of a Slack message into plain text for an issue.
+// Users are given by their Slack nicks, in code spans so that Github does not
+// mistake them for mentions of its own users.
+func plainText(b *slack.Bot, text string) string {
+	plain, entities := format.Parse(text)
+	for i := len(entities) - 1; i >= 0; i-- {
+		entity := entities[i]
+		if entity.Kind != format.UserEntity {
+			continue
+		}
+		name := entity.Label
+		if user, ok := b.Users[entity.ID]; ok {
+			name = user.Nick
+		} else if name == "" {
+			name = entity.ID
+		}
+		plain = plain[:entity.Offset] + "`@" + name + "`" + plain[entity.Offset+entity.Length:]
+	}
+	return plain
+}
+
+// author returns the nick of the user who sent message, or the name of the bot
+// which sent it.
+func author(b *slack.Bot, message map[string]interface{}) string {
+	if userID, ok := message["user"].(string); ok {
+		if user, ok := b.Users[userID]; ok {
+			return user.Nick
+		}
+		return userID
+	}
+	if username, ok := message["username"].(string); ok {
+		return username
+	}
+	return "someone"
+}
+
+// parseTimestamp parses a Slack message timestamp, like "1508284197.000015".
+func parseTimestamp(ts string) (time.Time, bool) {
+	parts := strings.SplitN(ts, ".", 2)
+	seconds, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.Unix(seconds, 0), true
+}
+
+func logCaptureError(b *slack.Bot, channel, ts string, err error) {
+	b.Log().Error("Failed to capture Slack messages for a Github issue.", slack.Fields{
+		"channel": channel,
+		"ts":      ts,
+		"error":   err,
+	})
+}
@@ -0,0 +1,152 @@
+package github
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ajm188/slack"
+	"github.com/ajm188/slack/brain"
+)
+
+func TestIssueTitle(t *testing.T) {
+	long := "The deploy pipeline fails every time somebody pushes a tag which contains a slash in it"
+	tests := []struct {
+		text     string
+		expected string
+	}{
+		{"Login is broken\nSee the logs", "Login is broken"},
+		{"  \n", defaultTitle},
+		{long, long[:79] + "…"},
+	}
+	for _, test := range tests {
+		if actual := issueTitle(test.text); actual != test.expected {
+			t.Errorf("Error. Expected title %q for %q, got %q.", test.expected, test.text, actual)
+		}
+	}
+}
+
+func TestPlainText(t *testing.T) {
+	bot := testBot()
+	tests := []struct {
+		text     string
+		expected string
+	}{
+		{"<@U1> it &lt;crashed&gt;", "`@alice` it <crashed>"},
+		{"ask <@U2|bob> in <#C1|general>", "ask `@bob` in #general"},
+		{"see <https://example.com|the logs>", "see the logs"},
+	}
+	for _, test := range tests {
+		if actual := plainText(bot, test.text); actual != test.expected {
+			t.Errorf("Error. Expected %q to become %q, got %q.", test.text, test.expected, actual)
+		}
+	}
+}
+
+func TestCaptureBody(t *testing.T) {
+	bot := testBot()
+	messages := []map[string]interface{}{
+		{"user": "U1", "text": "Login is broken\nafter the deploy", "ts": "1508284197.000015"},
+		{"username": "deploybot", "text": "rolled back", "ts": "1508284257.000020"},
+	}
+	expected := "Opened from [Slack](https://example.slack.com/p1) on behalf of bob.\n\n" +
+		"**alice** at 2017-10-17 23:49 UTC\n> Login is broken\n> after the deploy\n\n" +
+		"**deploybot** at 2017-10-17 23:50 UTC\n> rolled back"
+	if actual := captureBody(bot, messages, "https://example.slack.com/p1", "bob"); actual != expected {
+		t.Errorf("Error. Expected body %q, got %q.", expected, actual)
+	}
+	if actual := captureBody(bot, messages[1:], "", "bob"); actual != "Opened from Slack on behalf of bob.\n\n**deploybot** at 2017-10-17 23:50 UTC\n> rolled back" {
+		t.Errorf("Error. Unexpected body without a permalink: %q.", actual)
+	}
+}
+
+func TestCaptureIgnores(t *testing.T) {
+	bot := testBot()
+	bot.ID = "B1"
+	handlers := CaptureIssues(bot, nil, brain.NewMemory(), ":bug:", nil)
+	if len(handlers) != 2 {
+		t.Fatalf("Error. Expected 2 handlers, got %d.", len(handlers))
+	}
+	c := &capturer{emoji: "bug"}
+	events := []map[string]interface{}{
+		{"reaction": "bug", "user": "U1", "item": map[string]interface{}{"type": "file"}},
+		{"reaction": "bug", "user": "B1", "item": map[string]interface{}{"type": "message"}},
+	}
+	for _, event := range events {
+		if message, status := c.reaction(bot, event); message != nil || status != slack.Continue {
+			t.Errorf("Error. Expected %v to be ignored.", event)
+		}
+	}
+	event := map[string]interface{}{"user": "U1", "channel": "C1", "ts": "1.1", "text": "<@B1> issue this"}
+	if message, _ := c.thread(bot, event); message == nil {
+		t.Error("Error. Expected a reply when not asked in a thread.")
+	}
+	event["thread_ts"] = "1.0"
+	if message, _ := c.thread(bot, event); message == nil {
+		t.Error("Error. Expected a reply when the channel has no default repository.")
+	}
+}
+
+func TestCaptureRemembersPerPlugin(t *testing.T) {
+	client, requests, stop := testServer(t, map[string]string{
+		"POST /conversations.replies": `{"ok": true, "messages": [{"user": "U1", "text": "Login is broken", "ts": "1.1"}]}`,
+		"POST /chat.getPermalink":     `{"ok": true, "permalink": "https://example.slack.com/p1"}`,
+		"POST /repos/o/r/issues":      `{"number": 2, "html_url": "https://github.com/o/r/issues/2"}`,
+	})
+	defer stop()
+	bot := testBot()
+	bot.APIURL = client.BaseURL.String()
+	bot.Brain = brain.NewMemory()
+	repos := map[string]string{"C1": "o/r"}
+	first := &capturer{client, brain.Namespace(bot.BrainFor(New()), capturedNamespace), "bug", repos}
+	second := &capturer{client, brain.Namespace(bot.BrainFor(New(WithName("github-2"))), capturedNamespace), "bug", repos}
+
+	// The reaction's skin tone was already ignored by OnReactionAdded.
+	event := map[string]interface{}{
+		"reaction": "bug::skin-tone-2",
+		"user":     "U1",
+		"item":     map[string]interface{}{"type": "message", "channel": "C1", "ts": "1.1"},
+	}
+	tests := []struct {
+		c        *capturer
+		expected string
+	}{
+		{first, "I opened an issue for this: https://github.com/o/r/issues/2"},
+		{first, "This has already been opened as an issue: https://github.com/o/r/issues/2"},
+		{second, "I opened an issue for this: https://github.com/o/r/issues/2"},
+	}
+	for i, test := range tests {
+		delete(requests, "POST /repos/o/r/issues")
+		message, _ := test.c.reaction(bot, event)
+		if message == nil || !strings.HasSuffix(message.Text(), test.expected) {
+			t.Errorf("Error. Test %d: expected a reply ending in %q, got %v.", i, test.expected, message)
+		}
+		if _, opened := requests["POST /repos/o/r/issues"]; opened != (i != 1) {
+			t.Errorf("Error. Test %d: unexpected issue request %v.", i, opened)
+		}
+	}
+}
+
+func TestCaptureRe(t *testing.T) {
+	tests := []struct {
+		text     string
+		expected []string
+	}{
+		{"issue this", []string{"", "", ""}},
+		{"issue this o/r", []string{"o", "r", ""}},
+		{`issue this o/r "Login is broken"`, []string{"o", "r", "Login is broken"}},
+		{`issue this "Login is broken"`, []string{"", "", "Login is broken"}},
+		{"issue this please", nil},
+	}
+	for _, test := range tests {
+		m := captureRe.FindStringSubmatch(test.text)
+		if test.expected == nil {
+			if m != nil {
+				t.Errorf("Error. Expected %q not to match.", test.text)
+			}
+			continue
+		}
+		if m == nil || m[1] != test.expected[0] || m[2] != test.expected[1] || m[3] != test.expected[2] {
+			t.Errorf("Error. Expected %q to match %q, got %q.", test.text, test.expected, m)
+		}
+	}
+}
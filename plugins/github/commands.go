@@ -0,0 +1,258 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/ajm188/slack"
+	"github.com/ajm188/slack/format"
+	"github.com/google/go-github/github"
+)
+
+// listLimit is the most issues the "gh issues" command will list.
+const listLimit = 10
+
+const (
+	repoPattern      = `([\w.-]+)/([\w.-]+)`
+	referencePattern = repoPattern + `#(\d+)`
+)
+
+// reference identifies an issue or pull request in a repository.
+type reference struct {
+	owner  string
+	repo   string
+	number int
+}
+
+func (ref reference) String() string {
+	return fmt.Sprintf("%s/%s#%d", ref.owner, ref.repo, ref.number)
+}
+
+// referenceFrom builds a reference from the owner, repo and number captured by
+// referencePattern, starting at m[i].
+func referenceFrom(m []string, i int) reference {
+	number, _ := strconv.Atoi(m[i+2])
+	return reference{m[i], m[i+1], number}
+}
+
+// Commands registers handlers which let users work with issues and pull
+// requests from chat, using client. It returns the registered handlers. The
+// commands are all "Responds", and are:
+//
+//	gh show <owner>/<repo>#<number>
+//	gh issues <owner>/<repo> [<query>]
+//	gh comment <owner>/<repo>#<number> <text>
+//	gh close <owner>/<repo>#<number>
+//	gh reopen <owner>/<repo>#<number>
+//	gh label <owner>/<repo>#<number> <label>[, <label>...]
+//	gh assign <owner>/<repo>#<number> <login> [<login>...]
+//
+// "show" replies with a summary of the issue or pull request: its title,
+// state, author, assignees, labels and, for pull requests, the combined CI
+// status of its head commit. "issues" lists the open issues in the repository
+// which match the query, using Github's issue search syntax.
+func Commands(bot *slack.Bot, client *github.Client) []*slack.Handler {
+	c := &commands{client}
+	runs := map[string]func(context.Context, *slack.Bot, string, []string) (string, error){
+		"show":    c.show,
+		"issues":  c.list,
+		"comment": c.comment,
+		"state":   c.setState,
+		"label":   c.label,
+		"assign":  c.assign,
+	}
+	handlers := make([]*slack.Handler, len(commandPatterns))
+	for i, p := range commandPatterns {
+		handlers[i] = bot.RespondRegexp(p.re, command(p.name, p.re, runs[p.name]))
+	}
+	return handlers
+}
+
+// commandPatterns are the patterns of the commands registered by Commands.
+var commandPatterns = []struct {
+	name string
+	re   *regexp.Regexp
+}{
+	{"show", regexp.MustCompile(`^gh show ` + referencePattern + `\s*$`)},
+	{"issues", regexp.MustCompile(`^gh issues ` + repoPattern + `\s*(.*)$`)},
+	{"comment", regexp.MustCompile(`(?s)^gh comment ` + referencePattern + `\s+(.+)$`)},
+	{"state", regexp.MustCompile(`^gh (close|reopen) ` + referencePattern + `\s*$`)},
+	{"label", regexp.MustCompile(`^gh label ` + referencePattern + `\s+(.+)$`)},
+	{"assign", regexp.MustCompile(`^gh assign ` + referencePattern + `\s+(.+)$`)},
+}
+
+// isCommand reports whether text, addressed to the bot, is one of the commands
+// registered by Commands, OpenIssue or CaptureIssues.
+func isCommand(text string) bool {
+	if openIssueRe.MatchString(text) || captureRe.MatchString(text) {
+		return true
+	}
+	for _, p := range commandPatterns {
+		if p.re.MatchString(text) {
+			return true
+		}
+	}
+	return false
+}
+
+type commands struct {
+	client *github.Client
+}
+
+// command wraps run, the command called name, in a BotAction. run receives the
+// context of the event (see slack.Bot.Context), the bot, the ID of the user who
+// sent the command, and the submatches of re in the command, and returns the
+// text of the reply to that user.
+func command(name string, re *regexp.Regexp, run func(context.Context, *slack.Bot, string, []string) (string, error)) slack.BotAction {
+	return func(b *slack.Bot, event map[string]interface{}) (*slack.Message, slack.Status) {
+		text, _ := b.Addressed(event)
+		m := re.FindStringSubmatch(text)
+		userID, _ := event["user"].(string)
+		channel, _ := event["channel"].(string)
+		if m == nil || userID == "" {
+			return nil, slack.Continue
+		}
+		ctx := b.Context(event)
+		var reply string
+		err := traced(ctx, b, "github.command."+name, func(ctx context.Context) (err error) {
+			reply, err = run(ctx, b, userID, m)
+			return
+		})
+		if err != nil {
+			reply = fmt.Sprintf(
+				"I had some trouble talking to Github. Here was the error I got:\n%v",
+				err)
+		}
+		return b.Mention(userID, reply, channel), slack.Continue
+	}
+}
+
+func (c *commands) show(ctx context.Context, _ *slack.Bot, _ string, m []string) (string, error) {
+	ref := referenceFrom(m, 1)
+	issue, _, err := c.client.Issues.Get(ctx, ref.owner, ref.repo, ref.number)
+	if err != nil {
+		return "", err
+	}
+	if issue.PullRequestLinks == nil {
+		return summarize(ref, issue, nil, nil), nil
+	}
+	pull, _, err := c.client.PullRequests.Get(ctx, ref.owner, ref.repo, ref.number)
+	if err != nil {
+		return "", err
+	}
+	var status *github.CombinedStatus
+	if pull.Head != nil && pull.Head.SHA != nil {
+		status, _, err = c.client.Repositories.GetCombinedStatus(ctx, ref.owner, ref.repo, *pull.Head.SHA, nil)
+		if err != nil {
+			return "", err
+		}
+	}
+	return summarize(ref, issue, pull, status), nil
+}
+
+func (c *commands) list(ctx context.Context, _ *slack.Bot, _ string, m []string) (string, error) {
+	owner, repo := m[1], m[2]
+	query := fmt.Sprintf("repo:%s/%s is:open is:issue %s", owner, repo, format.Unescape(m[3]))
+	result, _, err := c.client.Search.Issues(ctx, strings.TrimSpace(query), &github.SearchOptions{
+		ListOptions: github.ListOptions{PerPage: listLimit},
+	})
+	if err != nil {
+		return "", err
+	}
+	if len(result.Issues) == 0 {
+		return "I couldn't find any open issues matching that.", nil
+	}
+	total := len(result.Issues)
+	if result.Total != nil {
+		total = *result.Total
+	}
+	lines := []string{fmt.Sprintf("Here are %d of the %d matching open issues:", len(result.Issues), total)}
+	for i := range result.Issues {
+		issue := &result.Issues[i]
+		ref := reference{owner, repo, intValue(issue.Number)}
+		lines = append(lines, fmt.Sprintf(
+			"• %s %s",
+			format.Link(stringValue(issue.HTMLURL), ref.String()),
+			format.Escape(stringValue(issue.Title)),
+		))
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+func (c *commands) comment(ctx context.Context, b *slack.Bot, userID string, m []string) (string, error) {
+	ref := referenceFrom(m, 1)
+	name, ok := displayName(b, userID)
+	if !ok {
+		return "I don't know who you are, so I can't comment on your behalf.", nil
+	}
+	body := fmt.Sprintf(
+		"%s\n\n Comment posted via slack on behalf of %s",
+		format.Unescape(m[4]),
+		name,
+	)
+	comment, _, err := c.client.Issues.CreateComment(ctx, ref.owner, ref.repo, ref.number, &github.IssueComment{
+		Body: &body,
+	})
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("I commented on %s.", format.Link(stringValue(comment.HTMLURL), ref.String())), nil
+}
+
+func (c *commands) setState(ctx context.Context, _ *slack.Bot, _ string, m []string) (string, error) {
+	ref := referenceFrom(m, 2)
+	state, verb := "closed", "closed"
+	if m[1] == "reopen" {
+		state, verb = "open", "reopened"
+	}
+	issue, _, err := c.client.Issues.Edit(ctx, ref.owner, ref.repo, ref.number, &github.IssueRequest{
+		State: &state,
+	})
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("I %s %s.", verb, format.Link(stringValue(issue.HTMLURL), ref.String())), nil
+}
+
+func (c *commands) label(ctx context.Context, _ *slack.Bot, _ string, m []string) (string, error) {
+	ref := referenceFrom(m, 1)
+	labels := splitList(format.Unescape(m[4]), ",")
+	if len(labels) == 0 {
+		return "Which labels should I add?", nil
+	}
+	_, _, err := c.client.Issues.AddLabelsToIssue(ctx, ref.owner, ref.repo, ref.number, labels)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("I added %s to %s.", format.Escape(strings.Join(labels, ", ")), ref), nil
+}
+
+func (c *commands) assign(ctx context.Context, _ *slack.Bot, _ string, m []string) (string, error) {
+	ref := referenceFrom(m, 1)
+	var logins []string
+	for _, login := range splitList(m[4], ", ") {
+		logins = append(logins, strings.TrimPrefix(login, "@"))
+	}
+	_, _, err := c.client.Issues.AddAssignees(ctx, ref.owner, ref.repo, ref.number, logins)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("I assigned %s to %s.", format.Escape(strings.Join(logins, ", ")), ref), nil
+}
+
+// splitList splits s at any of the characters in separators, and returns the
+// non-empty, trimmed parts.
+func splitList(s, separators string) []string {
+	var parts []string
+	for _, part := range strings.FieldsFunc(s, func(r rune) bool {
+		return strings.ContainsRune(separators, r)
+	}) {
+		if part = strings.TrimSpace(part); part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return parts
+}
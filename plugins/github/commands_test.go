@@ -0,0 +1,239 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/ajm188/slack"
+	"github.com/google/go-github/github"
+)
+
+// testServer starts a fake Github API which serves each of responses as JSON
+// at the path (prefixed by the method) it is keyed by. Request bodies are
+// recorded in requests.
+func testServer(t *testing.T, responses map[string]string) (*github.Client, map[string]string, func()) {
+	requests := make(map[string]string)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Method + " " + r.URL.Path
+		body, _ := ioutil.ReadAll(r.Body)
+		requests[key] = string(body)
+		response, ok := responses[key]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"message": "Not Found"}`))
+			return
+		}
+		w.Write([]byte(response))
+	}))
+	client := github.NewClient(nil)
+	client.BaseURL, _ = url.Parse(server.URL + "/")
+	return client, requests, server.Close
+}
+
+func testBot() *slack.Bot {
+	bot := slack.NewBot("token")
+	user := &slack.User{ID: "U1", Nick: "alice"}
+	bot.Users["U1"] = user
+	bot.Users["alice"] = user
+	return bot
+}
+
+func match(pattern, text string) []string {
+	return regexp.MustCompile(pattern).FindStringSubmatch(text)
+}
+
+func TestShow(t *testing.T) {
+	client, _, stop := testServer(t, map[string]string{
+		"GET /repos/o/r/issues/1": `{
+			"number": 1, "state": "open", "title": "Crash <on> start",
+			"html_url": "https://github.com/o/r/issues/1",
+			"user": {"login": "alice"},
+			"assignees": [{"login": "bob"}, {"login": "carol"}],
+			"labels": [{"name": "bug"}]
+		}`,
+		"GET /repos/o/r/issues/2": `{
+			"number": 2, "state": "closed", "title": "Fix crash",
+			"html_url": "https://github.com/o/r/pull/2",
+			"user": {"login": "bob"},
+			"pull_request": {"url": "https://api.github.com/repos/o/r/pulls/2"}
+		}`,
+		"GET /repos/o/r/pulls/2":            `{"number": 2, "merged": true, "head": {"sha": "abc"}}`,
+		"GET /repos/o/r/commits/abc/status": `{"state": "success"}`,
+	})
+	defer stop()
+	c := &commands{client}
+
+	tests := []struct {
+		text     string
+		expected string
+	}{
+		{
+			"gh show o/r#1",
+			"*<https://github.com/o/r/issues/1|o/r#1>* Crash &lt;on&gt; start\n" +
+				"Issue · open · opened by alice\n" +
+				"Assignees: bob, carol\n" +
+				"Labels: bug",
+		},
+		{
+			"gh show o/r#2",
+			"*<https://github.com/o/r/pull/2|o/r#2>* Fix crash\n" +
+				"Pull request · merged · opened by bob\n" +
+				"CI: success",
+		},
+	}
+	for _, test := range tests {
+		actual, err := c.show(context.Background(), nil, "U1", match(`^gh show `+referencePattern, test.text))
+		if err != nil {
+			t.Errorf("Error. Unexpected error %v.", err)
+		}
+		if actual != test.expected {
+			t.Errorf("Error. Expected %q, got %q.", test.expected, actual)
+		}
+	}
+
+	if _, err := c.show(context.Background(), nil, "U1", match(`^gh show `+referencePattern, "gh show o/r#3")); err == nil {
+		t.Error("Error. Expected an error for a missing issue.")
+	}
+}
+
+func TestList(t *testing.T) {
+	client, _, stop := testServer(t, map[string]string{
+		"GET /search/issues": `{"total_count": 12, "items": [
+			{"number": 1, "title": "Crash", "html_url": "https://github.com/o/r/issues/1"},
+			{"number": 4, "title": "Hang", "html_url": "https://github.com/o/r/issues/4"}
+		]}`,
+	})
+	defer stop()
+	c := &commands{client}
+
+	actual, err := c.list(context.Background(), nil, "U1", match(`^gh issues `+repoPattern+`\s*(.*)$`, "gh issues o/r label:bug"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "Here are 2 of the 12 matching open issues:\n" +
+		"• <https://github.com/o/r/issues/1|o/r#1> Crash\n" +
+		"• <https://github.com/o/r/issues/4|o/r#4> Hang"
+	if actual != expected {
+		t.Errorf("Error. Expected %q, got %q.", expected, actual)
+	}
+}
+
+func TestModify(t *testing.T) {
+	client, requests, stop := testServer(t, map[string]string{
+		"POST /repos/o/r/issues/1/comments":  `{"html_url": "https://github.com/o/r/issues/1#c"}`,
+		"PATCH /repos/o/r/issues/1":          `{"html_url": "https://github.com/o/r/issues/1"}`,
+		"POST /repos/o/r/issues/1/labels":    `[{"name": "bug"}]`,
+		"POST /repos/o/r/issues/1/assignees": `{}`,
+	})
+	defer stop()
+	bot := testBot()
+	handlers := Commands(bot, client)
+	if len(handlers) != 6 {
+		t.Fatalf("Error. Expected 6 handlers, got %d.", len(handlers))
+	}
+	c := &commands{client}
+
+	tests := []struct {
+		run      func(context.Context, *slack.Bot, string, []string) (string, error)
+		pattern  string
+		text     string
+		expected string
+		request  string
+		body     string
+	}{
+		{
+			c.comment, `(?s)^gh comment ` + referencePattern + `\s+(.+)$`,
+			"gh comment o/r#1 works for me &amp; you",
+			"I commented on <https://github.com/o/r/issues/1#c|o/r#1>.",
+			"POST /repos/o/r/issues/1/comments",
+			"works for me & you\n\n Comment posted via slack on behalf of alice",
+		},
+		{
+			c.setState, `^gh (close|reopen) ` + referencePattern,
+			"gh reopen o/r#1",
+			"I reopened <https://github.com/o/r/issues/1|o/r#1>.",
+			"PATCH /repos/o/r/issues/1",
+			"open",
+		},
+		{
+			c.label, `^gh label ` + referencePattern + `\s+(.+)$`,
+			"gh label o/r#1 bug, help wanted",
+			"I added bug, help wanted to o/r#1.",
+			"POST /repos/o/r/issues/1/labels",
+			`["bug","help wanted"]`,
+		},
+		{
+			c.assign, `^gh assign ` + referencePattern + `\s+(.+)$`,
+			"gh assign o/r#1 @bob carol",
+			"I assigned bob, carol to o/r#1.",
+			"POST /repos/o/r/issues/1/assignees",
+			`{"assignees":["bob","carol"]}`,
+		},
+	}
+	for _, test := range tests {
+		actual, err := test.run(context.Background(), bot, "U1", match(test.pattern, test.text))
+		if err != nil {
+			t.Errorf("Error. Unexpected error %v.", err)
+		}
+		if actual != test.expected {
+			t.Errorf("Error. Expected %q, got %q.", test.expected, actual)
+		}
+		body := requests[test.request]
+		var decoded map[string]interface{}
+		if json.Unmarshal([]byte(body), &decoded) == nil {
+			if v, ok := decoded["body"].(string); ok {
+				body = v
+			} else if v, ok := decoded["state"].(string); ok {
+				body = v
+			}
+		}
+		if strings.TrimSpace(body) != test.body {
+			t.Errorf("Error. Expected request body %q, got %q.", test.body, body)
+		}
+	}
+
+	// Comments need to know who they are on behalf of.
+	actual, _ := c.comment(context.Background(), bot, "U2", match(`(?s)^gh comment `+referencePattern+`\s+(.+)$`, "gh comment o/r#1 hi"))
+	if !strings.Contains(actual, "don't know who you are") {
+		t.Errorf("Error. Unexpected reply %q.", actual)
+	}
+}
+
+func TestSplitList(t *testing.T) {
+	actual := splitList(" bug,, help wanted ,", ",")
+	if len(actual) != 2 || actual[0] != "bug" || actual[1] != "help wanted" {
+		t.Errorf("Error. Unexpected result %q.", actual)
+	}
+}
+
+func TestCommand(t *testing.T) {
+	bot := testBot()
+	re := regexp.MustCompile(`^gh show ` + referencePattern)
+	calls := 0
+	action := command("show", re, func(_ context.Context, _ *slack.Bot, userID string, m []string) (string, error) {
+		calls++
+		if userID != "U1" || referenceFrom(m, 1).String() != "o/r#1" {
+			t.Errorf("Error. Unexpected arguments %s, %q.", userID, m)
+		}
+		return "done", nil
+	})
+
+	event := map[string]interface{}{"type": "message", "channel": "D1", "user": "U1", "text": "gh show o/r#1"}
+	if message, _ := action(bot, event); message == nil {
+		t.Error("Error. Expected a reply.")
+	}
+	event["text"] = "gh show o/r"
+	if message, _ := action(bot, event); message != nil {
+		t.Error("Error. Expected no reply for a command that does not match.")
+	}
+	if calls != 1 {
+		t.Errorf("Error. Expected 1 call, got %d.", calls)
+	}
+}
@@ -19,3 +19,11 @@ type issueError struct {
 func (err *issueError) Error() string {
 	return fmt.Sprintf("could not extract issue arguments from %s", err.text)
 }
+
+type configError struct {
+	message string
+}
+
+func (err *configError) Error() string {
+	return err.message
+}
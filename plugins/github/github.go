@@ -2,9 +2,12 @@
 Package github provides a plugin for building Github integrations into
 github.com/ajm188/slack.
 
-The package exports a number of package-wide variables, which may be used to
-configure the parameters used for authenticating with the Github API. See the
-documentation on each variable for what is is used for.
+The plugin is an instance of Plugin, constructed with New. Each instance has
+its own Github client, so several bots in one process can act as different
+Github users. The client is either given directly with WithClient, or built
+from Settings, which may be passed with WithSettings or to the plugin's Load
+function (which is how the github.com/ajm188/slack/config package configures
+plugins).
 
 Here is an example of how one might use this library to register a hook that
 opens Github issues:
@@ -16,9 +19,23 @@ opens Github issues:
 
     func main() {
         bot := slack.NewBot(myToken)
-        // configure auth for github plugin
-        github.OpenIssue(bot, nil)
+        plugin := github.New(github.WithSettings(&github.Settings{
+            AccessToken: myGithubToken,
+        }))
+        bot.UsePlugin(func() slack.Plugin { return plugin })
     }
+
+Besides opening issues, the plugin registers Commands for viewing, searching,
+commenting on, closing, reopening, labeling and assigning issues and pull
+requests, handlers which CaptureIssues from Slack messages and threads, and a
+handler which Unfurls references to issues and pull requests in messages.
+OpenIssue, Commands, CaptureIssues and Unfurl may also be used on their own,
+with any Github client.
+
+The plugin can also announce repository events, like pushes and pull requests,
+in Slack. If its WebhookSettings have an Addr, the plugin serves a Webhook
+there while the bot is running. Otherwise, the plugin's Webhook can be served by
+any HTTP server.
 */
 package github
 
@@ -26,8 +43,10 @@ package github
 // - testing
 
 import (
+	"context"
 	"fmt"
 	"regexp"
+	"strings"
 	"time"
 
 	"github.com/ajm188/slack"
@@ -36,67 +55,108 @@ import (
 	ghAuth "golang.org/x/oauth2/github" // have to rename so we don't have 2 "github"s
 )
 
-var (
-	// ClientID is issued by Github when you register an application. You
-	// should register an application for your bot, and set the ID before
-	// creating a client to authenticate with Github.
-	ClientID string
-	// ClientSecret is the secret key used to verify your registered
-	// application. You should set the secret before creating a client to
-	// authenticate with Github.
-	ClientSecret string
-	// AccessToken is an OAuth token for the user you want your Github
-	// interactions to be performed as. The bot will be commenting, opening
-	// issues, etc as the user who owns this token. You should set an access
-	// token before creating a client to authenticate with Github.
-	AccessToken string
-	// RedirectURL is the URL that Github should redirect to after a successful
-	// web authentication. Since the bot does not perform web-based
-	// authentication, this is likely a useless field. More information can be
-	// found at https://developer.github.com/v3/oauth/#redirect-urls.
-	RedirectURL string
-	// Scopes is the list of scopes that the OAuth token should be limited
-	// to. Since the user that created the token can specify the scopes
-	// available to the token when they create it, this field is probably also
-	// useless.
-	Scopes []string
-	// SharedClient is a variable for sharing a single OAuth client among
-	// various handlers. For example, when a call to OpenIssue is made, you may
-	// pass in a client of your own, if you want the issue hook to be handled
-	// by a different Github user. If you do not pass in a client, then the
-	// various hook methods will fall back to using this shared client.
-	SharedClient *github.Client
-)
+// Settings are the parameters used for authenticating with the Github API. They
+// can be decoded from the "github" section of a configuration file by the
+// github.com/ajm188/slack/config package.
+//
+// ClientID is issued by Github when you register an application, and
+// ClientSecret is the secret key used to verify your registered application.
+//
+// AccessToken is an OAuth token for the user you want your Github interactions
+// to be performed as. The bot will be commenting, opening issues, etc as the
+// user who owns this token.
+//
+// RedirectURL is the URL that Github should redirect to after a successful web
+// authentication. Since the bot does not perform web-based authentication,
+// this is likely a useless field. More information can be found at
+// https://developer.github.com/v3/oauth/#redirect-urls.
+//
+// Scopes is the list of scopes that the OAuth token should be limited to.
+// Since the user that created the token can specify the scopes available to
+// the token when they create it, this field is probably also useless.
+//
+// DefaultRepos maps channel names or IDs to the "<owner>/<repo>" that bare
+// issue references (like "#123") in that channel refer to. See Unfurl.
+//
+// IssueEmoji is the reaction which opens an issue from a message. It defaults
+// to DefaultIssueEmoji. See CaptureIssues.
+//
+// Logins maps Slack user IDs or nicks to Github logins, so that Slack users
+// can be assigned issues. See OpenIssue.
+//
+// Webhook configures the server that receives Github webhook deliveries.
+type Settings struct {
+	ClientID     string            `config:"client_id"`
+	ClientSecret string            `config:"client_secret"`
+	AccessToken  string            `config:"access_token,required"`
+	RedirectURL  string            `config:"redirect_url"`
+	Scopes       []string          `config:"scopes"`
+	DefaultRepos map[string]string `config:"default_repos"`
+	IssueEmoji   string            `config:"issue_emoji"`
+	Logins       map[string]string `config:"logins"`
+	Webhook      WebhookSettings   `config:"webhook"`
+}
 
-// DefaultClient constructs a Github client based on the variables set in this
-// package (ClientID, ClientSecret, AccessToken). This can be used to quickly
-// create a client when you don't need any customization to the underlying
-// oauth client. It uses the NoContext context from the oauth2 package. See the
-// Token function for the Token it will use.
-func DefaultClient() *github.Client {
-	return github.NewClient(Config().Client(oauth2.NoContext, Token()))
+// WebhookSettings configure the plugin's Webhook. If Addr is set, the plugin
+// serves the webhook at Path (DefaultWebhookPath if it is empty) on Addr while
+// the bot is running. Secret is the secret configured for the webhook on
+// Github, and Routes determine which channels events are announced in.
+type WebhookSettings struct {
+	Addr   string  `config:"addr"`
+	Path   string  `config:"path"`
+	Secret string  `config:"secret"`
+	Routes []Route `config:"routes"`
 }
 
-// Config returns an oauth config object that can be used to generate a client
-// for communicating with Github.
-func Config() *oauth2.Config {
+// Validate returns an error if the settings are missing an access token, or if
+// any of the default repositories are not of the form "<owner>/<repo>".
+func (settings *Settings) Validate() error {
+	if settings.AccessToken == "" {
+		return &configError{"access_token is required"}
+	}
+	for channel, repo := range settings.DefaultRepos {
+		if parts := strings.Split(repo, "/"); len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return &configError{fmt.Sprintf("default_repos.%s: %q is not of the form owner/repo", channel, repo)}
+		}
+	}
+	if settings.Webhook.Addr != "" && settings.Webhook.Secret == "" {
+		return &configError{"webhook.secret is required to serve the webhook"}
+	}
+	for i, route := range settings.Webhook.Routes {
+		if route.Channel == "" {
+			return &configError{fmt.Sprintf("webhook.routes[%d].channel is required", i)}
+		}
+	}
+	return nil
+}
+
+// Client constructs a Github client from the settings. This can be used to
+// quickly create a client when you don't need any customization to the
+// underlying oauth client. It uses the NoContext context from the oauth2
+// package. See Token for the Token it will use.
+func (settings *Settings) Client() *github.Client {
+	return github.NewClient(settings.OAuthConfig().Client(oauth2.NoContext, settings.Token()))
+}
+
+// OAuthConfig returns an oauth config object that can be used to generate a
+// client for communicating with Github.
+func (settings *Settings) OAuthConfig() *oauth2.Config {
 	return &oauth2.Config{
-		ClientID:     ClientID,
-		ClientSecret: ClientSecret,
+		ClientID:     settings.ClientID,
+		ClientSecret: settings.ClientSecret,
 		Endpoint:     ghAuth.Endpoint,
-		RedirectURL:  RedirectURL,
-		Scopes:       Scopes,
+		RedirectURL:  settings.RedirectURL,
+		Scopes:       settings.Scopes,
 	}
 }
 
 // Token constructs a basic token, with the bare minimum amount of information
-// necessary to authenticate with Github. It uses the package-wide AccessToken,
-// and sets the token to never expire. "TokenType" and "RefreshToken" fields
-// are left blank.
-func Token() *oauth2.Token {
+// necessary to authenticate with Github. It uses the AccessToken, and sets the
+// token to never expire. "TokenType" and "RefreshToken" fields are left blank.
+func (settings *Settings) Token() *oauth2.Token {
 	var noExpire time.Time // this sets noExpire to the zero Time value
 	return &oauth2.Token{
-		AccessToken:  AccessToken,
+		AccessToken:  settings.AccessToken,
 		TokenType:    "", // uhhh
 		RefreshToken: "",
 		Expiry:       noExpire,
@@ -108,71 +168,115 @@ func Token() *oauth2.Token {
 //
 // The handler is registered as a "Respond", not a "Listen" (see the docs for
 // github.com/ajm188/slack for the difference). The pattern which will cause
-// the handler to fire has the form 'issue me // <owner>/<repo> "<title>"
-// ("<body>" ("<assignee>")?)?'.
+// the handler to fire has the form 'issue me <owner>/<repo> "<title>"
+// ("<body>" ("<assignee>")?)? (<option>:<value>)*'. The options are:
+//
+//	labels:<label>,...        labels to add to the issue
+//	assignees:<assignee>,...  users to assign the issue to
+//	milestone:<milestone>     the title or number of an open milestone
+//	template:<name>           an issue template from .github/ISSUE_TEMPLATE
+//
+// An unknown option is answered with the option and the usage of the command.
+// URLs are not options, so they need not be quoted.
+//
+// Values containing spaces may be quoted, as in milestone:"Version 2". An
+// assignee may be a Github login, "me", or a Slack user (like "@alice"), and
+// Slack users are mapped to their Github logins with logins, which is keyed by
+// Slack user ID or nick. A template supplies the skeleton of the issue's body
+// (which follows any body given in the command), and any labels, assignees or
+// title prefix given in its front matter.
 //
 // The function takes as arguments the bot to which it should register the
-// handler, and a reference to a client that can authenticate with Github. If
-// no client is provided, then OpenIssue will fall back to using the
-// package-wide SharedClient.
+// handler, a reference to a client that can authenticate with Github, and the
+// map of Slack users to Github logins, which may be nil. It returns the
+// registered handler.
 //
-// Users should note that an attempt to assign an issue to a Github user that
-// is not a "contributor" on the repository will result in a 422 returned by
-// the Github API. This will prevent the issue from being created.
+// Before the issue is opened, the handler checks that the repository, labels,
+// milestone and template exist and that the assignees can be assigned issues
+// in the repository, and replies with the problems it finds instead of
+// opening the issue.
 //
 // When an issue has successfully been created, the bot will reply to the user
 // which triggered the handler with a link to the issue.
-func OpenIssue(bot *slack.Bot, client *github.Client) {
-	repoRe := regexp.MustCompile("issue me ([^/ ]+)/([^/ ]+)")
-	argsRe := regexp.MustCompile("(\".*?[^\\\\]\")")
-	if client == nil {
-		client = SharedClient
-	}
-	issues := client.Issues
+func OpenIssue(bot *slack.Bot, client *github.Client, logins map[string]string) *slack.Handler {
+	opener := &issueOpener{client: client, logins: logins}
 
 	handler := func(b *slack.Bot, event map[string]interface{}) (*slack.Message, slack.Status) {
 		text := event["text"].(string)
-		owner, repo, err := extractOwnerAndRepo(text, repoRe)
+		owner, repo, err := extractOwnerAndRepo(text, openIssueRe)
 		if err != nil {
 			return nil, slack.Continue
 		}
-		issueRequest, err := extractIssueArgs(text, argsRe)
+		userID := event["user"].(string)
+		channel := event["channel"].(string)
+		args, err := extractIssueArgs(text[openIssueRe.FindStringIndex(text)[1]:])
 		if err != nil {
+			if option, ok := err.(*optionError); ok {
+				return b.Mention(userID, option.reply(), channel), slack.Continue
+			}
 			return nil, slack.Continue
 		}
-		userID := event["user"].(string)
-		user, ok := b.Users[userID]
+		name, ok := displayName(b, userID)
 		if !ok {
 			return nil, slack.Continue
 		}
-		fullName := user.FullName()
-		name := user.Nick
-		if fullName != "" {
-			name += fmt.Sprintf(" (%s)", fullName)
+
+		ctx := b.Context(event)
+		var issueRequest *github.IssueRequest
+		err = traced(ctx, b, "github.issues.validate", func(ctx context.Context) (err error) {
+			issueRequest, err = opener.request(ctx, b, userID, owner, repo, args)
+			return
+		})
+		if err == nil {
+			issueCreationMessage := fmt.Sprintf(
+				"\n\n Issue created via slack on behalf of %s",
+				name,
+			)
+			issueBody := (*issueRequest.Body) + issueCreationMessage
+			issueRequest.Body = &issueBody
+			var issue *github.Issue
+			err = traced(ctx, b, "github.issues.create", func(ctx context.Context) (err error) {
+				issue, _, err = opener.client.Issues.Create(ctx, owner, repo, issueRequest)
+				return
+			})
+			if err == nil {
+				message := fmt.Sprintf(
+					"I created that issue for you. You can view it here: %s",
+					*issue.HTMLURL,
+				)
+				return b.Mention(userID, message, channel), slack.Continue
+			}
 		}
-		issueCreationMessage := fmt.Sprintf(
-			"\n\n Issue created via slack on behalf of %s",
-			name,
-		)
-		issueBody := (*issueRequest.Body) + issueCreationMessage
-		issueRequest.Body = &issueBody
-		issue, _, err := issues.Create(owner, repo, issueRequest)
-		channel := event["channel"].(string)
-		if err != nil {
-			message := fmt.Sprintf(
+
+		var message string
+		if problems, ok := err.(*issueProblems); ok {
+			message = problems.reply()
+		} else {
+			message = fmt.Sprintf(
 				"I had some trouble opening an issue. Here was the error I got:\n%v",
 				err)
-			return bot.Mention(userID, message, channel), slack.Continue
 		}
-
-		message := fmt.Sprintf(
-			"I created that issue for you. You can view it here: %s",
-			*issue.HTMLURL,
-		)
-		return bot.Mention(userID, message, channel), slack.Continue
+		return b.Mention(userID, message, channel), slack.Continue
 	}
 
-	bot.RespondRegexp(repoRe, handler)
+	return bot.RespondRegexp(openIssueRe, handler)
+}
+
+var openIssueRe = regexp.MustCompile("issue me ([^/ ]+)/([^/ ]+)")
+
+// displayName returns the nick of the Slack user with the given ID, followed
+// by their full name if they have one. It returns false if the user is not
+// known to the bot.
+func displayName(bot *slack.Bot, userID string) (string, bool) {
+	user, ok := bot.Users[userID]
+	if !ok {
+		return "", false
+	}
+	name := user.Nick
+	if fullName := user.FullName(); fullName != "" {
+		name += fmt.Sprintf(" (%s)", fullName)
+	}
+	return name, true
 }
 
 func extractOwnerAndRepo(text string, re *regexp.Regexp) (string, string, error) {
@@ -182,36 +286,3 @@ func extractOwnerAndRepo(text string, re *regexp.Regexp) (string, string, error)
 	}
 	return m[1], m[2], nil
 }
-
-func removeQuotes(s string) string {
-	return s[1 : len(s)-1]
-}
-
-func extractIssueArgs(text string, re *regexp.Regexp) (*github.IssueRequest, error) {
-	match := re.FindAllString(text, -1)
-	m := make([]string, len(match))
-	for i, v := range match {
-		m[i] = removeQuotes(v)
-	}
-	if m == nil || len(m) == 0 {
-		return nil, &issueError{text}
-	}
-	var title, body, assignee *string
-	title = &m[0]
-	if len(m) >= 2 {
-		body = &m[1]
-	}
-	if len(m) >= 3 {
-		assignee = &m[2]
-	}
-	issueState := "open"
-	request := github.IssueRequest{
-		Title:     title,
-		Body:      body,
-		Labels:    nil,
-		Assignee:  assignee,
-		State:     &issueState,
-		Milestone: nil,
-	}
-	return &request, nil
-}
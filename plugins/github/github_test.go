@@ -5,8 +5,41 @@ import (
 )
 
 func TestToken(t *testing.T) {
-	token := Token()
+	token := (&Settings{AccessToken: "token"}).Token()
 	if !token.Expiry.IsZero() {
 		t.Error("Error. Expected token expiration to be zero.")
 	}
+	if token.AccessToken != "token" {
+		t.Error("Error. Expected the token to use the access token from the settings.")
+	}
+}
+
+func TestSettings(t *testing.T) {
+	settings := &Settings{}
+	if settings.Validate() == nil {
+		t.Error("Error. Expected settings without an access token to be invalid.")
+	}
+
+	settings = &Settings{AccessToken: "token", Scopes: []string{"repo"}}
+	if err := settings.Validate(); err != nil {
+		t.Errorf("Error. Unexpected error %v.", err)
+	}
+	if config := settings.OAuthConfig(); len(config.Scopes) != 1 {
+		t.Error("Error. Expected the oauth config to use the scopes from the settings.")
+	}
+
+	settings.DefaultRepos = map[string]string{"dev": "owner"}
+	if settings.Validate() == nil {
+		t.Error("Error. Expected a default repo without an owner to be invalid.")
+	}
+
+	settings = &Settings{AccessToken: "token", Webhook: WebhookSettings{Addr: ":8080"}}
+	if settings.Validate() == nil {
+		t.Error("Error. Expected a webhook without a secret to be invalid.")
+	}
+	settings.Webhook.Secret = "secret"
+	settings.Webhook.Routes = []Route{{Repos: []string{"o/r"}}}
+	if settings.Validate() == nil {
+		t.Error("Error. Expected a route without a channel to be invalid.")
+	}
 }
@@ -0,0 +1,358 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/ajm188/slack"
+	"github.com/ajm188/slack/format"
+	"github.com/google/go-github/github"
+	"gopkg.in/yaml.v2"
+)
+
+// templateDir is where Github keeps a repository's issue templates.
+const templateDir = ".github/ISSUE_TEMPLATE"
+
+var (
+	// issueArgRe matches either an option (group 1 is the key, group 2 the
+	// possibly quoted value) or a quoted positional argument (group 3).
+	issueArgRe = regexp.MustCompile(`(\w+):("(?:[^"\\]|\\.)*"|[^\s"]+)|"((?:[^"\\]|\\.)*)"`)
+	mentionRe  = regexp.MustCompile(`^<@([A-Z0-9]+)(?:\|[^>]*)?>$`)
+)
+
+// issueArgs are the arguments of an "issue me" command.
+type issueArgs struct {
+	title     string
+	body      string
+	labels    []string
+	assignees []string
+	milestone string
+	template  string
+}
+
+// issueUsage describes the arguments of an "issue me" command.
+const issueUsage = `Usage: issue me <owner>/<repo> "<title>" ["<body>" ["<assignee>"]] ` +
+	`[labels:<label>,...] [assignees:<assignee>,...] [milestone:<milestone>] [template:<name>]`
+
+// extractIssueArgs parses the arguments which follow the repository in an
+// "issue me" command. The first quoted argument is the title, the second is
+// the body, and the third is an assignee. Unquoted URLs are skipped rather
+// than read as options, and an unknown option gives an *optionError.
+func extractIssueArgs(text string) (*issueArgs, error) {
+	args := &issueArgs{}
+	var positional []string
+	for _, m := range issueArgRe.FindAllStringSubmatch(text, -1) {
+		if m[1] == "" {
+			positional = append(positional, unquote(m[3]))
+			continue
+		}
+		if strings.HasPrefix(m[2], "//") {
+			continue
+		}
+		value := m[2]
+		if strings.HasPrefix(value, `"`) {
+			value = unquote(value[1 : len(value)-1])
+		}
+		switch strings.ToLower(m[1]) {
+		case "label", "labels":
+			args.labels = append(args.labels, splitList(value, ",")...)
+		case "assignee", "assignees":
+			args.assignees = append(args.assignees, splitList(value, ", ")...)
+		case "milestone":
+			args.milestone = value
+		case "template":
+			args.template = value
+		default:
+			return nil, &optionError{m[1]}
+		}
+	}
+	if len(positional) == 0 {
+		return nil, &issueError{text}
+	}
+	args.title = positional[0]
+	if len(positional) >= 2 {
+		args.body = positional[1]
+	}
+	if len(positional) >= 3 {
+		args.assignees = append([]string{positional[2]}, args.assignees...)
+	}
+	return args, nil
+}
+
+// unquote undoes Slack's escaping and the escaping of quotes inside a quoted
+// argument.
+func unquote(s string) string {
+	return strings.Replace(format.Unescape(s), `\"`, `"`, -1)
+}
+
+// issueProblems collects the reasons an issue cannot be opened.
+type issueProblems struct {
+	problems []string
+}
+
+func (p *issueProblems) add(format string, args ...interface{}) {
+	p.problems = append(p.problems, fmt.Sprintf(format, args...))
+}
+
+func (p *issueProblems) Error() string {
+	return strings.Join(p.problems, "; ")
+}
+
+func (p *issueProblems) reply() string {
+	if len(p.problems) == 1 {
+		return "I couldn't open that issue: " + p.problems[0]
+	}
+	return "I couldn't open that issue:\n• " + strings.Join(p.problems, "\n• ")
+}
+
+// optionError is an option in an "issue me" command which isn't known.
+type optionError struct {
+	option string
+}
+
+func (err *optionError) Error() string {
+	return fmt.Sprintf("unknown issue option %s", err.option)
+}
+
+func (err *optionError) reply() string {
+	return fmt.Sprintf("I don't know the option %q.\n%s", err.option, issueUsage)
+}
+
+type issueOpener struct {
+	client *github.Client
+	logins map[string]string
+}
+
+// request validates args against the repository, and builds the request to
+// open the issue. If args are invalid, the error is an *issueProblems.
+func (o *issueOpener) request(ctx context.Context, b *slack.Bot, userID, owner, repo string, args *issueArgs) (*github.IssueRequest, error) {
+	problems := &issueProblems{}
+	if _, _, err := o.client.Repositories.Get(ctx, owner, repo); err != nil {
+		if notFound(err) {
+			problems.add("I couldn't find the repository %s/%s.", owner, repo)
+			return nil, problems
+		}
+		return nil, err
+	}
+
+	title, body := args.title, args.body
+	labels := args.labels
+	var assignees []string
+	if args.template != "" {
+		template, err := o.template(ctx, owner, repo, args.template)
+		if err != nil {
+			if !notFound(err) {
+				return nil, err
+			}
+			problems.add("%s/%s has no issue template named %s.", owner, repo, format.Escape(args.template))
+		} else {
+			if template.Title != "" && !strings.HasPrefix(title, template.Title) {
+				title = template.Title + title
+			}
+			if body != "" && template.Body != "" {
+				body += "\n\n"
+			}
+			body += template.Body
+			labels = append(labels, template.Labels...)
+			assignees = append(assignees, template.Assignees...)
+		}
+	}
+
+	for _, assignee := range args.assignees {
+		login, err := o.login(b, userID, assignee)
+		if err != nil {
+			problems.add("%s", err.Error())
+			continue
+		}
+		assignees = append(assignees, login)
+	}
+	labels, assignees = distinct(labels), distinct(assignees)
+
+	for _, label := range labels {
+		if _, _, err := o.client.Issues.GetLabel(ctx, owner, repo, label); err != nil {
+			if !notFound(err) {
+				return nil, err
+			}
+			problems.add("%s/%s has no label named %s.", owner, repo, format.Escape(label))
+		}
+	}
+	for _, login := range assignees {
+		ok, _, err := o.client.Issues.IsAssignee(ctx, owner, repo, login)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			problems.add("%s can't be assigned issues in %s/%s.", format.Escape(login), owner, repo)
+		}
+	}
+	var milestone *int
+	if args.milestone != "" {
+		number, err := o.milestone(ctx, owner, repo, args.milestone)
+		if err != nil {
+			return nil, err
+		}
+		if number == 0 {
+			problems.add("%s/%s has no open milestone named %s.", owner, repo, format.Escape(args.milestone))
+		} else {
+			milestone = &number
+		}
+	}
+	if len(problems.problems) > 0 {
+		return nil, problems
+	}
+
+	state := "open"
+	request := &github.IssueRequest{
+		Title:     &title,
+		Body:      &body,
+		State:     &state,
+		Milestone: milestone,
+	}
+	if len(labels) > 0 {
+		request.Labels = &labels
+	}
+	if len(assignees) > 0 {
+		request.Assignees = &assignees
+	}
+	return request, nil
+}
+
+// login returns the Github login for assignee, which is either a Github login,
+// "me" (the user with the ID userID), or a Slack user, given as a mention or
+// an "@" followed by their nick.
+func (o *issueOpener) login(b *slack.Bot, userID, assignee string) (string, error) {
+	slackUser := ""
+	switch {
+	case assignee == "me":
+		slackUser = userID
+	case mentionRe.MatchString(assignee):
+		slackUser = mentionRe.FindStringSubmatch(assignee)[1]
+	case strings.HasPrefix(assignee, "@"):
+		nick := strings.TrimPrefix(assignee, "@")
+		if _, ok := b.Users[nick]; !ok {
+			// Not a Slack user, so it must be a Github login.
+			return nick, nil
+		}
+		slackUser = nick
+	default:
+		return assignee, nil
+	}
+
+	if login, ok := o.logins[slackUser]; ok {
+		return login, nil
+	}
+	if user, ok := b.Users[slackUser]; ok {
+		if login, ok := o.logins[user.ID]; ok {
+			return login, nil
+		}
+		if login, ok := o.logins[user.Nick]; ok {
+			return login, nil
+		}
+		slackUser = user.ID
+	}
+	return "", &configError{fmt.Sprintf("I don't know the Github login of %s.", format.User(slackUser))}
+}
+
+// milestone returns the number of the open milestone with the given title or
+// number, or 0 if there is no such milestone.
+func (o *issueOpener) milestone(ctx context.Context, owner, repo, name string) (int, error) {
+	opts := &github.MilestoneListOptions{
+		State:       "open",
+		ListOptions: github.ListOptions{PerPage: 100},
+	}
+	milestones, _, err := o.client.Issues.ListMilestones(ctx, owner, repo, opts)
+	if err != nil {
+		return 0, err
+	}
+	for _, milestone := range milestones {
+		number := intValue(milestone.Number)
+		if strings.EqualFold(stringValue(milestone.Title), name) || strconv.Itoa(number) == name {
+			return number, nil
+		}
+	}
+	return 0, nil
+}
+
+// issueTemplate is a Github issue template. Templates are Markdown files whose
+// front matter may give a title prefix, labels and assignees.
+type issueTemplate struct {
+	Title     string      `yaml:"title"`
+	Labels    stringOrSeq `yaml:"labels"`
+	Assignees stringOrSeq `yaml:"assignees"`
+	Body      string      `yaml:"-"`
+}
+
+// stringOrSeq is a list in front matter, which may be written either as a YAML
+// sequence or as a comma-separated string.
+type stringOrSeq []string
+
+func (s *stringOrSeq) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var seq []string
+	if err := unmarshal(&seq); err == nil {
+		*s = seq
+		return nil
+	}
+	var str string
+	if err := unmarshal(&str); err != nil {
+		return err
+	}
+	*s = splitList(str, ",")
+	return nil
+}
+
+// template fetches and parses the issue template with the given name.
+func (o *issueOpener) template(ctx context.Context, owner, repo, name string) (*issueTemplate, error) {
+	if path.Ext(name) == "" {
+		name += ".md"
+	}
+	file, _, _, err := o.client.Repositories.GetContents(ctx, owner, repo, path.Join(templateDir, name), nil)
+	if err != nil {
+		return nil, err
+	}
+	if file == nil {
+		return nil, &configError{fmt.Sprintf("%s is not a file", name)}
+	}
+	content, err := file.GetContent()
+	if err != nil {
+		return nil, err
+	}
+	return parseTemplate(content)
+}
+
+func parseTemplate(content string) (*issueTemplate, error) {
+	template := &issueTemplate{}
+	content = strings.Replace(content, "\r\n", "\n", -1)
+	if strings.HasPrefix(content, "---\n") {
+		end := strings.Index(content[4:], "\n---")
+		if end >= 0 {
+			if err := yaml.Unmarshal([]byte(content[4:4+end]), template); err != nil {
+				return nil, err
+			}
+			content = strings.TrimPrefix(content[4+end+4:], "\n")
+		}
+	}
+	template.Body = strings.TrimSpace(content)
+	return template, nil
+}
+
+func notFound(err error) bool {
+	errResponse, ok := err.(*github.ErrorResponse)
+	return ok && errResponse.Response != nil && errResponse.Response.StatusCode == http.StatusNotFound
+}
+
+func distinct(values []string) []string {
+	var result []string
+	seen := make(map[string]bool)
+	for _, value := range values {
+		if !seen[value] {
+			seen[value] = true
+			result = append(result, value)
+		}
+	}
+	return result
+}
@@ -0,0 +1,207 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExtractIssueArgs(t *testing.T) {
+	tests := []struct {
+		text     string
+		expected *issueArgs
+	}{
+		{
+			` "Crash"`,
+			&issueArgs{title: "Crash"},
+		},
+		{
+			` "Crash on \"start\"" "It &amp; the app crash" "bob"`,
+			&issueArgs{title: `Crash on "start"`, body: "It & the app crash", assignees: []string{"bob"}},
+		},
+		{
+			` "Crash" labels:bug,ui assignees:me,@carol milestone:"Version 2" template:bug`,
+			&issueArgs{
+				title:     "Crash",
+				labels:    []string{"bug", "ui"},
+				assignees: []string{"me", "@carol"},
+				milestone: "Version 2",
+				template:  "bug",
+			},
+		},
+		{
+			` "Crash" "" "bob" label:bug label:"needs triage" assignee:carol`,
+			&issueArgs{
+				title:     "Crash",
+				labels:    []string{"bug", "needs triage"},
+				assignees: []string{"bob", "carol"},
+			},
+		},
+		{
+			` "Crash" https://example.com/crash labels:bug`,
+			&issueArgs{title: "Crash", labels: []string{"bug"}},
+		},
+		{` labels:bug`, nil},
+		{` "Crash" priority:high`, nil},
+		{``, nil},
+	}
+
+	for _, test := range tests {
+		actual, err := extractIssueArgs(test.text)
+		if test.expected == nil {
+			if err == nil {
+				t.Errorf("Error. Expected %q to be rejected, got %+v.", test.text, actual)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Error. Unexpected error extracting %q: %v.", test.text, err)
+			continue
+		}
+		if !reflect.DeepEqual(actual, test.expected) {
+			t.Errorf("Error. Expected %q to give %+v, got %+v.", test.text, test.expected, actual)
+		}
+	}
+}
+
+func TestExtractIssueArgsUnknownOption(t *testing.T) {
+	_, err := extractIssueArgs(` "Crash" priority:high`)
+	option, ok := err.(*optionError)
+	if !ok || option.option != "priority" {
+		t.Fatalf("Error. Expected an *optionError for priority, got %v.", err)
+	}
+	reply := option.reply()
+	if !strings.Contains(reply, `"priority"`) || !strings.Contains(reply, issueUsage) {
+		t.Errorf("Error. Expected the reply to name the option and give the usage, got %q.", reply)
+	}
+}
+
+func TestIssueLogin(t *testing.T) {
+	bot := testBot()
+	opener := &issueOpener{logins: map[string]string{"U1": "alice-gh"}}
+
+	tests := []struct {
+		assignee string
+		expected string
+		ok       bool
+	}{
+		{"me", "alice-gh", true},
+		{"@alice", "alice-gh", true},
+		{"<@U1>", "alice-gh", true},
+		{"<@U1|alice>", "alice-gh", true},
+		{"bob", "bob", true},
+		{"@bob", "bob", true},
+		{"<@U2>", "", false},
+	}
+	for _, test := range tests {
+		actual, err := opener.login(bot, "U1", test.assignee)
+		if (err == nil) != test.ok || actual != test.expected {
+			t.Errorf("Error. Expected %s to map to %q (ok=%v), got %q (%v).", test.assignee, test.expected, test.ok, actual, err)
+		}
+	}
+
+	opener.logins = map[string]string{"alice": "alice-by-nick"}
+	if actual, err := opener.login(bot, "U1", "me"); err != nil || actual != "alice-by-nick" {
+		t.Errorf("Error. Expected logins keyed by nick to be used, got %q (%v).", actual, err)
+	}
+	opener.logins = nil
+	if _, err := opener.login(bot, "U1", "me"); err == nil {
+		t.Errorf("Error. Expected an error for a Slack user without a Github login.")
+	}
+}
+
+func TestParseTemplate(t *testing.T) {
+	template, err := parseTemplate("---\nname: Bug\ntitle: \"[Bug] \"\nlabels: bug, triage\nassignees:\n  - bob\n---\n\n## Steps\n")
+	if err != nil {
+		t.Fatalf("Error. Unexpected error parsing template: %v.", err)
+	}
+	expected := &issueTemplate{
+		Title:     "[Bug] ",
+		Labels:    stringOrSeq{"bug", "triage"},
+		Assignees: stringOrSeq{"bob"},
+		Body:      "## Steps",
+	}
+	if !reflect.DeepEqual(template, expected) {
+		t.Errorf("Error. Expected %+v, got %+v.", expected, template)
+	}
+
+	template, err = parseTemplate("Just a body.")
+	if err != nil || template.Body != "Just a body." || template.Title != "" {
+		t.Errorf("Error. Expected a template without front matter to be all body, got %+v (%v).", template, err)
+	}
+}
+
+func TestIssueRequest(t *testing.T) {
+	template, _ := json.Marshal(map[string]string{
+		"type":    "file",
+		"content": "---\ntitle: \"[Bug] \"\nlabels: [bug]\n---\n## Steps",
+	})
+	client, _, stop := testServer(t, map[string]string{
+		"GET /repos/o/r":                                        `{"full_name": "o/r"}`,
+		"GET /repos/o/r/labels/bug":                             `{"name": "bug"}`,
+		"GET /repos/o/r/labels/ui":                              `{"name": "ui"}`,
+		"GET /repos/o/r/milestones":                             `[{"number": 3, "title": "Version 2"}]`,
+		"GET /repos/o/r/assignees/alice-gh":                     ``,
+		"GET /repos/o/r/assignees/bob":                          ``,
+		"GET /repos/o/r/contents/.github/ISSUE_TEMPLATE/bug.md": string(template),
+	})
+	defer stop()
+	bot := testBot()
+	opener := &issueOpener{client: client, logins: map[string]string{"alice": "alice-gh"}}
+
+	args := &issueArgs{
+		title:     "Crash",
+		body:      "It crashed.",
+		labels:    []string{"ui", "bug"},
+		assignees: []string{"me", "bob"},
+		milestone: "version 2",
+		template:  "bug",
+	}
+	request, err := opener.request(context.Background(), bot, "U1", "o", "r", args)
+	if err != nil {
+		t.Fatalf("Error. Unexpected error: %v.", err)
+	}
+	if *request.Title != "[Bug] Crash" {
+		t.Errorf("Error. Expected the template's title prefix, got %q.", *request.Title)
+	}
+	if *request.Body != "It crashed.\n\n## Steps" {
+		t.Errorf("Error. Expected the template to follow the body, got %q.", *request.Body)
+	}
+	if !reflect.DeepEqual(*request.Labels, []string{"ui", "bug"}) {
+		t.Errorf("Error. Expected labels [ui bug], got %v.", *request.Labels)
+	}
+	if !reflect.DeepEqual(*request.Assignees, []string{"alice-gh", "bob"}) {
+		t.Errorf("Error. Expected assignees [alice-gh bob], got %v.", *request.Assignees)
+	}
+	if request.Milestone == nil || *request.Milestone != 3 {
+		t.Errorf("Error. Expected milestone 3, got %v.", request.Milestone)
+	}
+
+	args = &issueArgs{
+		title:     "Crash",
+		labels:    []string{"wontfix"},
+		assignees: []string{"carol", "<@U2>"},
+		milestone: "Version 3",
+		template:  "feature",
+	}
+	_, err = opener.request(context.Background(), bot, "U1", "o", "r", args)
+	problems, ok := err.(*issueProblems)
+	if !ok {
+		t.Fatalf("Error. Expected *issueProblems, got %v.", err)
+	}
+	if len(problems.problems) != 5 {
+		t.Errorf("Error. Expected 5 problems, got %q.", problems.problems)
+	}
+	for _, expected := range []string{"template named feature", "label named wontfix", "carol can't be assigned", "<@U2>", "milestone named Version 3"} {
+		if !strings.Contains(problems.reply(), expected) {
+			t.Errorf("Error. Expected the reply to mention %q, got %q.", expected, problems.reply())
+		}
+	}
+
+	_, err = opener.request(context.Background(), bot, "U1", "o", "missing", &issueArgs{title: "Crash"})
+	if problems, ok := err.(*issueProblems); !ok || problems.reply() != "I couldn't open that issue: I couldn't find the repository o/missing." {
+		t.Errorf("Error. Expected a missing repository to be reported, got %v.", err)
+	}
+}
@@ -0,0 +1,158 @@
+package github
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/ajm188/slack"
+	"github.com/ajm188/slack/brain"
+	"github.com/google/go-github/github"
+)
+
+// DefaultName is the name of a Plugin, unless it is given another one with
+// WithName.
+const DefaultName = "github"
+
+// shutdownTimeout is how long the webhook server waits for deliveries in
+// progress when the bot shuts down.
+const shutdownTimeout = 5 * time.Second
+
+// Plugin is a slack.Plugin which integrates a bot with Github. Construct one
+// with New.
+type Plugin struct {
+	name     string
+	client   *github.Client
+	settings *Settings
+	webhook  *Webhook
+	server   *http.Server
+}
+
+// Option configures a Plugin.
+type Option func(*Plugin)
+
+// New constructs a Plugin, configured by options.
+func New(options ...Option) *Plugin {
+	p := &Plugin{name: DefaultName}
+	for _, option := range options {
+		option(p)
+	}
+	return p
+}
+
+// WithName sets the name of the plugin. Since a bot cannot load two plugins
+// with the same name, this must be used to load more than one Plugin into the
+// same bot.
+func WithName(name string) Option {
+	return func(p *Plugin) {
+		p.name = name
+	}
+}
+
+// WithClient sets the client the plugin uses to talk to Github. It takes
+// precedence over any Settings.
+func WithClient(client *github.Client) Option {
+	return func(p *Plugin) {
+		p.client = client
+	}
+}
+
+// WithSettings sets the Settings the plugin uses to construct its client.
+func WithSettings(settings *Settings) Option {
+	return func(p *Plugin) {
+		p.settings = settings
+	}
+}
+
+// Name returns the name of the plugin.
+func (p *Plugin) Name() string {
+	return p.name
+}
+
+// CanLoad returns true. Whether the plugin has a client is checked by Load,
+// since Settings may be passed as one of its arguments.
+func (p *Plugin) CanLoad() bool {
+	return true
+}
+
+// Load registers the plugin's handlers (OpenIssue, Commands, CaptureIssues and
+// Unfurl) with bot. Any *Settings in args replace the plugin's Settings. It returns an error
+// if the plugin has neither a client nor valid Settings.
+func (p *Plugin) Load(bot *slack.Bot, args ...interface{}) error {
+	for _, arg := range args {
+		if settings, ok := arg.(*Settings); ok {
+			p.settings = settings
+		}
+	}
+	if p.client == nil {
+		if p.settings == nil {
+			return &configError{"no client or settings given"}
+		}
+		if err := p.settings.Validate(); err != nil {
+			return err
+		}
+		p.client = p.settings.Client()
+	}
+	settings := p.settings
+	if settings == nil {
+		settings = &Settings{}
+	}
+	handlers := []*slack.Handler{OpenIssue(bot, p.client, settings.Logins)}
+	handlers = append(handlers, Commands(bot, p.client)...)
+	handlers = append(handlers, CaptureIssues(bot, p.client, brain.Namespace(bot.BrainFor(p), capturedNamespace), settings.IssueEmoji, settings.DefaultRepos)...)
+	handlers = append(handlers, Unfurl(bot, p.client, settings.DefaultRepos))
+	p.webhook = NewWebhook(bot, settings.Webhook.Secret, settings.Webhook.Routes...)
+	return bot.Own(p, handlers...)
+}
+
+// Start starts serving the plugin's Webhook, if its WebhookSettings have an
+// Addr.
+func (p *Plugin) Start(bot *slack.Bot) error {
+	if p.settings == nil || p.settings.Webhook.Addr == "" {
+		return nil
+	}
+	path := p.settings.Webhook.Path
+	if path == "" {
+		path = DefaultWebhookPath
+	}
+	listener, err := net.Listen("tcp", p.settings.Webhook.Addr)
+	if err != nil {
+		return err
+	}
+	mux := http.NewServeMux()
+	mux.Handle(path, p.webhook)
+	p.server = &http.Server{Handler: mux}
+	go func(server *http.Server) {
+		if err := server.Serve(listener); err != nil && err != http.ErrServerClosed {
+			bot.Log().Error("Github webhook server failed.", slack.Fields{
+				"error": err,
+			})
+		}
+	}(p.server)
+	return nil
+}
+
+// Stop stops serving the plugin's Webhook.
+func (p *Plugin) Stop(bot *slack.Bot) error {
+	if p.server == nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	err := p.server.Shutdown(ctx)
+	p.server = nil
+	return err
+}
+
+// Webhook returns the plugin's Webhook, so that it can be served by another
+// HTTP server. It is nil until the plugin is loaded.
+func (p *Plugin) Webhook() *Webhook {
+	return p.webhook
+}
+
+// Client returns the client the plugin uses to talk to Github. It is nil until
+// the plugin is loaded, unless one was given with WithClient.
+func (p *Plugin) Client() *github.Client {
+	return p.client
+}
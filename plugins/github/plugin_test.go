@@ -0,0 +1,66 @@
+package github
+
+import (
+	"testing"
+
+	"github.com/ajm188/slack"
+	"github.com/google/go-github/github"
+)
+
+func TestNew(t *testing.T) {
+	p := New()
+	if p.Name() != DefaultName || !p.CanLoad() || p.Client() != nil {
+		t.Error("Error. Unexpected defaults for a new plugin.")
+	}
+
+	client := github.NewClient(nil)
+	p = New(WithName("github-ops"), WithClient(client))
+	if p.Name() != "github-ops" || p.Client() != client {
+		t.Error("Error. Expected the options to configure the plugin.")
+	}
+}
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		plugin *Plugin
+		args   []interface{}
+		ok     bool
+	}{
+		{New(), nil, false},
+		{New(), []interface{}{&Settings{}}, false},
+		{New(), []interface{}{&Settings{AccessToken: "token"}}, true},
+		{New(WithSettings(&Settings{AccessToken: "token"})), nil, true},
+		{New(WithClient(github.NewClient(nil))), nil, true},
+	}
+
+	for i, test := range tests {
+		bot := slack.NewBot("token")
+		plugin := test.plugin
+		err := bot.UsePlugin(func() slack.Plugin { return plugin }, test.args...)
+		if (err == nil) != test.ok {
+			t.Errorf("Error. Test %d: unexpected error %v.", i, err)
+		}
+		if test.ok && (plugin.Client() == nil || len(bot.Handlers["message"]) != 9 || len(bot.Handlers["reaction_added"]) != 1) {
+			t.Errorf("Error. Test %d: expected the plugin to load with a client.", i)
+		}
+	}
+}
+
+func TestSeparateInstances(t *testing.T) {
+	bot := slack.NewBot("token")
+	first := New(WithSettings(&Settings{AccessToken: "first"}))
+	second := New(WithName("github-2"), WithSettings(&Settings{AccessToken: "second"}))
+	if err := bot.UsePlugin(func() slack.Plugin { return first }); err != nil {
+		t.Fatal(err)
+	}
+	if err := bot.UsePlugin(func() slack.Plugin { return second }); err != nil {
+		t.Fatal(err)
+	}
+	if first.Client() == second.Client() {
+		t.Error("Error. Expected each plugin to have its own client.")
+	}
+	plugins := bot.Plugins()
+	if len(plugins) != 2 || len(plugins[0].Handlers) != 10 || len(plugins[1].Handlers) != 10 {
+		t.Errorf("Error. Expected both plugins to be loaded and own their handlers. Got %+v.", plugins)
+	}
+}
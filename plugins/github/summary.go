@@ -0,0 +1,137 @@
+package github
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ajm188/slack/format"
+	"github.com/google/go-github/github"
+)
+
+// summarize formats a compact summary of an issue, or of a pull request if
+// pull is not nil. status is the combined status of the pull request's head
+// commit, and may be nil.
+func summarize(ref reference, issue *github.Issue, pull *github.PullRequest, status *github.CombinedStatus) string {
+	lines := []string{fmt.Sprintf(
+		"%s %s",
+		format.Bold(format.Link(stringValue(issue.HTMLURL), ref.String())),
+		format.Escape(stringValue(issue.Title)),
+	)}
+
+	kind, state := "Issue", stringValue(issue.State)
+	if pull != nil {
+		kind = "Pull request"
+		if pull.Merged != nil && *pull.Merged {
+			state = "merged"
+		}
+	}
+	details := []string{kind, state}
+	if issue.User != nil {
+		details = append(details, "opened by "+stringValue(issue.User.Login))
+	}
+	lines = append(lines, strings.Join(details, " · "))
+
+	if assignees := logins(issue); len(assignees) > 0 {
+		lines = append(lines, "Assignees: "+format.Escape(strings.Join(assignees, ", ")))
+	}
+	if len(issue.Labels) > 0 {
+		labels := make([]string, len(issue.Labels))
+		for i, label := range issue.Labels {
+			labels[i] = stringValue(label.Name)
+		}
+		lines = append(lines, "Labels: "+format.Escape(strings.Join(labels, ", ")))
+	}
+	if status != nil && status.State != nil {
+		lines = append(lines, "CI: "+*status.State)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// compactSummary formats a one-line summary of an issue, or of a pull request
+// if pull is not nil, for unfurling. reviews are the pull request's reviews, in
+// the order they were submitted.
+func compactSummary(ref reference, issue *github.Issue, pull *github.PullRequest, reviews []*github.PullRequestReview) string {
+	state := stringValue(issue.State)
+	if pull != nil && pull.Merged != nil && *pull.Merged {
+		state = "merged"
+	}
+	parts := []string{
+		format.Bold(format.Link(stringValue(issue.HTMLURL), ref.String())) + " " +
+			format.Escape(stringValue(issue.Title)),
+		state,
+	}
+	if issue.User != nil {
+		parts = append(parts, "by "+stringValue(issue.User.Login))
+	}
+	if len(issue.Labels) > 0 {
+		labels := make([]string, len(issue.Labels))
+		for i, label := range issue.Labels {
+			labels[i] = stringValue(label.Name)
+		}
+		parts = append(parts, format.Escape(strings.Join(labels, ", ")))
+	}
+	if pull != nil {
+		parts = append(parts, reviewStatus(reviews))
+	}
+	return strings.Join(parts, " · ")
+}
+
+// reviewStatus describes the state of a pull request's reviews, based on the
+// latest review from each reviewer.
+func reviewStatus(reviews []*github.PullRequestReview) string {
+	latest := make(map[string]string)
+	for _, review := range reviews {
+		if review.User == nil {
+			continue
+		}
+		login := stringValue(review.User.Login)
+		switch state := stringValue(review.State); state {
+		case "APPROVED", "CHANGES_REQUESTED":
+			latest[login] = state
+		case "DISMISSED":
+			delete(latest, login)
+		}
+	}
+	approvals := 0
+	for _, state := range latest {
+		if state == "CHANGES_REQUESTED" {
+			return "changes requested"
+		}
+		approvals++
+	}
+	switch approvals {
+	case 0:
+		return "review required"
+	case 1:
+		return "approved"
+	}
+	return fmt.Sprintf("approved by %d reviewers", approvals)
+}
+
+// logins returns the logins of the users assigned to issue.
+func logins(issue *github.Issue) []string {
+	var result []string
+	for _, user := range issue.Assignees {
+		if user != nil {
+			result = append(result, stringValue(user.Login))
+		}
+	}
+	if len(result) == 0 && issue.Assignee != nil {
+		result = append(result, stringValue(issue.Assignee.Login))
+	}
+	return result
+}
+
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func intValue(i *int) int {
+	if i == nil {
+		return 0
+	}
+	return *i
+}
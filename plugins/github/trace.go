@@ -0,0 +1,19 @@
+package github
+
+import (
+	"context"
+
+	"github.com/ajm188/slack"
+)
+
+// traced runs work, which talks to Github, in a span named name. ctx is the
+// context of the event being handled (see slack.Bot.Context), so that the time
+// spent waiting on Github shows up in the event's trace. work receives the
+// context of the span, which it passes to its Github calls.
+func traced(ctx context.Context, b *slack.Bot, name string, work func(context.Context) error) error {
+	ctx, span := b.StartSpan(ctx, name)
+	defer span.End()
+	err := work(ctx)
+	span.RecordError(err)
+	return err
+}
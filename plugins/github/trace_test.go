@@ -0,0 +1,46 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ajm188/slack"
+)
+
+type exporter []slack.SpanData
+
+func (e *exporter) Export(span slack.SpanData) {
+	*e = append(*e, span)
+}
+
+func TestTraced(t *testing.T) {
+	var tests = []struct {
+		err    error
+		errors int
+	}{
+		{nil, 0},
+		{errors.New("Not Found"), 1},
+	}
+
+	for _, test := range tests {
+		spans := &exporter{}
+		b := testBot()
+		b.Tracer = slack.NewTracer(spans)
+		err := traced(b.Context(nil), b, "github.test", func(ctx context.Context) error {
+			_, span := b.StartSpan(ctx, "github.call")
+			span.End()
+			return test.err
+		})
+		if err != test.err {
+			t.Errorf("Error. Expected %v. Got %v.", test.err, err)
+		}
+		if len(*spans) != 2 || (*spans)[1].Name != "github.test" || len((*spans)[1].Errors) != test.errors {
+			t.Errorf("Error. Expected a github.test span with %d errors. Got %v.", test.errors, *spans)
+			continue
+		}
+		if (*spans)[0].ParentID != (*spans)[1].SpanID {
+			t.Errorf("Error. Expected work's context to carry the github.test span. Got %v.", *spans)
+		}
+	}
+}
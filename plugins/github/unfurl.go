@@ -0,0 +1,198 @@
+package github
+
+import (
+	"context"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ajm188/slack"
+	"github.com/ajm188/slack/brain"
+	"github.com/google/go-github/github"
+)
+
+const (
+	// unfurlLimit is the most references that will be summarized in reply
+	// to a single message.
+	unfurlLimit = 3
+	// unfurlCacheTTL is how long a summary is reused before it is fetched
+	// from Github again.
+	unfurlCacheTTL = 5 * time.Minute
+)
+
+var (
+	urlRe       = regexp.MustCompile(`https?://github\.com/([\w.-]+)/([\w.-]+)/(?:issues|pull)/(\d+)`)
+	referenceRe = regexp.MustCompile(`(?:^|[^\w./])` + referencePattern + `\b`)
+	bareRe      = regexp.MustCompile(`(?:^|[\s(])#(\d+)\b`)
+	unfurlRe    = regexp.MustCompile(urlRe.String() + "|" + referenceRe.String() + "|" + bareRe.String())
+)
+
+// Unfurl registers a handler which replies to messages that mention issues or
+// pull requests with a compact summary of each of them: its title, state,
+// author, labels and, for pull requests, its review status. It returns the
+// registered handler.
+//
+// The handler is registered as a "Listen". It recognizes references of the
+// form "<owner>/<repo>#<number>", links to issues and pull requests on
+// github.com, and (in channels with a default repository) bare references of
+// the form "#<number>". defaultRepos maps channel names or IDs to the
+// "<owner>/<repo>" that bare references in that channel refer to.
+//
+// Messages sent by bots, and the commands of this package (which are left to
+// their own handlers), are ignored. Summaries are cached for a few minutes, so
+// that references which come up repeatedly do not hit the Github API each
+// time.
+func Unfurl(bot *slack.Bot, client *github.Client, defaultRepos map[string]string) *slack.Handler {
+	u := &unfurler{
+		client:       client,
+		defaultRepos: defaultRepos,
+		cache:        brain.NewMemory(),
+	}
+	return bot.ListenRegexp(unfurlRe, u.unfurl)
+}
+
+type unfurler struct {
+	client       *github.Client
+	defaultRepos map[string]string
+	cache        brain.Store
+}
+
+func (u *unfurler) unfurl(b *slack.Bot, event map[string]interface{}) (*slack.Message, slack.Status) {
+	if userID, _ := event["user"].(string); userID == "" || userID == b.ID {
+		return nil, slack.Continue
+	}
+	if _, ok := event["bot_id"]; ok {
+		return nil, slack.Continue
+	}
+	if text, addressed := b.Addressed(event); addressed && isCommand(text) {
+		return nil, slack.Continue
+	}
+	text, _ := event["text"].(string)
+	channel, _ := event["channel"].(string)
+
+	var summaries []string
+	for _, ref := range u.references(b, text, channel) {
+		var summary string
+		ctx := b.Context(event)
+		err := traced(ctx, b, "github.unfurl", func(ctx context.Context) (err error) {
+			summary, err = u.summary(ctx, ref)
+			return
+		})
+		if err != nil {
+			b.Log().Debug("Failed to unfurl Github reference.", slack.Fields{
+				"reference": ref.String(),
+				"error":     err,
+			})
+			continue
+		}
+		summaries = append(summaries, summary)
+	}
+	if len(summaries) == 0 {
+		return nil, slack.Continue
+	}
+	message := slack.NewMessage(strings.Join(summaries, "\n"), channel)
+	if thread, ok := event["thread_ts"].(string); ok {
+		message.InThread(thread)
+	}
+	return message, slack.Continue
+}
+
+// references returns the distinct references in text, in the order they
+// appear, up to unfurlLimit.
+func (u *unfurler) references(b *slack.Bot, text, channel string) []reference {
+	var all foundReferences
+	for _, m := range urlRe.FindAllStringSubmatchIndex(text, -1) {
+		all = append(all, foundReference{m[0], referenceAt(text, m, 1)})
+	}
+	// Links to issues contain text like "o/r/issues/1", which does not look
+	// like a reference, so links and references never overlap.
+	for _, m := range referenceRe.FindAllStringSubmatchIndex(text, -1) {
+		all = append(all, foundReference{m[2], referenceAt(text, m, 1)})
+	}
+	if owner, repo, ok := defaultRepo(b, u.defaultRepos, channel); ok {
+		for _, m := range bareRe.FindAllStringSubmatchIndex(text, -1) {
+			number, _ := strconv.Atoi(text[m[2]:m[3]])
+			all = append(all, foundReference{m[2], reference{owner, repo, number}})
+		}
+	}
+	sort.Stable(all)
+
+	var refs []reference
+	seen := make(map[reference]bool)
+	for _, f := range all {
+		if seen[f.ref] || len(refs) == unfurlLimit {
+			continue
+		}
+		seen[f.ref] = true
+		refs = append(refs, f.ref)
+	}
+	return refs
+}
+
+// foundReference is a reference, and the index in the text it was found at.
+type foundReference struct {
+	index int
+	ref   reference
+}
+
+// foundReferences sorts references by where they were found.
+type foundReferences []foundReference
+
+func (f foundReferences) Len() int           { return len(f) }
+func (f foundReferences) Less(i, j int) bool { return f[i].index < f[j].index }
+func (f foundReferences) Swap(i, j int)      { f[i], f[j] = f[j], f[i] }
+
+// referenceAt builds a reference from the owner, repo and number captured by
+// the submatch indices m, starting at group i.
+func referenceAt(text string, m []int, i int) reference {
+	number, _ := strconv.Atoi(text[m[2*i+4]:m[2*i+5]])
+	return reference{text[m[2*i]:m[2*i+1]], text[m[2*i+2]:m[2*i+3]], number}
+}
+
+// defaultRepo returns the owner and repo of the default repository of channel,
+// which may be configured in defaultRepos by its ID or its name.
+func defaultRepo(b *slack.Bot, defaultRepos map[string]string, channel string) (string, string, bool) {
+	repo, ok := defaultRepos[channel]
+	if !ok {
+		name := b.Channels[channel]
+		if repo, ok = defaultRepos[name]; !ok {
+			repo, ok = defaultRepos["#"+name]
+		}
+	}
+	if !ok {
+		return "", "", false
+	}
+	parts := strings.SplitN(repo, "/", 2)
+	if len(parts) != 2 {
+		return "", "", false
+	}
+	return parts[0], parts[1], true
+}
+
+// summary returns the compact summary of ref, from the cache if possible.
+func (u *unfurler) summary(ctx context.Context, ref reference) (string, error) {
+	key := ref.String()
+	if cached, ok, err := u.cache.Get(key); err == nil && ok {
+		return string(cached), nil
+	}
+
+	issue, _, err := u.client.Issues.Get(ctx, ref.owner, ref.repo, ref.number)
+	if err != nil {
+		return "", err
+	}
+	var pull *github.PullRequest
+	var reviews []*github.PullRequestReview
+	if issue.PullRequestLinks != nil {
+		if pull, _, err = u.client.PullRequests.Get(ctx, ref.owner, ref.repo, ref.number); err != nil {
+			return "", err
+		}
+		if reviews, _, err = u.client.PullRequests.ListReviews(ctx, ref.owner, ref.repo, ref.number, nil); err != nil {
+			return "", err
+		}
+	}
+	summary := compactSummary(ref, issue, pull, reviews)
+	u.cache.Set(key, []byte(summary), unfurlCacheTTL)
+	return summary, nil
+}
@@ -0,0 +1,126 @@
+package github
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/ajm188/slack"
+	"github.com/ajm188/slack/brain"
+	"github.com/google/go-github/github"
+)
+
+func TestReferences(t *testing.T) {
+	bot := slack.NewBot("token")
+	bot.Channels["C1"] = "dev"
+	bot.Channels["dev"] = "C1"
+	u := &unfurler{defaultRepos: map[string]string{"dev": "o/r"}}
+
+	tests := []struct {
+		text     string
+		channel  string
+		expected []string
+	}{
+		{"see o/r#1 and o/s#2", "C2", []string{"o/r#1", "o/s#2"}},
+		{"fixed in <https://github.com/o/r/pull/3|o/r#3>", "C2", []string{"o/r#3"}},
+		{"dupes o/r#1 o/r#1 https://github.com/o/r/issues/1", "C2", []string{"o/r#1"}},
+		{"bare #4 and (#5)", "C1", []string{"o/r#4", "o/r#5"}},
+		{"bare #4 with no default", "C2", nil},
+		{"not a ref: a/b/c#6 or x#7 or <#C1|dev>", "C1", nil},
+		{"#1 #2 #3 #4", "C1", []string{"o/r#1", "o/r#2", "o/r#3"}},
+	}
+	for _, test := range tests {
+		var actual []string
+		for _, ref := range u.references(bot, test.text, test.channel) {
+			actual = append(actual, ref.String())
+		}
+		if strings.Join(actual, " ") != strings.Join(test.expected, " ") {
+			t.Errorf("Error. %q: expected %v, got %v.", test.text, test.expected, actual)
+		}
+	}
+}
+
+func TestUnfurl(t *testing.T) {
+	client, requests, stop := testServer(t, map[string]string{
+		"GET /repos/o/r/issues/1": `{
+			"number": 1, "state": "open", "title": "Crash",
+			"html_url": "https://github.com/o/r/issues/1",
+			"user": {"login": "alice"}, "labels": [{"name": "bug"}, {"name": "p1"}]
+		}`,
+		"GET /repos/o/r/issues/2": `{
+			"number": 2, "state": "open", "title": "Fix crash",
+			"html_url": "https://github.com/o/r/pull/2",
+			"user": {"login": "bob"}, "pull_request": {}
+		}`,
+		"GET /repos/o/r/pulls/2": `{"number": 2, "merged": false}`,
+		"GET /repos/o/r/pulls/2/reviews": `[
+			{"user": {"login": "carol"}, "state": "CHANGES_REQUESTED"},
+			{"user": {"login": "carol"}, "state": "APPROVED"},
+			{"user": {"login": "dave"}, "state": "COMMENTED"}
+		]`,
+	})
+	defer stop()
+
+	bot := slack.NewBot("token")
+	bot.ID = "UBOT"
+	bot.Name = "bot"
+	u := &unfurler{client: client, cache: brain.NewMemory()}
+
+	summary, err := u.summary(context.Background(), reference{"o", "r", 1})
+	expected := "*<https://github.com/o/r/issues/1|o/r#1>* Crash · open · by alice · bug, p1"
+	if err != nil || summary != expected {
+		t.Errorf("Error. Expected %q, got %q (%v).", expected, summary, err)
+	}
+	summary, err = u.summary(context.Background(), reference{"o", "r", 2})
+	expected = "*<https://github.com/o/r/pull/2|o/r#2>* Fix crash · open · by bob · approved"
+	if err != nil || summary != expected {
+		t.Errorf("Error. Expected %q, got %q (%v).", expected, summary, err)
+	}
+
+	// Summaries are cached.
+	delete(requests, "GET /repos/o/r/issues/1")
+	u.summary(context.Background(), reference{"o", "r", 1})
+	if _, ok := requests["GET /repos/o/r/issues/1"]; ok {
+		t.Error("Error. Expected the summary to come from the cache.")
+	}
+
+	tests := []struct {
+		event map[string]interface{}
+		reply bool
+	}{
+		{map[string]interface{}{"user": "U1", "channel": "C1", "text": "see o/r#1"}, true},
+		{map[string]interface{}{"user": "U1", "channel": "C1", "text": "see o/r#9"}, false},
+		{map[string]interface{}{"user": "UBOT", "channel": "C1", "text": "see o/r#1"}, false},
+		{map[string]interface{}{"user": "U1", "bot_id": "B1", "channel": "C1", "text": "see o/r#1"}, false},
+		{map[string]interface{}{"user": "U1", "channel": "C1", "text": "bot: gh show o/r#1"}, false},
+		{map[string]interface{}{"user": "U1", "channel": "D1", "text": "gh show o/r#1"}, false},
+		{map[string]interface{}{"user": "U1", "channel": "D1", "text": "what about o/r#1?"}, true},
+		{map[string]interface{}{"user": "U1", "channel": "C1", "text": "bot: have you seen o/r#1?"}, true},
+	}
+	for _, test := range tests {
+		message, _ := u.unfurl(bot, test.event)
+		if (message != nil) != test.reply {
+			t.Errorf("Error. %v: expected reply %v.", test.event, test.reply)
+		}
+	}
+}
+
+func TestReviewStatus(t *testing.T) {
+	review := func(login, state string) *github.PullRequestReview {
+		return &github.PullRequestReview{User: &github.User{Login: &login}, State: &state}
+	}
+	tests := []struct {
+		reviews  []*github.PullRequestReview
+		expected string
+	}{
+		{nil, "review required"},
+		{[]*github.PullRequestReview{review("a", "APPROVED"), review("b", "CHANGES_REQUESTED")}, "changes requested"},
+		{[]*github.PullRequestReview{review("a", "APPROVED"), review("b", "APPROVED")}, "approved by 2 reviewers"},
+		{[]*github.PullRequestReview{review("a", "APPROVED"), review("a", "DISMISSED")}, "review required"},
+	}
+	for _, test := range tests {
+		if actual := reviewStatus(test.reviews); actual != test.expected {
+			t.Errorf("Error. Expected %q, got %q.", test.expected, actual)
+		}
+	}
+}
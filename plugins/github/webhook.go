@@ -0,0 +1,378 @@
+package github
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/ajm188/slack"
+	"github.com/ajm188/slack/format"
+	"github.com/google/go-github/github"
+)
+
+const (
+	// DefaultWebhookPath is the path the webhook is served at when the
+	// plugin starts its own server, unless WebhookSettings.Path is set.
+	DefaultWebhookPath = "/github/webhook"
+
+	// maxPayloadSize is the largest payload the webhook will read. Github
+	// caps payloads at 25MB.
+	maxPayloadSize = 25 << 20
+	// pushCommitLimit is the most commits listed in a push announcement.
+	pushCommitLimit = 5
+)
+
+// webhookEvents are the types of events the webhook decodes.
+var webhookEvents = map[string]bool{
+	"ping":                true,
+	"push":                true,
+	"pull_request":        true,
+	"pull_request_review": true,
+	"status":              true,
+	"check_run":           true,
+	"release":             true,
+}
+
+// Route sends the Github events which match it to a Slack channel, given by
+// its name or ID.
+//
+// Repos restricts the route to events from the listed repositories, given as
+// "<owner>/<repo>". Patterns like "<owner>/*" are also accepted. Events
+// restricts the route to the listed event types, like "push" or
+// "pull_request". An empty list matches everything.
+type Route struct {
+	Channel string   `config:"channel,required"`
+	Repos   []string `config:"repos"`
+	Events  []string `config:"events"`
+}
+
+func (route Route) matches(repo, event string) bool {
+	return matchAny(route.Repos, repo) && matchAny(route.Events, event)
+}
+
+func matchAny(patterns []string, name string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Webhook is an http.Handler which receives Github webhook deliveries and
+// announces them in Slack. It verifies each delivery's X-Hub-Signature-256
+// header against Secret, decodes the payload into one of go-github's event
+// types, and sends an announcement to the channel of each matching Route
+// with the bot's Send method.
+//
+// Pushes, pull requests being opened, closed, merged, reopened or marked ready
+// for review, submitted reviews, failed commit statuses and check runs, and
+// published releases are announced. Other events are accepted and ignored.
+type Webhook struct {
+	Secret []byte
+	Routes []Route
+
+	bot *slack.Bot
+}
+
+// NewWebhook constructs a Webhook which sends announcements with bot.
+func NewWebhook(bot *slack.Bot, secret string, routes ...Route) *Webhook {
+	return &Webhook{
+		Secret: []byte(secret),
+		Routes: routes,
+		bot:    bot,
+	}
+}
+
+// ServeHTTP handles a webhook delivery. It responds with 401 if the signature
+// is missing or wrong, 400 if the payload cannot be decoded, and 204
+// otherwise.
+func (hook *Webhook) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		w.Header().Set("Allow", "POST")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	payload, err := ioutil.ReadAll(io.LimitReader(r.Body, maxPayloadSize))
+	if err != nil {
+		http.Error(w, "could not read payload", http.StatusBadRequest)
+		return
+	}
+	if !hook.verify(r.Header.Get("X-Hub-Signature-256"), payload) {
+		http.Error(w, "invalid signature", http.StatusUnauthorized)
+		return
+	}
+
+	// Github sends many more kinds of events than this webhook announces,
+	// so other events are accepted and ignored.
+	eventType := r.Header.Get("X-GitHub-Event")
+	if !webhookEvents[eventType] {
+		hook.bot.Log().Debug("Ignoring Github webhook delivery.", slack.Fields{
+			"event": eventType,
+		})
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	event, err := github.ParseWebHook(eventType, payload)
+	if err != nil {
+		http.Error(w, "could not decode payload", http.StatusBadRequest)
+		return
+	}
+	repo, text := announce(event)
+	if text != "" {
+		hook.send(repo, eventType, text)
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// verify checks signature, which has the form "sha256=<hex digest>", against
+// the HMAC of payload keyed by the secret.
+func (hook *Webhook) verify(signature string, payload []byte) bool {
+	if len(hook.Secret) == 0 || !strings.HasPrefix(signature, "sha256=") {
+		return false
+	}
+	expected, err := hex.DecodeString(strings.TrimPrefix(signature, "sha256="))
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, hook.Secret)
+	mac.Write(payload)
+	return hmac.Equal(mac.Sum(nil), expected)
+}
+
+// send sends text to the channels the event is routed to.
+func (hook *Webhook) send(repo, eventType, text string) {
+	for _, channel := range hook.channels(repo, eventType) {
+		hook.bot.Send(slack.NewMessage(text, channel))
+	}
+}
+
+// channels returns the IDs of the channels of every route which matches the
+// event, without duplicates.
+func (hook *Webhook) channels(repo, eventType string) []string {
+	var channels []string
+	seen := make(map[string]bool)
+	for _, route := range hook.Routes {
+		if !route.matches(repo, eventType) {
+			continue
+		}
+		channel := channelID(hook.bot, route.Channel)
+		if !seen[channel] {
+			seen[channel] = true
+			channels = append(channels, channel)
+		}
+	}
+	return channels
+}
+
+// channelID returns the ID of channel, which may be given by its ID or by its
+// name (with or without a leading "#"). Channel names are always lower case,
+// and IDs are always upper case, which tells them apart.
+func channelID(bot *slack.Bot, channel string) string {
+	name := strings.TrimPrefix(channel, "#")
+	if name != channel || strings.ToLower(name) == name {
+		if id, ok := bot.Channels[name]; ok {
+			return id
+		}
+	}
+	return name
+}
+
+// announce returns the full name of the repository event came from, and the
+// text announcing it. The text is empty if the event should not be announced.
+func announce(event interface{}) (string, string) {
+	switch e := event.(type) {
+	case *github.PushEvent:
+		return pushRepo(e), announcePush(e)
+	case *github.PullRequestEvent:
+		return repoName(e.Repo), announcePullRequest(e)
+	case *github.PullRequestReviewEvent:
+		return repoName(e.Repo), announceReview(e)
+	case *github.StatusEvent:
+		return repoName(e.Repo), announceStatus(e)
+	case *github.CheckRunEvent:
+		return repoName(e.Repo), announceCheckRun(e)
+	case *github.ReleaseEvent:
+		return repoName(e.Repo), announceRelease(e)
+	}
+	return "", ""
+}
+
+func announcePush(e *github.PushEvent) string {
+	if (e.Deleted != nil && *e.Deleted) || len(e.Commits) == 0 {
+		return ""
+	}
+	branch := strings.TrimPrefix(stringValue(e.Ref), "refs/heads/")
+	commits := "1 commit"
+	if len(e.Commits) != 1 {
+		commits = fmt.Sprintf("%d commits", len(e.Commits))
+	}
+	lines := []string{fmt.Sprintf(
+		"%s pushed %s to %s in %s",
+		format.Bold(login(e.Sender)),
+		format.Link(stringValue(e.Compare), commits),
+		format.Code(branch),
+		format.Escape(pushRepo(e)),
+	)}
+	for i, commit := range e.Commits {
+		if i == pushCommitLimit {
+			lines = append(lines, fmt.Sprintf("… and %d more", len(e.Commits)-i))
+			break
+		}
+		message := strings.SplitN(stringValue(commit.Message), "\n", 2)[0]
+		lines = append(lines, fmt.Sprintf(
+			"• %s %s",
+			format.Link(stringValue(commit.URL), shortSHA(stringValue(commit.ID))),
+			format.Escape(message),
+		))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func announcePullRequest(e *github.PullRequestEvent) string {
+	if e.PullRequest == nil {
+		return ""
+	}
+	verb := stringValue(e.Action)
+	switch verb {
+	case "opened", "reopened":
+	case "ready_for_review":
+		verb = "marked ready for review"
+	case "closed":
+		if e.PullRequest.Merged != nil && *e.PullRequest.Merged {
+			verb = "merged"
+		}
+	default:
+		return ""
+	}
+	return fmt.Sprintf(
+		"%s %s %s",
+		format.Bold(login(e.Sender)),
+		verb,
+		pullLink(repoName(e.Repo), e.PullRequest),
+	)
+}
+
+func announceReview(e *github.PullRequestReviewEvent) string {
+	if stringValue(e.Action) != "submitted" || e.Review == nil || e.PullRequest == nil {
+		return ""
+	}
+	var verb string
+	switch strings.ToLower(stringValue(e.Review.State)) {
+	case "approved":
+		verb = "approved"
+	case "changes_requested":
+		verb = "requested changes on"
+	case "commented":
+		verb = "reviewed"
+	default:
+		return ""
+	}
+	return fmt.Sprintf(
+		"%s %s %s",
+		format.Bold(login(e.Review.User)),
+		format.Link(stringValue(e.Review.HTMLURL), verb),
+		pullLink(repoName(e.Repo), e.PullRequest),
+	)
+}
+
+func announceStatus(e *github.StatusEvent) string {
+	state := stringValue(e.State)
+	if state != "failure" && state != "error" {
+		return ""
+	}
+	text := fmt.Sprintf(
+		"CI failed for %s in %s: %s",
+		format.Code(shortSHA(stringValue(e.SHA))),
+		format.Escape(repoName(e.Repo)),
+		format.Link(stringValue(e.TargetURL), stringValue(e.Context)),
+	)
+	if description := stringValue(e.Description); description != "" {
+		text += " — " + format.Escape(description)
+	}
+	return text
+}
+
+func announceCheckRun(e *github.CheckRunEvent) string {
+	if stringValue(e.Action) != "completed" || e.CheckRun == nil {
+		return ""
+	}
+	conclusion := stringValue(e.CheckRun.Conclusion)
+	if conclusion != "failure" && conclusion != "timed_out" {
+		return ""
+	}
+	return fmt.Sprintf(
+		"CI failed for %s in %s: %s (%s)",
+		format.Code(shortSHA(stringValue(e.CheckRun.HeadSHA))),
+		format.Escape(repoName(e.Repo)),
+		format.Link(stringValue(e.CheckRun.HTMLURL), stringValue(e.CheckRun.Name)),
+		strings.Replace(conclusion, "_", " ", -1),
+	)
+}
+
+func announceRelease(e *github.ReleaseEvent) string {
+	if stringValue(e.Action) != "published" || e.Release == nil {
+		return ""
+	}
+	name := stringValue(e.Release.Name)
+	if name == "" {
+		name = stringValue(e.Release.TagName)
+	}
+	kind := "released"
+	if e.Release.Prerelease != nil && *e.Release.Prerelease {
+		kind = "pre-released"
+	}
+	return fmt.Sprintf(
+		"%s %s %s in %s",
+		format.Bold(login(e.Sender)),
+		kind,
+		format.Link(stringValue(e.Release.HTMLURL), name),
+		format.Escape(repoName(e.Repo)),
+	)
+}
+
+func pullLink(repo string, pull *github.PullRequest) string {
+	ref := fmt.Sprintf("%s#%d", repo, intValue(pull.Number))
+	return fmt.Sprintf(
+		"%s %s",
+		format.Link(stringValue(pull.HTMLURL), ref),
+		format.Escape(stringValue(pull.Title)),
+	)
+}
+
+func pushRepo(e *github.PushEvent) string {
+	if e.Repo == nil {
+		return ""
+	}
+	return stringValue(e.Repo.FullName)
+}
+
+func repoName(repo *github.Repository) string {
+	if repo == nil {
+		return ""
+	}
+	return stringValue(repo.FullName)
+}
+
+func login(user *github.User) string {
+	if user == nil {
+		return "someone"
+	}
+	return stringValue(user.Login)
+}
+
+func shortSHA(sha string) string {
+	if len(sha) > 7 {
+		return sha[:7]
+	}
+	return sha
+}
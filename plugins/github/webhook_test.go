@@ -0,0 +1,197 @@
+package github
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ajm188/slack"
+	"github.com/google/go-github/github"
+)
+
+func sign(secret, payload string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(payload))
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestWebhookServeHTTP(t *testing.T) {
+	hook := NewWebhook(slack.NewBot("token"), "secret")
+	push := `{"ref": "refs/heads/main", "commits": [{"id": "abc", "message": "hi"}]}`
+
+	tests := []struct {
+		method    string
+		event     string
+		payload   string
+		signature string
+		status    int
+	}{
+		{"POST", "push", push, sign("secret", push), http.StatusNoContent},
+		{"POST", "push", push, sign("wrong", push), http.StatusUnauthorized},
+		{"POST", "push", push, "sha1=abc", http.StatusUnauthorized},
+		{"POST", "push", push, "", http.StatusUnauthorized},
+		{"POST", "push", "{", sign("secret", "{"), http.StatusBadRequest},
+		{"POST", "watch", "{", sign("secret", "{"), http.StatusNoContent},
+		{"POST", "ping", `{"zen": "Keep it simple."}`, sign("secret", `{"zen": "Keep it simple."}`), http.StatusNoContent},
+		{"GET", "push", "", "", http.StatusMethodNotAllowed},
+	}
+	for _, test := range tests {
+		r := httptest.NewRequest(test.method, DefaultWebhookPath, strings.NewReader(test.payload))
+		r.Header.Set("X-GitHub-Event", test.event)
+		if test.signature != "" {
+			r.Header.Set("X-Hub-Signature-256", test.signature)
+		}
+		w := httptest.NewRecorder()
+		hook.ServeHTTP(w, r)
+		if w.Code != test.status {
+			t.Errorf("Error. %s %s: expected %d, got %d.", test.method, test.event, test.status, w.Code)
+		}
+	}
+
+	// Without a secret, every delivery is rejected.
+	hook = NewWebhook(slack.NewBot("token"), "")
+	r := httptest.NewRequest("POST", DefaultWebhookPath, strings.NewReader(push))
+	r.Header.Set("X-GitHub-Event", "push")
+	r.Header.Set("X-Hub-Signature-256", sign("", push))
+	w := httptest.NewRecorder()
+	hook.ServeHTTP(w, r)
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("Error. Expected 401 without a secret, got %d.", w.Code)
+	}
+}
+
+func TestWebhookChannels(t *testing.T) {
+	bot := slack.NewBot("token")
+	bot.Channels["dev"] = "C1"
+	bot.Channels["C1"] = "dev"
+	bot.Channels["releases"] = "C2"
+	bot.Channels["C2"] = "releases"
+	hook := NewWebhook(bot, "secret",
+		Route{Channel: "#dev", Repos: []string{"o/*"}},
+		Route{Channel: "C1", Events: []string{"push"}},
+		Route{Channel: "releases", Repos: []string{"o/r"}, Events: []string{"release"}},
+		Route{Channel: "C3", Repos: []string{"other/r"}},
+	)
+
+	tests := []struct {
+		repo     string
+		event    string
+		expected string
+	}{
+		{"o/r", "push", "C1"},
+		{"o/r", "release", "C1 C2"},
+		{"o/s", "release", "C1"},
+		{"x/y", "push", "C1"},
+		{"x/y", "release", ""},
+		{"other/r", "status", "C3"},
+	}
+	for _, test := range tests {
+		actual := strings.Join(hook.channels(test.repo, test.event), " ")
+		if actual != test.expected {
+			t.Errorf("Error. %s %s: expected %q, got %q.", test.repo, test.event, test.expected, actual)
+		}
+	}
+}
+
+func TestAnnounce(t *testing.T) {
+	tests := []struct {
+		event    string
+		payload  string
+		repo     string
+		expected string
+	}{
+		{
+			"push",
+			`{"ref": "refs/heads/main", "compare": "https://c", "repository": {"full_name": "o/r"},
+			  "sender": {"login": "alice"},
+			  "commits": [{"id": "0123456789", "message": "Fix <crash>\n\nDetails", "url": "https://u"}]}`,
+			"o/r",
+			"*alice* pushed <https://c|1 commit> to `main` in o/r\n• <https://u|0123456> Fix &lt;crash&gt;",
+		},
+		{"push", `{"deleted": true, "repository": {"full_name": "o/r"}}`, "o/r", ""},
+		{
+			"pull_request",
+			`{"action": "closed", "repository": {"full_name": "o/r"}, "sender": {"login": "bob"},
+			  "pull_request": {"number": 2, "title": "Fix", "html_url": "https://p", "merged": true}}`,
+			"o/r",
+			"*bob* merged <https://p|o/r#2> Fix",
+		},
+		{"pull_request", `{"action": "labeled", "pull_request": {}}`, "", ""},
+		{
+			"pull_request_review",
+			`{"action": "submitted", "repository": {"full_name": "o/r"},
+			  "review": {"state": "changes_requested", "user": {"login": "carol"}, "html_url": "https://r"},
+			  "pull_request": {"number": 2, "title": "Fix", "html_url": "https://p"}}`,
+			"o/r",
+			"*carol* <https://r|requested changes on> <https://p|o/r#2> Fix",
+		},
+		{
+			"status",
+			`{"sha": "0123456789", "state": "failure", "context": "ci/build", "target_url": "https://t",
+			  "description": "Tests failed", "repository": {"full_name": "o/r"}}`,
+			"o/r",
+			"CI failed for `0123456` in o/r: <https://t|ci/build> — Tests failed",
+		},
+		{"status", `{"state": "success", "repository": {"full_name": "o/r"}}`, "o/r", ""},
+		{
+			"check_run",
+			`{"action": "completed", "repository": {"full_name": "o/r"},
+			  "check_run": {"name": "lint", "head_sha": "abc", "conclusion": "timed_out", "html_url": "https://k"}}`,
+			"o/r",
+			"CI failed for `abc` in o/r: <https://k|lint> (timed out)",
+		},
+		{
+			"release",
+			`{"action": "published", "repository": {"full_name": "o/r"}, "sender": {"login": "dave"},
+			  "release": {"tag_name": "v1.0.0", "html_url": "https://v"}}`,
+			"o/r",
+			"*dave* released <https://v|v1.0.0> in o/r",
+		},
+	}
+	for _, test := range tests {
+		event, err := github.ParseWebHook(test.event, []byte(test.payload))
+		if err != nil {
+			t.Fatal(err)
+		}
+		repo, text := announce(event)
+		if repo != test.repo || text != test.expected {
+			t.Errorf("Error. %s: expected %q in %q, got %q in %q.", test.event, test.expected, test.repo, text, repo)
+		}
+	}
+}
+
+func TestPluginServesWebhook(t *testing.T) {
+	bot := slack.NewBot("token")
+	plugin := New(WithClient(github.NewClient(nil)))
+	settings := &Settings{
+		AccessToken: "token",
+		Webhook:     WebhookSettings{Addr: "127.0.0.1:0", Secret: "secret"},
+	}
+	if err := bot.UsePlugin(func() slack.Plugin { return plugin }, settings); err != nil {
+		t.Fatal(err)
+	}
+	if plugin.Webhook() == nil {
+		t.Fatal("Error. Expected the plugin to have a webhook.")
+	}
+	if err := plugin.Start(bot); err != nil {
+		t.Fatal(err)
+	}
+	if plugin.server == nil {
+		t.Fatal("Error. Expected the plugin to start a server.")
+	}
+	if err := plugin.Stop(bot); err != nil {
+		t.Error(err)
+	}
+
+	r := httptest.NewRequest("POST", DefaultWebhookPath, bytes.NewReader(nil))
+	w := httptest.NewRecorder()
+	plugin.Webhook().ServeHTTP(w, r)
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("Error. Expected 401 for an unsigned delivery, got %d.", w.Code)
+	}
+}
@@ -0,0 +1,38 @@
+package slack
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/ajm188/slack/blocks"
+)
+
+// PostMessage sends message with the chat.postMessage Web API method, rather
+// than over the RTM websocket. This is required for messages with blocks, and
+// the bot's main loop uses it for those messages automatically. The blocks are
+// validated before the message is sent. The response payload is returned on
+// success.
+func (bot *Bot) PostMessage(message *Message) (map[string]interface{}, error) {
+	params := url.Values{}
+	params.Set("channel", message.channel)
+	params.Set("text", message.text)
+	params.Set("as_user", "true")
+	if message.threadTS != "" {
+		params.Set("thread_ts", message.threadTS)
+	}
+	if len(message.blocks) > 0 {
+		encoded, err := blocks.Encode(message.blocks...)
+		if err != nil {
+			return nil, err
+		}
+		params.Set("blocks", encoded)
+	}
+	payload, err := bot.Call("chat.postMessage", params)
+	if err != nil {
+		return nil, err
+	}
+	if ok, _ := payload["ok"].(bool); !ok {
+		return payload, &Error{fmt.Sprintf("chat.postMessage failed: %v", payload["error"])}
+	}
+	return payload, nil
+}
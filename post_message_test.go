@@ -0,0 +1,16 @@
+package slack
+
+import (
+	"testing"
+
+	"github.com/ajm188/slack/blocks"
+)
+
+func TestPostMessage_invalidBlocks(t *testing.T) {
+	bot := NewBot("token")
+	message := NewMessage("text", "C1").WithBlocks(&blocks.Section{})
+	_, err := bot.PostMessage(message)
+	if _, ok := err.(*blocks.Error); !ok {
+		t.Errorf("Error. Expected a blocks.Error. Got %v.", err)
+	}
+}
@@ -1,20 +1,169 @@
 package slack
 
 import (
+	"fmt"
 	"net/url"
+	"strings"
 )
 
-// React creates a BotAction which reacts to the passed-in event with emoji.
-func React(emoji string) BotAction {
+// Reaction is an emoji reaction on a message: the name of the emoji, how many
+// users reacted with it, and which ones.
+type Reaction struct {
+	Name  string
+	Count int
+	Users []string
+}
+
+// React creates a BotAction which reacts to the passed-in event with each of
+// the emoji, in order. Reactions which fail (for example, because the bot
+// already reacted with that emoji) are logged, and do not stop the others.
+func React(emoji ...string) BotAction {
 	closure := func(bot *Bot, event map[string]interface{}) (*Message, Status) {
 		channel := event["channel"].(string)
 		timestamp := event["ts"].(string)
-		params := url.Values{}
-		params.Set("channel", channel)
-		params.Set("timestamp", timestamp)
-		params.Set("name", emoji)
-		bot.Call("reactions.add", params)
+		if err := bot.AddReactions(channel, timestamp, emoji...); err != nil {
+			bot.Log().Warn("Failed to react.", Fields{
+				"channel": channel,
+				"ts":      timestamp,
+				"error":   err,
+			})
+		}
 		return nil, Continue
 	}
 	return closure
 }
+
+// AddReaction reacts with emoji to the message with the timestamp ts in
+// channel, with the reactions.add Web API method. The colons around emoji are
+// optional. If Slack refuses, the error says why, like "reactions.add failed:
+// already_reacted".
+func (bot *Bot) AddReaction(channel, ts, emoji string) error {
+	return bot.callReactions("reactions.add", channel, ts, emoji)
+}
+
+// RemoveReaction removes the bot's emoji reaction from the message with the
+// timestamp ts in channel, with the reactions.remove Web API method. If Slack
+// refuses, the error says why, like "reactions.remove failed: no_reaction".
+func (bot *Bot) RemoveReaction(channel, ts, emoji string) error {
+	return bot.callReactions("reactions.remove", channel, ts, emoji)
+}
+
+// AddReactions reacts to the message with the timestamp ts in channel with
+// each of the emoji, in order. Every reaction is attempted, even if some fail;
+// the returned error describes each failure.
+func (bot *Bot) AddReactions(channel, ts string, emoji ...string) error {
+	var failures []string
+	for _, name := range emoji {
+		if err := bot.AddReaction(channel, ts, name); err != nil {
+			failures = append(failures, fmt.Sprintf("%s (%v)", strings.Trim(name, ":"), err))
+		}
+	}
+	if len(failures) > 0 {
+		return &Error{"could not react with " + strings.Join(failures, ", ")}
+	}
+	return nil
+}
+
+func (bot *Bot) callReactions(method, channel, ts, emoji string) error {
+	params := url.Values{}
+	params.Set("channel", channel)
+	params.Set("timestamp", ts)
+	params.Set("name", strings.Trim(emoji, ":"))
+	payload, err := bot.Call(method, params)
+	if err != nil {
+		return err
+	}
+	if ok, _ := payload["ok"].(bool); !ok {
+		return &Error{fmt.Sprintf("%s failed: %v", method, payload["error"])}
+	}
+	return nil
+}
+
+// Reactions returns the reactions on the message with the timestamp ts in
+// channel, with the reactions.get Web API method.
+func (bot *Bot) Reactions(channel, ts string) ([]Reaction, error) {
+	params := url.Values{}
+	params.Set("channel", channel)
+	params.Set("timestamp", ts)
+	params.Set("full", "true")
+	payload, err := bot.Call("reactions.get", params)
+	if err != nil {
+		return nil, err
+	}
+	if ok, _ := payload["ok"].(bool); !ok {
+		return nil, &Error{fmt.Sprintf("reactions.get failed: %v", payload["error"])}
+	}
+	message, _ := payload["message"].(map[string]interface{})
+	list, _ := message["reactions"].([]interface{})
+	reactions := make([]Reaction, 0, len(list))
+	for _, item := range list {
+		fields, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		reaction := Reaction{}
+		reaction.Name, _ = fields["name"].(string)
+		if count, ok := fields["count"].(float64); ok {
+			reaction.Count = int(count)
+		}
+		users, _ := fields["users"].([]interface{})
+		for _, user := range users {
+			if user, ok := user.(string); ok {
+				reaction.Users = append(reaction.Users, user)
+			}
+		}
+		reactions = append(reactions, reaction)
+	}
+	return reactions, nil
+}
+
+// OnReactionAdded registers handler to fire when a user reacts to a message
+// with emoji in channel. An empty emoji or channel matches any. The colons
+// around emoji are optional, and skin tones are ignored, so "thumbsup" matches
+// "thumbsup::skin-tone-2". channel may be an ID or a name, with or without a
+// leading "#".
+//
+// The handler receives the "reaction_added" event. The reacted-to message is
+// described by its "item", which has the message's "channel" and "ts".
+func (bot *Bot) OnReactionAdded(emoji, channel string, handler BotAction) *Handler {
+	return bot.OnEvent("reaction_added", reactionFilter(emoji, channel, handler))
+}
+
+// OnReactionRemoved registers handler to fire when a user removes their emoji
+// reaction from a message in channel. The filters work as they do for
+// OnReactionAdded.
+func (bot *Bot) OnReactionRemoved(emoji, channel string, handler BotAction) *Handler {
+	return bot.OnEvent("reaction_removed", reactionFilter(emoji, channel, handler))
+}
+
+func reactionFilter(emoji, channel string, handler BotAction) BotAction {
+	emoji = strings.Trim(emoji, ":")
+	channel = strings.TrimPrefix(channel, "#")
+	return func(bot *Bot, event map[string]interface{}) (*Message, Status) {
+		reaction, _ := event["reaction"].(string)
+		if i := strings.Index(reaction, "::"); i >= 0 {
+			reaction = reaction[:i]
+		}
+		if emoji != "" && reaction != emoji {
+			return nil, Continue
+		}
+		item, _ := event["item"].(map[string]interface{})
+		if channel != "" && !bot.isChannel(item["channel"], channel) {
+			return nil, Continue
+		}
+		return handler(bot, event)
+	}
+}
+
+// isChannel returns whether the channel ID id is the channel given by its ID or
+// name.
+func (bot *Bot) isChannel(id interface{}, channel string) bool {
+	if id == channel {
+		return true
+	}
+	if bot == nil {
+		return false
+	}
+	known, ok := bot.Channels[channel]
+	return ok && known == id
+}
@@ -0,0 +1,63 @@
+package slack
+
+import (
+	"testing"
+)
+
+func TestOnReactionAdded(t *testing.T) {
+	var tests = []struct {
+		emoji    string
+		channel  string
+		reaction string
+		in       string
+		fired    bool
+	}{
+		{"", "", "tada", "C1", true},
+		{"tada", "", "tada", "C1", true},
+		{":tada:", "", "tada", "C1", true},
+		{"tada", "", "eyes", "C1", false},
+		{"thumbsup", "", "thumbsup::skin-tone-2", "C1", true},
+		{"", "C1", "tada", "C1", true},
+		{"", "C1", "tada", "C2", false},
+		{"", "general", "tada", "C1", true},
+		{"", "#general", "tada", "C1", true},
+		{"", "#general", "tada", "C2", false},
+		{"", "random", "tada", "C1", false},
+	}
+
+	for _, test := range tests {
+		bot := NewBot("token")
+		bot.Channels["general"] = "C1"
+		bot.Channels["C1"] = "general"
+		fired := false
+		bot.OnReactionAdded(test.emoji, test.channel, func(_ *Bot, _ map[string]interface{}) (*Message, Status) {
+			fired = true
+			return nil, Continue
+		})
+		bot.OnReactionRemoved("", "", func(_ *Bot, _ map[string]interface{}) (*Message, Status) {
+			t.Error("Error. Expected reaction_removed handlers not to fire for reaction_added.")
+			return nil, Continue
+		})
+		bot.Dispatch(map[string]interface{}{
+			"type":     "reaction_added",
+			"reaction": test.reaction,
+			"item":     map[string]interface{}{"type": "message", "channel": test.in, "ts": "1.0"},
+		})
+		if fired != test.fired {
+			t.Errorf("Error. Expected %v for %q in %s with filters %q, %q. Got %v.",
+				test.fired, test.reaction, test.in, test.emoji, test.channel, fired)
+		}
+	}
+}
+
+func TestOnReactionRemoved(t *testing.T) {
+	bot := NewBot("token")
+	fired := 0
+	bot.OnReactionRemoved("tada", "", func(_ *Bot, _ map[string]interface{}) (*Message, Status) {
+		fired++
+		return nil, Continue
+	})
+	bot.Dispatch(map[string]interface{}{"type": "reaction_removed", "reaction": "tada"})
+	bot.Dispatch(map[string]interface{}{"type": "reaction_removed", "reaction": "eyes"})
+	assert(fired == 1, t)
+}
@@ -0,0 +1,153 @@
+package slack
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	// Incoming is the Direction of events the bot received from Slack.
+	Incoming = "in"
+	// Outgoing is the Direction of messages the bot sent to Slack.
+	Outgoing = "out"
+)
+
+// Record is a single line of a recording: an event the bot received, or a
+// message it sent, and the time that happened. Event is set for Incoming
+// records, and Message for Outgoing ones.
+type Record struct {
+	Time      time.Time              `json:"time"`
+	Direction string                 `json:"direction"`
+	Event     map[string]interface{} `json:"event,omitempty"`
+	Message   map[string]string      `json:"message,omitempty"`
+}
+
+// Recorder writes the events a bot receives and the messages it sends to a
+// recording, one JSON-encoded Record per line. Set a bot's Recorder to start
+// recording its session; the recording can be fed back to the bot with
+// Replay.
+//
+// Messages are recorded as they are written to the RTM websocket, so messages
+// sent with PostMessage are recorded without their blocks.
+type Recorder struct {
+	mu      sync.Mutex
+	encoder *json.Encoder
+	closer  io.Closer
+	now     func() time.Time
+}
+
+// NewRecorder constructs a Recorder which writes the recording to w.
+func NewRecorder(w io.Writer) *Recorder {
+	recorder := &Recorder{
+		encoder: json.NewEncoder(w),
+		now:     time.Now,
+	}
+	if closer, ok := w.(io.Closer); ok {
+		recorder.closer = closer
+	}
+	return recorder
+}
+
+// CreateRecording constructs a Recorder which appends the recording to the
+// file at path, creating the file if it does not exist.
+func CreateRecording(path string) (*Recorder, error) {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, err
+	}
+	return NewRecorder(file), nil
+}
+
+// Close closes the writer the recording is written to, if it is an
+// io.Closer.
+func (recorder *Recorder) Close() error {
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+	if recorder.closer == nil {
+		return nil
+	}
+	return recorder.closer.Close()
+}
+
+func (recorder *Recorder) recordEvent(event map[string]interface{}) error {
+	return recorder.record(Record{Direction: Incoming, Event: event})
+}
+
+func (recorder *Recorder) recordMessage(message *Message) error {
+	return recorder.record(Record{Direction: Outgoing, Message: message.toMap()})
+}
+
+func (recorder *Recorder) record(record Record) error {
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+	record.Time = recorder.now()
+	return recorder.encoder.Encode(record)
+}
+
+// ReadRecording reads the records of a recording written by a Recorder.
+func ReadRecording(r io.Reader) ([]Record, error) {
+	var records []Record
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 16<<20)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		var record Record
+		if err := json.Unmarshal(line, &record); err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+	return records, scanner.Err()
+}
+
+// Replay feeds the events of a recording to the bot's handlers, in the order
+// they were recorded, and returns the messages the handlers returned instead
+// of sending them. Just like the bot's main loop, replaying stops when a
+// handler returns Shutdown or ShutdownNow. Messages that were sent during the
+// recording are ignored, so that they can be compared with the messages that
+// are returned.
+//
+// If realTime is true, Replay waits between events for as long as passed
+// between them when they were recorded. Otherwise, the events are replayed
+// one after another. Either way, each event's handlers finish before the next
+// event is handled, so replaying is deterministic as long as the handlers are.
+func (bot *Bot) Replay(r io.Reader, realTime bool) ([]*Message, error) {
+	records, err := ReadRecording(r)
+	if err != nil {
+		return nil, err
+	}
+	var messages []*Message
+	var last time.Time
+	for _, record := range records {
+		if record.Direction != Incoming || record.Event == nil {
+			continue
+		}
+		if realTime && !last.IsZero() && record.Time.After(last) {
+			time.Sleep(record.Time.Sub(last))
+		}
+		last = record.Time
+		abort := false
+		for _, response := range bot.Dispatch(record.Event) {
+			if response.Status == ShutdownNow {
+				return messages, nil
+			}
+			if response.Message != nil {
+				messages = append(messages, response.Message)
+			}
+			if response.Status == Shutdown {
+				abort = true
+			}
+		}
+		if abort {
+			break
+		}
+	}
+	return messages, nil
+}
@@ -0,0 +1,84 @@
+package slack
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRecorder(t *testing.T) {
+	var buffer bytes.Buffer
+	recorder := NewRecorder(&buffer)
+	now := time.Date(2017, 10, 17, 23, 49, 57, 0, time.UTC)
+	recorder.now = func() time.Time {
+		now = now.Add(time.Second)
+		return now
+	}
+	recorder.recordEvent(map[string]interface{}{"type": "message", "text": "ping"})
+	recorder.recordMessage(NewMessage("pong", "C1").InThread("1.0"))
+	assert(recorder.Close() == nil, t)
+
+	records, err := ReadRecording(&buffer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert(len(records) == 2, t)
+	assert(records[0].Direction == Incoming && records[0].Event["text"] == "ping", t)
+	assert(records[0].Time.Equal(time.Date(2017, 10, 17, 23, 49, 58, 0, time.UTC)), t)
+	assert(records[1].Direction == Outgoing && records[1].Event == nil, t)
+	assert(records[1].Message["text"] == "pong" && records[1].Message["thread_ts"] == "1.0", t)
+
+	if _, err := ReadRecording(strings.NewReader("not json\n")); err == nil {
+		t.Error("Error. Expecting error. Got nil")
+	}
+}
+
+const recording = `{"time":"2017-10-17T23:49:57Z","direction":"in","event":{"type":"message","channel":"C1","user":"U1","text":"one"}}
+{"time":"2017-10-17T23:49:57.02Z","direction":"out","message":{"channel":"C1","text":"echo one","type":"message"}}
+
+{"time":"2017-10-17T23:49:57.03Z","direction":"in","event":{"type":"message","channel":"C1","user":"U1","text":"two"}}
+{"time":"2017-10-17T23:49:57.04Z","direction":"in","event":{"type":"message","channel":"C1","user":"U1","text":"stop"}}
+{"time":"2017-10-17T23:49:57.05Z","direction":"in","event":{"type":"message","channel":"C1","user":"U1","text":"three"}}
+`
+
+func replayBot() *Bot {
+	bot := NewBot("token")
+	bot.OnEvent("message", func(_ *Bot, event map[string]interface{}) (*Message, Status) {
+		text := event["text"].(string)
+		if text == "stop" {
+			return NewMessage("bye", "C1"), Shutdown
+		}
+		return NewMessage("echo "+text, "C1"), Continue
+	})
+	return bot
+}
+
+func TestReplay(t *testing.T) {
+	messages, err := replayBot().Replay(strings.NewReader(recording), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var texts []string
+	for _, message := range messages {
+		texts = append(texts, message.Text())
+	}
+	if strings.Join(texts, ",") != "echo one,echo two,bye" {
+		t.Errorf("Error. Unexpected replies %q.", texts)
+	}
+
+	if _, err := replayBot().Replay(strings.NewReader("{"), false); err == nil {
+		t.Error("Error. Expecting error. Got nil")
+	}
+}
+
+func TestReplay_realTime(t *testing.T) {
+	start := time.Now()
+	if _, err := replayBot().Replay(strings.NewReader(recording), true); err != nil {
+		t.Fatal(err)
+	}
+	// The events up to "stop" were recorded over 40ms.
+	if elapsed := time.Since(start); elapsed < 40*time.Millisecond {
+		t.Errorf("Error. Expected replaying in real time to take 40ms. Took %v.", elapsed)
+	}
+}
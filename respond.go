@@ -1,10 +1,7 @@
 package slack
 
 import (
-	"fmt"
 	"regexp"
-
-	log "github.com/Sirupsen/logrus"
 )
 
 // Respond creates a BotAction which responds to the passed-in event with text.
@@ -19,41 +16,35 @@ func Respond(text string) BotAction {
 
 // RespondRegexp functions exactly as Respond, but instead takes a compiled
 // regexp instead of a string.
-func (bot *Bot) RespondRegexp(re *regexp.Regexp, handler BotAction) {
+func (bot *Bot) RespondRegexp(re *regexp.Regexp, handler BotAction) *Handler {
 	closure := func(self *Bot, event map[string]interface{}) (*Message, Status) {
-		name := regexp.MustCompile(fmt.Sprintf("\\A%s:? ", self.Name))
-		id := regexp.MustCompile(fmt.Sprintf("\\A<@%s>:? ", self.ID))
-		maybeText := event["text"]
-		if maybeText == nil {
+		text, ok := event["text"].(string)
+		if !ok {
 			return nil, Continue
 		}
-		text := maybeText.(string)
-		logger := log.WithFields(log.Fields{
+		fields := Fields{
 			"text":  text,
-			"regex": re,
-		})
-		match := name.FindStringIndex(text)
-		if match == nil {
-			match = id.FindStringIndex(text)
-			if match == nil {
-				logger.Info("NO MENTION. Not invoking handler.")
-				return nil, Continue
-			}
+			"regex": re.String(),
+		}
+		unmatchedText, ok := self.Addressed(event)
+		if !ok {
+			bot.Log().Debug("NO MENTION. Not invoking handler.", fields)
+			return nil, Continue
 		}
-		unmatchedText := text[match[1]:]
 		if re.MatchString(unmatchedText) {
-			logger.Info("MATCH. Invoking handler.")
+			bot.Log().Info("MATCH. Invoking handler.", fields)
 			return handler(self, event)
 		}
-		logger.Info("NO MATCH. Not invoking handler.")
+		bot.Log().Debug("NO MATCH. Not invoking handler.", fields)
 		return nil, Continue
 	}
-	bot.OnEvent("message", closure)
+	return bot.OnEvent("message", closure)
 }
 
 // Respond registers the given handler to fire on "message" events with no
-// subtype, which address the bot directly and match the given text.
-func (bot *Bot) Respond(text string, handler BotAction) {
+// subtype, which address the bot directly and match the given text. See
+// Addressed for what it means for a message to address the bot.
+func (bot *Bot) Respond(text string, handler BotAction) *Handler {
 	re := regexp.MustCompile(text)
-	bot.RespondRegexp(re, handler)
+	return bot.RespondRegexp(re, handler)
 }
@@ -0,0 +1,303 @@
+package slack
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule determines when a scheduled job runs.
+//
+// Next should return the first time after t that the job should run, or the
+// zero Time if the job should not run again. String should return a
+// description of the schedule, which is used to recognize a schedule that was
+// persisted by a ScheduleStore.
+type Schedule interface {
+	Next(t time.Time) time.Time
+	String() string
+}
+
+// Every returns a Schedule which runs a job repeatedly, once every interval.
+func Every(interval time.Duration) Schedule {
+	return intervalSchedule(interval)
+}
+
+type intervalSchedule time.Duration
+
+func (interval intervalSchedule) Next(t time.Time) time.Time {
+	if interval <= 0 {
+		return time.Time{}
+	}
+	return t.Add(time.Duration(interval))
+}
+
+func (interval intervalSchedule) String() string {
+	return fmt.Sprintf("every %s", time.Duration(interval))
+}
+
+// At returns a Schedule which runs a job once, at t.
+func At(t time.Time) Schedule {
+	return onceSchedule(t)
+}
+
+// After returns a Schedule which runs a job once, after delay has elapsed. The
+// delay is counted from when the bot starts, or from when the job is added if
+// the bot is already running.
+func After(delay time.Duration) Schedule {
+	return afterSchedule(delay)
+}
+
+type afterSchedule time.Duration
+
+// Next returns delay after t. A Scheduler only runs a job with this schedule
+// once.
+func (delay afterSchedule) Next(t time.Time) time.Time {
+	return t.Add(time.Duration(delay))
+}
+
+func (delay afterSchedule) String() string {
+	return fmt.Sprintf("after %s", time.Duration(delay))
+}
+
+type onceSchedule time.Time
+
+func (once onceSchedule) Next(t time.Time) time.Time {
+	at := time.Time(once)
+	if t.Before(at) {
+		return at
+	}
+	return time.Time{}
+}
+
+func (once onceSchedule) String() string {
+	return fmt.Sprintf("at %s", time.Time(once).Format(time.RFC3339))
+}
+
+// CronSchedule is a Schedule defined by a cron expression. See Cron.
+type CronSchedule struct {
+	// Location is the time zone the expression is evaluated in. If it is
+	// nil, the expression is evaluated in the location of the time passed
+	// to Next.
+	Location *time.Location
+
+	expression string
+	minute     bits
+	hour       bits
+	dom        bits
+	month      bits
+	dow        bits
+	anyDom     bool
+	anyDow     bool
+}
+
+type bits uint64
+
+func (b bits) has(i int) bool {
+	return b&(1<<uint(i)) != 0
+}
+
+type cronField struct {
+	name     string
+	min, max int
+	names    []string
+}
+
+var (
+	minuteField = cronField{"minute", 0, 59, nil}
+	hourField   = cronField{"hour", 0, 23, nil}
+	domField    = cronField{"day of month", 1, 31, nil}
+	monthField  = cronField{"month", 1, 12, []string{
+		"jan", "feb", "mar", "apr", "may", "jun",
+		"jul", "aug", "sep", "oct", "nov", "dec",
+	}}
+	dowField = cronField{"day of week", 0, 7, []string{
+		"sun", "mon", "tue", "wed", "thu", "fri", "sat",
+	}}
+
+	cronDescriptors = map[string]string{
+		"@yearly":   "0 0 1 1 *",
+		"@annually": "0 0 1 1 *",
+		"@monthly":  "0 0 1 * *",
+		"@weekly":   "0 0 * * 0",
+		"@daily":    "0 0 * * *",
+		"@midnight": "0 0 * * *",
+		"@hourly":   "0 * * * *",
+	}
+)
+
+// Cron parses a standard five-field cron expression ("minute hour
+// day-of-month month day-of-week") into a Schedule. Each field may be "*", a
+// number, a range ("1-5"), a step ("*/15" or "0-30/10"), or a comma-separated
+// list of those. Months and days of the week may also be given by their
+// three-letter English names ("jan", "mon"), and Sunday may be either 0 or 7.
+// The descriptors "@yearly", "@monthly", "@weekly", "@daily" and "@hourly" are
+// also supported.
+//
+// As in cron, if both the day of month and day of week are restricted (that is,
+// neither starts with "*"), a job runs when either of them matches.
+func Cron(expression string) (*CronSchedule, error) {
+	spec := strings.TrimSpace(expression)
+	if descriptor, ok := cronDescriptors[strings.ToLower(spec)]; ok {
+		spec = descriptor
+	}
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, &Error{fmt.Sprintf("cron expression %q must have 5 fields", expression)}
+	}
+	schedule := &CronSchedule{expression: expression}
+	var err error
+	if schedule.minute, err = minuteField.parse(fields[0]); err != nil {
+		return nil, err
+	}
+	if schedule.hour, err = hourField.parse(fields[1]); err != nil {
+		return nil, err
+	}
+	if schedule.dom, err = domField.parse(fields[2]); err != nil {
+		return nil, err
+	}
+	if schedule.month, err = monthField.parse(fields[3]); err != nil {
+		return nil, err
+	}
+	if schedule.dow, err = dowField.parse(fields[4]); err != nil {
+		return nil, err
+	}
+	if schedule.dow.has(7) {
+		schedule.dow |= 1
+	}
+	schedule.anyDom = unrestricted(fields[2])
+	schedule.anyDow = unrestricted(fields[4])
+	return schedule, nil
+}
+
+// unrestricted reports whether a day field leaves the day unrestricted, for
+// cron's rule about restricting both. As in cron, that is any field starting
+// with "*" (so "*/2" is unrestricted), or "?".
+func unrestricted(field string) bool {
+	return strings.HasPrefix(field, "*") || field == "?"
+}
+
+// MustCron functions exactly as Cron, but panics if the expression cannot be
+// parsed.
+func MustCron(expression string) *CronSchedule {
+	schedule, err := Cron(expression)
+	if err != nil {
+		panic(err)
+	}
+	return schedule
+}
+
+// Next returns the first minute after t which matches the expression. It
+// returns the zero Time if no such minute exists within five years.
+func (schedule *CronSchedule) Next(t time.Time) time.Time {
+	loc := schedule.Location
+	if loc == nil {
+		loc = t.Location()
+	}
+	t = t.In(loc)
+	t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute()+1, 0, 0, loc)
+	limit := t.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		switch {
+		case !schedule.month.has(int(t.Month())):
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+		case !schedule.dayMatches(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+		case !schedule.hour.has(t.Hour()):
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+		case !schedule.minute.has(t.Minute()):
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute()+1, 0, 0, loc)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// String returns "cron " followed by the original expression.
+func (schedule *CronSchedule) String() string {
+	return "cron " + schedule.expression
+}
+
+func (schedule *CronSchedule) dayMatches(t time.Time) bool {
+	dom := schedule.dom.has(t.Day())
+	dow := schedule.dow.has(int(t.Weekday()))
+	if schedule.anyDom || schedule.anyDow {
+		return dom && dow
+	}
+	return dom || dow
+}
+
+func (field cronField) parse(spec string) (bits, error) {
+	var result bits
+	for _, part := range strings.Split(spec, ",") {
+		b, err := field.parsePart(part)
+		if err != nil {
+			return 0, err
+		}
+		result |= b
+	}
+	return result, nil
+}
+
+func (field cronField) parsePart(part string) (bits, error) {
+	rangeSpec, step := part, 1
+	if i := strings.IndexByte(part, '/'); i >= 0 {
+		rangeSpec = part[:i]
+		var err error
+		step, err = strconv.Atoi(part[i+1:])
+		if err != nil || step <= 0 {
+			return 0, field.errorf("invalid step in %q", part)
+		}
+	}
+
+	var low, high int
+	switch {
+	case rangeSpec == "*" || rangeSpec == "?":
+		low, high = field.min, field.max
+	case strings.IndexByte(rangeSpec, '-') > 0:
+		i := strings.IndexByte(rangeSpec, '-')
+		var err error
+		if low, err = field.value(rangeSpec[:i]); err != nil {
+			return 0, err
+		}
+		if high, err = field.value(rangeSpec[i+1:]); err != nil {
+			return 0, err
+		}
+	default:
+		var err error
+		if low, err = field.value(rangeSpec); err != nil {
+			return 0, err
+		}
+		high = low
+		if step != 1 {
+			high = field.max
+		}
+	}
+	if low > high {
+		return 0, field.errorf("invalid range %q", part)
+	}
+
+	var result bits
+	for i := low; i <= high; i += step {
+		result |= 1 << uint(i)
+	}
+	return result, nil
+}
+
+func (field cronField) value(s string) (int, error) {
+	for i, name := range field.names {
+		if strings.EqualFold(s, name) {
+			return i + field.min, nil
+		}
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n < field.min || n > field.max {
+		return 0, field.errorf("invalid value %q", s)
+	}
+	return n, nil
+}
+
+func (field cronField) errorf(format string, args ...interface{}) error {
+	return &Error{fmt.Sprintf("cron %s: %s", field.name, fmt.Sprintf(format, args...))}
+}
@@ -0,0 +1,72 @@
+package slack
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCron(t *testing.T) {
+	// 2016-02-26 is a Friday
+	start := time.Date(2016, 2, 26, 10, 30, 15, 0, time.UTC)
+	var tests = []struct {
+		expression string
+		expected   time.Time
+	}{
+		{"* * * * *", time.Date(2016, 2, 26, 10, 31, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2016, 2, 26, 10, 45, 0, 0, time.UTC)},
+		{"0 9 * * *", time.Date(2016, 2, 27, 9, 0, 0, 0, time.UTC)},
+		{"0 9 * * mon-fri", time.Date(2016, 2, 29, 9, 0, 0, 0, time.UTC)},
+		{"0 9 * * 1-5", time.Date(2016, 2, 29, 9, 0, 0, 0, time.UTC)},
+		{"0 0 29 feb *", time.Date(2016, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"0 0 1 * 7", time.Date(2016, 2, 28, 0, 0, 0, 0, time.UTC)},
+		{"0 0 */2 * mon", time.Date(2016, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"30 10,12 * * *", time.Date(2016, 2, 26, 12, 30, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2016, 2, 26, 11, 0, 0, 0, time.UTC)},
+		{"@yearly", time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, test := range tests {
+		schedule, err := Cron(test.expression)
+		if err != nil {
+			t.Errorf("Error. Was not expecting an error for %q, but found %v",
+				test.expression, err)
+			continue
+		}
+		actual := schedule.Next(start)
+		if !actual.Equal(test.expected) {
+			t.Errorf("Error. Expected %s for %q. Got %s.",
+				test.expected, test.expression, actual)
+		}
+	}
+}
+
+func TestCronInvalid(t *testing.T) {
+	for _, expression := range []string{
+		"", "* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *",
+		"* * * 13 *", "* * * * 8", "*/0 * * * *", "5-1 * * * *", "x * * * *",
+	} {
+		if _, err := Cron(expression); err == nil {
+			t.Errorf("Error. Expected an error for %q.", expression)
+		}
+	}
+}
+
+func TestCronNever(t *testing.T) {
+	schedule := MustCron("0 0 31 feb *")
+	assert(schedule.Next(time.Now()).IsZero(), t)
+}
+
+func TestEveryAndAt(t *testing.T) {
+	now := time.Date(2016, 2, 26, 10, 30, 0, 0, time.UTC)
+	assert(Every(time.Hour).Next(now).Equal(now.Add(time.Hour)), t)
+	assert(Every(0).Next(now).IsZero(), t)
+
+	at := At(now.Add(time.Minute))
+	assert(at.Next(now).Equal(now.Add(time.Minute)), t)
+	assert(at.Next(now.Add(time.Minute)).IsZero(), t)
+	assert(at.String() == "at 2016-02-26T10:31:00Z", t)
+
+	after := After(time.Minute)
+	assert(after.Next(now).Equal(now.Add(time.Minute)), t)
+	assert(after.String() == "after 1m0s" && After(time.Minute).String() == after.String(), t)
+}
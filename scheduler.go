@@ -0,0 +1,313 @@
+package slack
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+)
+
+// Job is a scheduled task. It receives a context, which is cancelled when the
+// bot shuts down, and a reference to the bot, and may return a Message (which
+// can be nil) to send when it completes. Jobs may also send any number of
+// messages themselves; since Bot.Send blocks while the bot is disconnected,
+// they should use Bot.SendAndWait with ctx, so that they stop when it is
+// cancelled.
+type Job func(ctx context.Context, bot *Bot) *Message
+
+// ScheduleState is the persisted state of a single scheduled job.
+type ScheduleState struct {
+	Schedule string    `json:"schedule"`
+	LastRun  time.Time `json:"last_run"`
+	NextRun  time.Time `json:"next_run"`
+}
+
+// ScheduleStore persists the state of scheduled jobs, so that schedules pick
+// up where they left off when the bot restarts. States are keyed by job name.
+type ScheduleStore interface {
+	LoadSchedules() (map[string]ScheduleState, error)
+	SaveSchedules(map[string]ScheduleState) error
+}
+
+// Scheduler runs jobs on a Schedule while the bot is running. Every bot has a
+// Scheduler, which starts when the bot starts and stops when the bot shuts
+// down.
+//
+// A job's first run is scheduled when the bot starts, or when the job is added
+// if the bot is already running, so a job added long before the bot starts
+// does not run as soon as it starts.
+//
+// If Store is set before the bot starts, the state of each job is saved to it
+// after every run. When a job is added with the same name and schedule as a
+// saved job, it resumes from the saved state: a job that should have run
+// while the bot was down runs immediately, and a one-time job that already
+// ran does not run again.
+type Scheduler struct {
+	Store ScheduleStore
+
+	bot     *Bot
+	mu      sync.Mutex
+	jobs    map[string]*scheduledJob
+	saved   map[string]ScheduleState
+	running bool
+	ctx     context.Context
+	stop    context.CancelFunc
+	wg      sync.WaitGroup
+}
+
+type scheduledJob struct {
+	name     string
+	schedule Schedule
+	job      Job
+	state    ScheduleState
+	cancel   chan struct{}
+}
+
+func newScheduler(bot *Bot) *Scheduler {
+	return &Scheduler{
+		bot:  bot,
+		jobs: make(map[string]*scheduledJob),
+	}
+}
+
+// Add schedules job to run on schedule under the given name. If a job with the
+// same name is already scheduled, it is replaced.
+func (scheduler *Scheduler) Add(name string, schedule Schedule, job Job) {
+	scheduled := &scheduledJob{
+		name:     name,
+		schedule: schedule,
+		job:      job,
+		state:    ScheduleState{Schedule: schedule.String()},
+		cancel:   make(chan struct{}),
+	}
+	scheduled.state.NextRun = scheduled.next(time.Now())
+
+	scheduler.mu.Lock()
+	defer scheduler.mu.Unlock()
+	if old, ok := scheduler.jobs[name]; ok {
+		close(old.cancel)
+	}
+	scheduler.jobs[name] = scheduled
+	if scheduler.running {
+		scheduler.resume(scheduled, time.Now())
+		scheduler.launch(scheduled)
+	}
+}
+
+// Remove unschedules the job with the given name. It returns false if there
+// was no such job.
+func (scheduler *Scheduler) Remove(name string) bool {
+	scheduler.mu.Lock()
+	defer scheduler.mu.Unlock()
+	scheduled, ok := scheduler.jobs[name]
+	if !ok {
+		return false
+	}
+	close(scheduled.cancel)
+	delete(scheduler.jobs, name)
+	if scheduler.saved != nil {
+		delete(scheduler.saved, name)
+		scheduler.save()
+	}
+	return true
+}
+
+// Next returns the next time the job with the given name will run. It returns
+// false if there is no such job, or if the job will not run again.
+func (scheduler *Scheduler) Next(name string) (time.Time, bool) {
+	scheduler.mu.Lock()
+	defer scheduler.mu.Unlock()
+	scheduled, ok := scheduler.jobs[name]
+	if !ok || scheduled.state.NextRun.IsZero() {
+		return time.Time{}, false
+	}
+	return scheduled.state.NextRun, true
+}
+
+// Jobs returns the names of all scheduled jobs.
+func (scheduler *Scheduler) Jobs() []string {
+	scheduler.mu.Lock()
+	defer scheduler.mu.Unlock()
+	names := make([]string, 0, len(scheduler.jobs))
+	for name := range scheduler.jobs {
+		names = append(names, name)
+	}
+	return names
+}
+
+func (scheduler *Scheduler) start() {
+	scheduler.mu.Lock()
+	defer scheduler.mu.Unlock()
+	if scheduler.running {
+		return
+	}
+	scheduler.running = true
+	scheduler.ctx, scheduler.stop = context.WithCancel(context.Background())
+	if scheduler.Store != nil {
+		saved, err := scheduler.Store.LoadSchedules()
+		if err != nil {
+			scheduler.bot.Log().Error("Failed to load schedules.", Fields{
+				"error": err,
+			})
+		}
+		if saved == nil {
+			saved = make(map[string]ScheduleState)
+		}
+		scheduler.saved = saved
+	}
+	now := time.Now()
+	for _, scheduled := range scheduler.jobs {
+		scheduler.resume(scheduled, now)
+		scheduler.launch(scheduled)
+	}
+}
+
+// shutdown stops all jobs, cancelling the context of any jobs that are running,
+// and waits for them to finish.
+func (scheduler *Scheduler) shutdown() {
+	scheduler.mu.Lock()
+	if !scheduler.running {
+		scheduler.mu.Unlock()
+		return
+	}
+	scheduler.running = false
+	scheduler.stop()
+	scheduler.mu.Unlock()
+	scheduler.wg.Wait()
+}
+
+// resume restores the saved state of scheduled, if its schedule is unchanged.
+// Otherwise, it schedules the next run after now. It must be called with mu
+// held.
+func (scheduler *Scheduler) resume(scheduled *scheduledJob, now time.Time) {
+	state, ok := scheduler.saved[scheduled.name]
+	if ok && state.Schedule == scheduled.state.Schedule {
+		scheduled.state = state
+		return
+	}
+	scheduled.state.NextRun = scheduled.next(now)
+}
+
+// launch starts the goroutine which runs scheduled. It must be called with mu
+// held.
+func (scheduler *Scheduler) launch(scheduled *scheduledJob) {
+	scheduler.wg.Add(1)
+	go scheduler.run(scheduler.ctx, scheduled)
+}
+
+func (scheduler *Scheduler) run(ctx context.Context, scheduled *scheduledJob) {
+	defer scheduler.wg.Done()
+	for {
+		scheduler.mu.Lock()
+		next := scheduled.state.NextRun
+		scheduler.mu.Unlock()
+		if next.IsZero() {
+			return
+		}
+
+		timer := time.NewTimer(next.Sub(time.Now()))
+		select {
+		case <-timer.C:
+		case <-scheduled.cancel:
+			timer.Stop()
+			return
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		}
+
+		scheduler.invoke(ctx, scheduled)
+		now := time.Now()
+		scheduler.mu.Lock()
+		scheduled.state.LastRun = now
+		scheduled.state.NextRun = scheduled.next(now)
+		if scheduler.saved != nil && scheduler.jobs[scheduled.name] == scheduled {
+			scheduler.saved[scheduled.name] = scheduled.state
+			scheduler.save()
+		}
+		scheduler.mu.Unlock()
+	}
+}
+
+// next returns the first time after t that scheduled should run, or the zero
+// Time if it should not run again. Jobs scheduled with After only run once.
+func (scheduled *scheduledJob) next(t time.Time) time.Time {
+	if _, once := scheduled.schedule.(afterSchedule); once && !scheduled.state.LastRun.IsZero() {
+		return time.Time{}
+	}
+	return scheduled.schedule.Next(t)
+}
+
+// invoke runs scheduled, and sends the message it returns unless the bot shuts
+// down first.
+func (scheduler *Scheduler) invoke(ctx context.Context, scheduled *scheduledJob) {
+	defer func() {
+		if r := recover(); r != nil {
+			scheduler.bot.Log().Error("Scheduled job panicked.", Fields{
+				"job":   scheduled.name,
+				"panic": r,
+			})
+		}
+	}()
+	message := scheduled.job(ctx, scheduler.bot)
+	if message == nil {
+		return
+	}
+	select {
+	case scheduler.bot.outgoing <- message:
+	case <-ctx.Done():
+		scheduler.bot.Log().Warn("Dropped a scheduled job's message at shutdown.", Fields{
+			"job": scheduled.name,
+		})
+	}
+}
+
+// save writes the saved states to the store. It must be called with mu held.
+func (scheduler *Scheduler) save() {
+	states := make(map[string]ScheduleState, len(scheduler.saved))
+	for name, state := range scheduler.saved {
+		states[name] = state
+	}
+	if err := scheduler.Store.SaveSchedules(states); err != nil {
+		scheduler.bot.Log().Error("Failed to save schedules.", Fields{
+			"error": err,
+		})
+	}
+}
+
+// FileScheduleStore is a ScheduleStore which keeps schedules in a JSON file.
+type FileScheduleStore struct {
+	Path string
+}
+
+// LoadSchedules reads the schedules from the file. A missing file is treated
+// as having no schedules.
+func (store *FileScheduleStore) LoadSchedules() (map[string]ScheduleState, error) {
+	data, err := ioutil.ReadFile(store.Path)
+	if os.IsNotExist(err) {
+		return make(map[string]ScheduleState), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	states := make(map[string]ScheduleState)
+	if err := json.Unmarshal(data, &states); err != nil {
+		return nil, err
+	}
+	return states, nil
+}
+
+// SaveSchedules writes the schedules to the file, replacing its contents.
+func (store *FileScheduleStore) SaveSchedules(states map[string]ScheduleState) error {
+	data, err := json.MarshalIndent(states, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := store.Path + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, store.Path)
+}
@@ -0,0 +1,148 @@
+package slack
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSchedulerRunsJobs(t *testing.T) {
+	bot := NewBot("token")
+	message := NewMessage("standup!", "general")
+	runs := make(chan struct{}, 10)
+	bot.Scheduler.Add("standup", Every(time.Millisecond), func(context.Context, *Bot) *Message {
+		runs <- struct{}{}
+		return message
+	})
+	bot.Scheduler.start()
+	<-runs
+	<-runs
+	bot.Scheduler.shutdown()
+	assert(<-bot.outgoing == message, t)
+
+	// jobs added while running start immediately
+	bot.Scheduler.start()
+	bot.Scheduler.Add("once", After(0), func(context.Context, *Bot) *Message {
+		runs <- struct{}{}
+		return nil
+	})
+	bot.Scheduler.Remove("standup")
+	bot.Scheduler.shutdown()
+	_, ok := bot.Scheduler.Next("standup")
+	assert(!ok, t)
+	assert(len(bot.Scheduler.Jobs()) == 1, t)
+}
+
+func TestSchedulerSchedulesOnStart(t *testing.T) {
+	bot := NewBot("token")
+	runs := make(chan struct{}, 10)
+	bot.Scheduler.Add("once", After(20*time.Millisecond), func(context.Context, *Bot) *Message {
+		runs <- struct{}{}
+		return nil
+	})
+	time.Sleep(30 * time.Millisecond)
+	started := time.Now()
+	bot.Scheduler.start()
+	defer bot.Scheduler.shutdown()
+	next, ok := bot.Scheduler.Next("once")
+	assert(ok && !next.Before(started.Add(20*time.Millisecond)), t)
+
+	<-runs
+	for {
+		if _, ok := bot.Scheduler.Next("once"); !ok {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	assert(len(runs) == 0, t)
+}
+
+func TestSchedulerShutdownCancelsJobs(t *testing.T) {
+	bot := NewBot("token")
+	for len(bot.outgoing) < cap(bot.outgoing) {
+		bot.outgoing <- NewMessage("queued", "general")
+	}
+	running := make(chan struct{})
+	bot.Scheduler.Add("blocked", After(0), func(ctx context.Context, _ *Bot) *Message {
+		close(running)
+		<-ctx.Done()
+		return NewMessage("too late", "general")
+	})
+	bot.Scheduler.start()
+	<-running
+
+	stopped := make(chan struct{})
+	go func() {
+		bot.Scheduler.shutdown()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatal("Error. Expected shutdown to cancel the job and not wait to send its message.")
+	}
+}
+
+func TestSchedulerRecoversPanics(t *testing.T) {
+	bot := NewBot("token")
+	runs := make(chan struct{}, 10)
+	bot.Scheduler.Add("panics", Every(time.Millisecond), func(context.Context, *Bot) *Message {
+		runs <- struct{}{}
+		panic("oops")
+	})
+	bot.Scheduler.start()
+	<-runs
+	<-runs
+	bot.Scheduler.shutdown()
+}
+
+func TestSchedulerStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "schedules")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	store := &FileScheduleStore{filepath.Join(dir, "schedules.json")}
+
+	states, err := store.LoadSchedules()
+	assert(err == nil && len(states) == 0, t)
+
+	// a one-time job which already ran does not run again
+	at := At(time.Now().Add(time.Hour))
+	store.SaveSchedules(map[string]ScheduleState{
+		"reminder": {Schedule: at.String(), LastRun: time.Now()},
+		"missed":   {Schedule: Every(time.Hour).String(), NextRun: time.Now().Add(-time.Minute)},
+		"delayed":  {Schedule: After(time.Minute).String(), LastRun: time.Now()},
+	})
+
+	bot := NewBot("token")
+	bot.Scheduler.Store = store
+	runs := make(chan string, 10)
+	bot.Scheduler.Add("reminder", at, func(context.Context, *Bot) *Message {
+		runs <- "reminder"
+		return nil
+	})
+	bot.Scheduler.Add("missed", Every(time.Hour), func(context.Context, *Bot) *Message {
+		runs <- "missed"
+		return nil
+	})
+	bot.Scheduler.Add("delayed", After(time.Minute), func(context.Context, *Bot) *Message {
+		runs <- "delayed"
+		return nil
+	})
+	bot.Scheduler.start()
+	assert(<-runs == "missed", t)
+	bot.Scheduler.shutdown()
+	_, ok := bot.Scheduler.Next("reminder")
+	assert(!ok, t)
+	_, ok = bot.Scheduler.Next("delayed")
+	assert(!ok, t)
+
+	states, err = store.LoadSchedules()
+	assert(err == nil, t)
+	next := states["missed"].NextRun
+	assert(next.After(time.Now().Add(59*time.Minute)), t)
+}
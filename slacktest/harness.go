@@ -0,0 +1,268 @@
+package slacktest
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/ajm188/slack"
+)
+
+// Harness is a lightweight way to unit test a bot's handlers. It feeds
+// simulated events straight to the handlers with Bot.Dispatch, without a
+// websocket, and checks what the handlers returned:
+//
+//	h := slacktest.NewHarness(t)
+//	defer h.Close()
+//	h.AddUser("U1", "alice").AddChannel("C1", "ops")
+//	h.Bot.Respond("^deploy (\\w+)$", deployHandler)
+//
+//	h.User("alice").In("#ops").Says("bot deploy prod").
+//		ExpectReply("<@U1>: deploying prod").
+//		ExpectReaction("rocket").
+//		ExpectStatus(slack.Continue)
+//
+// The harness's Bot is named "bot" and calls the Web API on Server, so Web
+// API calls made by handlers (like the reactions added by slack.React) are
+// recorded rather than sent to Slack.
+type Harness struct {
+	Bot    *slack.Bot
+	Server *Server
+
+	t testing.TB
+}
+
+// NewHarness constructs a Harness which reports failed expectations to t. It
+// should be closed with Close once the test is done with it.
+func NewHarness(t testing.TB) *Harness {
+	server := NewServer()
+	bot := slack.NewBot("token")
+	bot.APIURL = server.URL
+	bot.ID = server.BotID
+	bot.Name = server.BotName
+	return &Harness{Bot: bot, Server: server, t: t}
+}
+
+// Close shuts down the harness's Server.
+func (h *Harness) Close() {
+	h.Server.Close()
+}
+
+// AddUser adds a user to the bot's Users (and the Server's team), and returns
+// the harness so that calls can be chained.
+func (h *Harness) AddUser(id, nick string) *Harness {
+	user := &slack.User{ID: id, Nick: nick}
+	h.Bot.Users[id] = user
+	h.Bot.Users[nick] = user
+	h.Server.AddUser(User{ID: id, Name: nick})
+	return h
+}
+
+// AddChannel adds a channel to the bot's Channels (and the Server's team), and
+// returns the harness so that calls can be chained.
+func (h *Harness) AddChannel(id, name string) *Harness {
+	h.Bot.Channels[id] = name
+	h.Bot.Channels[name] = id
+	h.Server.AddChannel(Channel{ID: id, Name: name})
+	return h
+}
+
+// User returns a Sender which sends messages as the user with the given nick
+// or ID. Unless it is given a channel with In, the Sender sends messages in a
+// direct message with the bot.
+func (h *Harness) User(user string) *Sender {
+	id := user
+	if u, ok := h.Bot.Users[user]; ok {
+		id = u.ID
+	}
+	return &Sender{h: h, user: id, channel: "D" + id}
+}
+
+// Send dispatches event to the bot's handlers, and returns the Result. Use it
+// for events other than messages, like "reaction_added".
+func (h *Harness) Send(event map[string]interface{}) *Result {
+	calls := len(h.Server.Calls(""))
+	responses := h.Bot.Dispatch(event)
+	return &Result{
+		Event:     event,
+		Responses: responses,
+		Calls:     h.Server.Calls("")[calls:],
+		t:         h.t,
+	}
+}
+
+// Sender sends messages to the bot as a user.
+type Sender struct {
+	h       *Harness
+	user    string
+	channel string
+	thread  string
+}
+
+// In sets the channel messages are sent in, given by its ID or its name (with
+// or without a leading "#"), and returns the Sender. Channel names are always
+// lower case, and IDs are always upper case, which tells them apart.
+func (s *Sender) In(channel string) *Sender {
+	name := strings.TrimPrefix(channel, "#")
+	s.channel = name
+	if name != channel || strings.ToLower(name) == name {
+		if id, ok := s.h.Bot.Channels[name]; ok {
+			s.channel = id
+		}
+	}
+	return s
+}
+
+// InThread makes messages replies in the thread whose parent message has the
+// timestamp ts, and returns the Sender.
+func (s *Sender) InThread(ts string) *Sender {
+	s.thread = ts
+	return s
+}
+
+// Says sends a message with text to the bot, and returns the Result.
+func (s *Sender) Says(text string) *Result {
+	event := map[string]interface{}{
+		"type":    "message",
+		"channel": s.channel,
+		"user":    s.user,
+		"text":    text,
+		"ts":      s.h.Server.nextTS(),
+	}
+	if s.thread != "" {
+		event["thread_ts"] = s.thread
+	}
+	return s.h.Send(event)
+}
+
+// Reacts sends a "reaction_added" event, for the user reacting with emoji to
+// the message with the timestamp ts, and returns the Result.
+func (s *Sender) Reacts(emoji, ts string) *Result {
+	return s.h.Send(map[string]interface{}{
+		"type":     "reaction_added",
+		"user":     s.user,
+		"reaction": strings.Trim(emoji, ":"),
+		"item": map[string]interface{}{
+			"type":    "message",
+			"channel": s.channel,
+			"ts":      ts,
+		},
+		"event_ts": s.h.Server.nextTS(),
+	})
+}
+
+// Result is what the bot's handlers did with an event: the Responses they
+// returned, and the Web API Calls they made.
+type Result struct {
+	Event     map[string]interface{}
+	Responses []slack.Response
+	Calls     []Call
+
+	t testing.TB
+}
+
+// Replies returns the messages the handlers returned.
+func (r *Result) Replies() []*slack.Message {
+	var replies []*slack.Message
+	for _, response := range r.Responses {
+		if response.Message != nil {
+			replies = append(replies, response.Message)
+		}
+	}
+	return replies
+}
+
+// Reactions returns the names of the reactions the handlers added.
+func (r *Result) Reactions() []string {
+	var reactions []string
+	for _, call := range r.Calls {
+		if call.Method == "reactions.add" {
+			reactions = append(reactions, call.Params.Get("name"))
+		}
+	}
+	return reactions
+}
+
+// Status returns the overall Status of the handlers, as the bot's main loop
+// would act on it: ShutdownNow if any handler returned it, otherwise Shutdown
+// if any handler returned it, and otherwise Continue.
+func (r *Result) Status() slack.Status {
+	status := slack.Continue
+	for _, response := range r.Responses {
+		switch response.Status {
+		case slack.ShutdownNow:
+			return slack.ShutdownNow
+		case slack.Shutdown:
+			status = slack.Shutdown
+		}
+	}
+	return status
+}
+
+// ExpectReply reports an error unless one of the replies has exactly the
+// given text. It returns the Result so that expectations can be chained.
+func (r *Result) ExpectReply(text string) *Result {
+	r.t.Helper()
+	for _, reply := range r.Replies() {
+		if reply.Text() == text {
+			return r
+		}
+	}
+	r.t.Errorf("Error. Expected a reply %q. Got %q.", text, r.texts())
+	return r
+}
+
+// ExpectReplyMatching reports an error unless the text of one of the replies
+// matches pattern.
+func (r *Result) ExpectReplyMatching(pattern string) *Result {
+	r.t.Helper()
+	re := regexp.MustCompile(pattern)
+	for _, reply := range r.Replies() {
+		if re.MatchString(reply.Text()) {
+			return r
+		}
+	}
+	r.t.Errorf("Error. Expected a reply matching %q. Got %q.", pattern, r.texts())
+	return r
+}
+
+// ExpectNoReply reports an error if the handlers returned any messages.
+func (r *Result) ExpectNoReply() *Result {
+	r.t.Helper()
+	if texts := r.texts(); len(texts) > 0 {
+		r.t.Errorf("Error. Expected no replies. Got %q.", texts)
+	}
+	return r
+}
+
+// ExpectReaction reports an error unless the handlers added a reaction with
+// the given name to the message.
+func (r *Result) ExpectReaction(emoji string) *Result {
+	r.t.Helper()
+	emoji = strings.Trim(emoji, ":")
+	for _, reaction := range r.Reactions() {
+		if reaction == emoji {
+			return r
+		}
+	}
+	r.t.Errorf("Error. Expected a reaction %q. Got %q.", emoji, r.Reactions())
+	return r
+}
+
+// ExpectStatus reports an error unless the overall Status of the handlers is
+// status.
+func (r *Result) ExpectStatus(status slack.Status) *Result {
+	r.t.Helper()
+	if actual := r.Status(); actual != status {
+		r.t.Errorf("Error. Expected status %v. Got %v.", status, actual)
+	}
+	return r
+}
+
+func (r *Result) texts() []string {
+	var texts []string
+	for _, reply := range r.Replies() {
+		texts = append(texts, reply.Text())
+	}
+	return texts
+}
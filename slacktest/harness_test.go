@@ -0,0 +1,84 @@
+package slacktest
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/ajm188/slack"
+)
+
+// recorder records the failures reported to it, instead of failing the test.
+type recorder struct {
+	testing.TB
+	failures []string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...interface{}) {
+	r.failures = append(r.failures, fmt.Sprintf(format, args...))
+}
+
+func TestHarness(t *testing.T) {
+	h := NewHarness(t)
+	defer h.Close()
+	h.AddUser("U1", "alice").AddChannel("C1", "ops")
+	h.Bot.Respond("^deploy (\\w+)$", func(b *slack.Bot, event map[string]interface{}) (*slack.Message, slack.Status) {
+		slack.React("rocket")(b, event)
+		return slack.Respond("deploying")(b, event)
+	})
+	h.Bot.Respond("^die$", func(b *slack.Bot, event map[string]interface{}) (*slack.Message, slack.Status) {
+		return nil, slack.Shutdown
+	})
+
+	result := h.User("alice").In("#ops").Says("bot deploy prod").
+		ExpectReply("<@U1>: deploying").
+		ExpectReplyMatching("deploy").
+		ExpectReaction(":rocket:").
+		ExpectStatus(slack.Continue)
+	if result.Event["channel"] != "C1" || result.Event["user"] != "U1" {
+		t.Errorf("Error. Expected the message to be from U1 in C1. Got %v.", result.Event)
+	}
+	if replies := result.Replies(); len(replies) != 1 || replies[0].Channel() != "C1" {
+		t.Errorf("Error. Expected a reply in C1. Got %v.", replies)
+	}
+
+	h.User("U1").Says("deploy prod").ExpectReply("<@U1>: deploying")
+	h.User("alice").In("ops").Says("deploy prod").ExpectNoReply()
+	h.User("alice").Says("die").ExpectNoReply().ExpectStatus(slack.Shutdown)
+
+	thread := h.User("alice").In("C1").InThread("1.0").Says("hi").Event
+	if thread["channel"] != "C1" || thread["thread_ts"] != "1.0" {
+		t.Errorf("Error. Expected a reply in the thread 1.0 in C1. Got %v.", thread)
+	}
+}
+
+func TestHarness_failures(t *testing.T) {
+	r := &recorder{TB: t}
+	h := NewHarness(r)
+	defer h.Close()
+	h.Bot.Respond("^hi$", slack.Respond("hello"))
+
+	h.User("U1").Says("hi").
+		ExpectReply("goodbye").
+		ExpectReplyMatching("^bye").
+		ExpectNoReply().
+		ExpectReaction("wave").
+		ExpectStatus(slack.Shutdown)
+	if len(r.failures) != 5 {
+		t.Errorf("Error. Expected 5 failures. Got %q.", r.failures)
+	}
+}
+
+func TestHarness_Reacts(t *testing.T) {
+	h := NewHarness(t)
+	defer h.Close()
+	h.AddChannel("C1", "general")
+	h.Bot.OnReactionAdded("eyes", "#general", func(b *slack.Bot, event map[string]interface{}) (*slack.Message, slack.Status) {
+		item := event["item"].(map[string]interface{})
+		return slack.NewMessage("looking", item["channel"].(string)), slack.Continue
+	})
+
+	h.User("U1").In("#general").Reacts(":eyes:", "1.0").ExpectReply("looking")
+	h.User("U1").In("#general").Reacts("tada", "1.0").ExpectNoReply()
+}
@@ -0,0 +1,631 @@
+/*
+Package slacktest provides a fake Slack server for testing bots end to end,
+without connecting to Slack, and a Harness for testing handlers.
+
+A Server speaks enough of the Slack Web API for a bot to start (rtm.start and
+rtm.connect), send messages (chat.*), react (reactions.*), open direct
+messages (im.open and conversations.open) and look up users (users.*), and
+serves the RTM websocket that the bot connects to, answering its pings. Tests inject events with
+SendEvent or SendMessage, and assert on everything the bot sent with Messages
+and Calls:
+
+	server := slacktest.NewServer()
+	defer server.Close()
+	server.AddUser(slacktest.User{ID: "U1", Name: "alice"})
+	server.AddChannel(slacktest.Channel{ID: "C1", Name: "general"})
+
+	bot := slack.NewBot("token")
+	bot.APIURL = server.URL
+	bot.Respond("^ping$", slack.Respond("pong"))
+	go bot.Start()
+
+	server.SendMessage("C1", "U1", "<@"+server.BotID+"> ping")
+	messages, err := server.WaitForMessages(1, time.Second)
+	// messages[0].Text == "<@U1>: pong"
+
+Other Web API methods can be faked with Handle.
+
+For unit testing handlers, a Harness is lighter weight: it dispatches simulated
+events straight to the bot's handlers, and makes assertions on what they
+returned.
+*/
+package slacktest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// DefaultTimeout is how long SendEvent waits for the bot to connect.
+const DefaultTimeout = 5 * time.Second
+
+// User is a user of the fake Slack team.
+type User struct {
+	ID        string
+	Name      string
+	FirstName string
+	LastName  string
+}
+
+func (user User) toJSON() map[string]interface{} {
+	return map[string]interface{}{
+		"id":   user.ID,
+		"name": user.Name,
+		"profile": map[string]interface{}{
+			"first_name": user.FirstName,
+			"last_name":  user.LastName,
+		},
+	}
+}
+
+// Channel is a channel in the fake Slack team.
+type Channel struct {
+	ID   string
+	Name string
+}
+
+// Call is a call the bot made to the Web API.
+type Call struct {
+	Method string
+	Params url.Values
+}
+
+// Message is a message the bot sent, either over the RTM websocket or with the
+// chat.postMessage Web API method. ID is only set for messages sent over the
+// websocket, and Blocks (the JSON-encoded blocks of the message) only for
+// messages sent with chat.postMessage. TS is the timestamp the server gave
+// the message.
+//
+// Like Slack, the server rejects messages sent over the websocket without
+// text; those are not recorded.
+type Message struct {
+	ID       string
+	TS       string
+	Channel  string
+	Text     string
+	ThreadTS string
+	Blocks   string
+}
+
+// Handler fakes a Web API method. It receives the parameters of a call to the
+// method, and returns the response payload.
+type Handler func(params url.Values) map[string]interface{}
+
+// Server is a fake Slack Web API and RTM server, listening on a local port.
+// Construct one with NewServer, and point a bot at it by setting the bot's
+// APIURL to URL.
+//
+// BotID and BotName are the identity of the bot, as returned by rtm.start and
+// rtm.connect. If Token is set, Web API calls with a different token fail
+// with "invalid_auth".
+type Server struct {
+	URL     string
+	Token   string
+	BotID   string
+	BotName string
+
+	server   *httptest.Server
+	upgrader websocket.Upgrader
+
+	mu       sync.Mutex
+	writeMu  sync.Mutex
+	users    []User
+	channels []Channel
+	handlers map[string]Handler
+	calls    []Call
+	messages []Message
+	reacted  map[string][]reaction
+	conn     *websocket.Conn
+	lastTS   int64
+	changed  chan struct{}
+}
+
+// NewServer starts a Server. It should be closed with Close once the test is
+// done with it.
+func NewServer() *Server {
+	s := &Server{
+		BotID:    "UBOT",
+		BotName:  "bot",
+		handlers: make(map[string]Handler),
+		reacted:  make(map[string][]reaction),
+		lastTS:   time.Now().Unix() * 1e6,
+		changed:  make(chan struct{}),
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/", s.serveAPI)
+	mux.HandleFunc("/rtm", s.serveRTM)
+	s.server = httptest.NewServer(mux)
+	s.URL = s.server.URL + "/api/"
+	return s
+}
+
+// AddReaction records that user reacted with emoji to the message with the
+// timestamp ts in channel, as reactions.get reports it. It does not send an
+// event; use SendEvent for that.
+func (s *Server) AddReaction(channel, ts, user, emoji string) {
+	s.react(channel, ts, user, emoji, true)
+}
+
+// reaction is an emoji reaction to a message, and who reacted with it.
+type reaction struct {
+	name  string
+	users []string
+}
+
+// react adds or removes user's reaction with emoji to the message with the
+// timestamp ts in channel. It returns false if the user had already reacted
+// with the emoji, when adding, or had not, when removing.
+func (s *Server) react(channel, ts, user, emoji string, add bool) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := channel + "/" + ts
+	reactions := s.reacted[key]
+	for i, r := range reactions {
+		if r.name != emoji {
+			continue
+		}
+		for j, u := range r.users {
+			if u != user {
+				continue
+			}
+			if add {
+				return false
+			}
+			r.users = append(r.users[:j:j], r.users[j+1:]...)
+			if len(r.users) == 0 {
+				reactions = append(reactions[:i:i], reactions[i+1:]...)
+			} else {
+				reactions[i] = r
+			}
+			s.reacted[key] = reactions
+			return true
+		}
+		if !add {
+			return false
+		}
+		reactions[i].users = append(r.users, user)
+		return true
+	}
+	if !add {
+		return false
+	}
+	s.reacted[key] = append(reactions, reaction{emoji, []string{user}})
+	return true
+}
+
+func (s *Server) reactionsJSON(channel, ts string) []interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var reactions []interface{}
+	for _, r := range s.reacted[channel+"/"+ts] {
+		users := make([]interface{}, len(r.users))
+		for i, user := range r.users {
+			users[i] = user
+		}
+		reactions = append(reactions, map[string]interface{}{
+			"name":  r.name,
+			"count": len(r.users),
+			"users": users,
+		})
+	}
+	return reactions
+}
+
+// Close disconnects the bot, if it is connected, and shuts down the server.
+// Disconnecting the bot makes its Start method return.
+func (s *Server) Close() {
+	s.mu.Lock()
+	conn := s.conn
+	s.conn = nil
+	s.mu.Unlock()
+	if conn != nil {
+		conn.Close()
+	}
+	s.server.Close()
+}
+
+// AddUser adds user to the team.
+func (s *Server) AddUser(user User) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.users = append(s.users, user)
+}
+
+// AddChannel adds channel to the team.
+func (s *Server) AddChannel(channel Channel) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.channels = append(s.channels, channel)
+}
+
+// Handle fakes the Web API method with handler, replacing the server's own
+// fake of the method if it has one.
+func (s *Server) Handle(method string, handler Handler) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.handlers[method] = handler
+}
+
+// Calls returns the calls the bot has made to the Web API method, in the order
+// they were made. If method is empty, every call is returned.
+func (s *Server) Calls(method string) []Call {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var calls []Call
+	for _, call := range s.calls {
+		if method == "" || call.Method == method {
+			calls = append(calls, call)
+		}
+	}
+	return calls
+}
+
+// Messages returns the messages the bot has sent, in the order they were
+// received.
+func (s *Server) Messages() []Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Message(nil), s.messages...)
+}
+
+// WaitForMessages waits until the bot has sent at least n messages, and
+// returns them. It returns an error (along with the messages sent so far) if
+// that does not happen within timeout.
+func (s *Server) WaitForMessages(n int, timeout time.Duration) ([]Message, error) {
+	ok := s.wait(timeout, func() bool {
+		return len(s.messages) >= n
+	})
+	messages := s.Messages()
+	if !ok {
+		return messages, &Error{fmt.Sprintf("timed out waiting for %d messages, got %d", n, len(messages))}
+	}
+	return messages, nil
+}
+
+// WaitForConnection waits until the bot has connected to the RTM websocket.
+func (s *Server) WaitForConnection(timeout time.Duration) error {
+	ok := s.wait(timeout, func() bool {
+		return s.conn != nil
+	})
+	if !ok {
+		return &Error{"timed out waiting for the bot to connect"}
+	}
+	return nil
+}
+
+// SendEvent sends event to the bot over the RTM websocket, waiting up to
+// DefaultTimeout for the bot to connect.
+func (s *Server) SendEvent(event map[string]interface{}) error {
+	if err := s.WaitForConnection(DefaultTimeout); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	conn := s.conn
+	s.mu.Unlock()
+	if conn == nil {
+		return &Error{"the bot is not connected"}
+	}
+	return s.write(conn, event)
+}
+
+// SendMessage sends a message event from user in channel to the bot, and
+// returns the timestamp of the message.
+func (s *Server) SendMessage(channel, user, text string) (string, error) {
+	ts := s.nextTS()
+	return ts, s.SendEvent(map[string]interface{}{
+		"type":    "message",
+		"channel": channel,
+		"user":    user,
+		"text":    text,
+		"ts":      ts,
+	})
+}
+
+// wait waits until done returns true, calling it with s.mu held whenever the
+// server's state changes. It returns false if timeout passes first.
+func (s *Server) wait(timeout time.Duration, done func() bool) bool {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	for {
+		s.mu.Lock()
+		if done() {
+			s.mu.Unlock()
+			return true
+		}
+		changed := s.changed
+		s.mu.Unlock()
+		select {
+		case <-changed:
+		case <-timer.C:
+			return false
+		}
+	}
+}
+
+// notify wakes up everything waiting on the server. s.mu must be held.
+func (s *Server) notify() {
+	close(s.changed)
+	s.changed = make(chan struct{})
+}
+
+// nextTS returns a new, unique message timestamp.
+func (s *Server) nextTS() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lastTS++
+	return fmt.Sprintf("%d.%06d", s.lastTS/1e6, s.lastTS%1e6)
+}
+
+func (s *Server) write(conn *websocket.Conn, v interface{}) error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	return conn.WriteJSON(v)
+}
+
+func (s *Server) serveRTM(w http.ResponseWriter, r *http.Request) {
+	conn, err := s.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	if err := s.write(conn, map[string]interface{}{"type": "hello"}); err != nil {
+		conn.Close()
+		return
+	}
+	s.mu.Lock()
+	s.conn = conn
+	s.notify()
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		if s.conn == conn {
+			s.conn = nil
+			s.notify()
+		}
+		s.mu.Unlock()
+		conn.Close()
+	}()
+
+	for {
+		var frame map[string]interface{}
+		if err := conn.ReadJSON(&frame); err != nil {
+			return
+		}
+		if frame["type"] == "ping" {
+			s.write(conn, map[string]interface{}{"type": "pong", "reply_to": frame["id"]})
+			continue
+		}
+		if frame["type"] != "message" {
+			continue
+		}
+		if stringOf(frame["text"]) == "" {
+			s.write(conn, map[string]interface{}{
+				"ok":       false,
+				"reply_to": frame["id"],
+				"error":    map[string]interface{}{"code": 2, "msg": "message text is missing"},
+			})
+			continue
+		}
+		ts := s.nextTS()
+		s.mu.Lock()
+		s.messages = append(s.messages, Message{
+			ID:       stringOf(frame["id"]),
+			TS:       ts,
+			Channel:  stringOf(frame["channel"]),
+			Text:     stringOf(frame["text"]),
+			ThreadTS: stringOf(frame["thread_ts"]),
+		})
+		s.notify()
+		s.mu.Unlock()
+		// Slack acknowledges each message sent over the websocket.
+		s.write(conn, map[string]interface{}{
+			"ok":       true,
+			"reply_to": frame["id"],
+			"ts":       ts,
+			"text":     frame["text"],
+		})
+	}
+}
+
+func (s *Server) serveAPI(w http.ResponseWriter, r *http.Request) {
+	method := strings.TrimPrefix(r.URL.Path, "/api/")
+	r.ParseForm()
+	params := r.Form
+
+	var payload map[string]interface{}
+	token := params.Get("token")
+	switch {
+	case token == "":
+		payload = failure("not_authed")
+	case s.Token != "" && token != s.Token:
+		payload = failure("invalid_auth")
+	default:
+		s.mu.Lock()
+		s.calls = append(s.calls, Call{method, params})
+		s.notify()
+		handler, ok := s.handlers[method]
+		s.mu.Unlock()
+		if ok {
+			payload = handler(params)
+		} else {
+			payload = s.call(method, params)
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(payload)
+}
+
+// call fakes the Web API method.
+func (s *Server) call(method string, params url.Values) map[string]interface{} {
+	self := map[string]interface{}{"id": s.BotID, "name": s.BotName}
+	switch method {
+	case "rtm.start":
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		users := make([]interface{}, len(s.users))
+		for i, user := range s.users {
+			users[i] = user.toJSON()
+		}
+		channels := make([]interface{}, len(s.channels))
+		for i, channel := range s.channels {
+			channels[i] = map[string]interface{}{"id": channel.ID, "name": channel.Name}
+		}
+		return success(map[string]interface{}{
+			"url":      s.rtmURL(),
+			"self":     self,
+			"users":    users,
+			"channels": channels,
+		})
+	case "rtm.connect":
+		return success(map[string]interface{}{
+			"url":  s.rtmURL(),
+			"self": self,
+			"team": map[string]interface{}{"id": "T1", "name": "slacktest"},
+		})
+	case "auth.test":
+		return success(map[string]interface{}{"user_id": s.BotID, "user": s.BotName})
+	case "chat.postMessage":
+		ts := s.nextTS()
+		s.mu.Lock()
+		s.messages = append(s.messages, Message{
+			TS:       ts,
+			Channel:  params.Get("channel"),
+			Text:     params.Get("text"),
+			ThreadTS: params.Get("thread_ts"),
+			Blocks:   params.Get("blocks"),
+		})
+		s.notify()
+		s.mu.Unlock()
+		return success(map[string]interface{}{
+			"channel": params.Get("channel"),
+			"ts":      ts,
+			"message": map[string]interface{}{
+				"type": "message",
+				"user": s.BotID,
+				"text": params.Get("text"),
+				"ts":   ts,
+			},
+		})
+	case "chat.update", "chat.delete":
+		return success(map[string]interface{}{
+			"channel": params.Get("channel"),
+			"ts":      params.Get("ts"),
+		})
+	case "chat.getPermalink":
+		channel, ts := params.Get("channel"), params.Get("message_ts")
+		return success(map[string]interface{}{
+			"channel":   channel,
+			"permalink": fmt.Sprintf("%s/archives/%s/p%s", s.server.URL, channel, strings.Replace(ts, ".", "", 1)),
+		})
+	case "reactions.add", "reactions.remove", "reactions.get":
+		channel, ts, name := params.Get("channel"), params.Get("timestamp"), params.Get("name")
+		if channel == "" || ts == "" || name == "" && method != "reactions.get" {
+			return failure("invalid_arguments")
+		}
+		switch method {
+		case "reactions.add":
+			if !s.react(channel, ts, s.BotID, name, true) {
+				return failure("already_reacted")
+			}
+		case "reactions.remove":
+			if !s.react(channel, ts, s.BotID, name, false) {
+				return failure("no_reaction")
+			}
+		case "reactions.get":
+			return success(map[string]interface{}{
+				"type": "message",
+				"message": map[string]interface{}{
+					"type":      "message",
+					"ts":        ts,
+					"reactions": s.reactionsJSON(channel, ts),
+				},
+			})
+		}
+		return success(nil)
+	case "im.open", "conversations.open":
+		users := params.Get("user")
+		if users == "" {
+			users = params.Get("users")
+		}
+		if users == "" || method == "im.open" && strings.Contains(users, ",") {
+			return failure("invalid_arguments")
+		}
+		ids := strings.Split(users, ",")
+		for _, id := range ids {
+			if _, ok := s.user(id); !ok {
+				return failure("user_not_found")
+			}
+		}
+		// Direct messages are "D" followed by the user's ID, and group direct
+		// messages are "G" followed by the sorted IDs of their users.
+		if len(ids) == 1 {
+			return success(map[string]interface{}{
+				"channel": map[string]interface{}{"id": "D" + users},
+			})
+		}
+		sort.Strings(ids)
+		return success(map[string]interface{}{
+			"channel": map[string]interface{}{"id": "G" + strings.Join(ids, "")},
+		})
+	case "users.list":
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		members := make([]interface{}, len(s.users))
+		for i, user := range s.users {
+			members[i] = user.toJSON()
+		}
+		return success(map[string]interface{}{"members": members})
+	case "users.info":
+		user, ok := s.user(params.Get("user"))
+		if !ok {
+			return failure("user_not_found")
+		}
+		return success(map[string]interface{}{"user": user.toJSON()})
+	}
+	return failure("unknown_method")
+}
+
+func (s *Server) user(id string) (User, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, user := range s.users {
+		if user.ID == id {
+			return user, true
+		}
+	}
+	return User{}, false
+}
+
+func (s *Server) rtmURL() string {
+	return "ws" + strings.TrimPrefix(s.server.URL, "http") + "/rtm"
+}
+
+func success(fields map[string]interface{}) map[string]interface{} {
+	payload := map[string]interface{}{"ok": true}
+	for k, v := range fields {
+		payload[k] = v
+	}
+	return payload
+}
+
+func failure(reason string) map[string]interface{} {
+	return map[string]interface{}{"ok": false, "error": reason}
+}
+
+func stringOf(v interface{}) string {
+	if v == nil {
+		return ""
+	}
+	if s, ok := v.(string); ok {
+		return s
+	}
+	return fmt.Sprint(v)
+}
@@ -0,0 +1,128 @@
+package slacktest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func call(t *testing.T, s *Server, method string, params url.Values) map[string]interface{} {
+	response, err := http.PostForm(s.URL+method, params)
+	if err != nil {
+		t.Fatalf("Error. Could not call %s: %v.", method, err)
+	}
+	defer response.Body.Close()
+	var payload map[string]interface{}
+	if err := json.NewDecoder(response.Body).Decode(&payload); err != nil {
+		t.Fatalf("Error. Could not decode the response to %s: %v.", method, err)
+	}
+	return payload
+}
+
+func TestAPI(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	s.Token = "token"
+	s.AddUser(User{ID: "U1", Name: "alice", FirstName: "Alice"})
+	s.Handle("conversations.replies", func(params url.Values) map[string]interface{} {
+		return map[string]interface{}{"ok": true, "messages": []interface{}{}}
+	})
+
+	tests := []struct {
+		method   string
+		params   url.Values
+		ok       bool
+		expected string
+	}{
+		{"auth.test", url.Values{}, false, "not_authed"},
+		{"auth.test", url.Values{"token": {"wrong"}}, false, "invalid_auth"},
+		{"auth.test", url.Values{"token": {"token"}}, true, ""},
+		{"im.open", url.Values{"token": {"token"}, "user": {"U1"}}, true, ""},
+		{"im.open", url.Values{"token": {"token"}, "user": {"U2"}}, false, "user_not_found"},
+		{"reactions.add", url.Values{"token": {"token"}, "name": {"ok"}}, false, "invalid_arguments"},
+		{"conversations.replies", url.Values{"token": {"token"}}, true, ""},
+		{"files.upload", url.Values{"token": {"token"}}, false, "unknown_method"},
+	}
+	for _, test := range tests {
+		payload := call(t, s, test.method, test.params)
+		if ok, _ := payload["ok"].(bool); ok != test.ok || (!ok && payload["error"] != test.expected) {
+			t.Errorf("Error. Unexpected response to %s %v: %v.", test.method, test.params, payload)
+		}
+	}
+
+	payload := call(t, s, "im.open", url.Values{"token": {"token"}, "user": {"U1"}})
+	if channel := payload["channel"].(map[string]interface{}); channel["id"] != "DU1" {
+		t.Errorf("Error. Expected the DM channel DU1, got %v.", channel["id"])
+	}
+	payload = call(t, s, "rtm.start", url.Values{"token": {"token"}})
+	if users := payload["users"].([]interface{}); len(users) != 1 || !strings.HasPrefix(payload["url"].(string), "ws://") {
+		t.Errorf("Error. Unexpected rtm.start payload: %v.", payload)
+	}
+
+	payload = call(t, s, "chat.postMessage", url.Values{"token": {"token"}, "channel": {"C1"}, "text": {"hi"}, "thread_ts": {"1.0"}})
+	messages := s.Messages()
+	if len(messages) != 1 || messages[0] != (Message{TS: payload["ts"].(string), Channel: "C1", Text: "hi", ThreadTS: "1.0"}) {
+		t.Errorf("Error. Expected chat.postMessage to be recorded, got %v.", messages)
+	}
+	// Calls which fail authentication are not recorded.
+	if calls := s.Calls(""); len(calls) != len(tests)-2+3 {
+		t.Errorf("Error. Expected %d calls, got %d.", len(tests)-2+3, len(calls))
+	}
+	if calls := s.Calls("im.open"); len(calls) != 3 || calls[0].Params.Get("user") != "U1" {
+		t.Errorf("Error. Unexpected im.open calls: %v.", calls)
+	}
+}
+
+func TestRTM(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	payload := call(t, s, "rtm.connect", url.Values{"token": {"token"}})
+	conn, _, err := websocket.DefaultDialer.Dial(payload["url"].(string), nil)
+	if err != nil {
+		t.Fatalf("Error. Could not connect: %v.", err)
+	}
+	defer conn.Close()
+
+	var hello map[string]interface{}
+	if err := conn.ReadJSON(&hello); err != nil || hello["type"] != "hello" {
+		t.Fatalf("Error. Expected hello, got %v (%v).", hello, err)
+	}
+	if err := s.WaitForConnection(time.Second); err != nil {
+		t.Fatal(err)
+	}
+
+	conn.WriteJSON(map[string]string{"id": "1", "type": "message", "channel": "C1", "text": "hi"})
+	var ack map[string]interface{}
+	if err := conn.ReadJSON(&ack); err != nil || ack["reply_to"] != "1" || ack["ok"] != true {
+		t.Errorf("Error. Expected the message to be acknowledged, got %v (%v).", ack, err)
+	}
+	messages, err := s.WaitForMessages(1, time.Second)
+	if err != nil || messages[0] != (Message{ID: "1", TS: stringOf(ack["ts"]), Channel: "C1", Text: "hi"}) {
+		t.Errorf("Error. Expected the message to be recorded, got %v (%v).", messages, err)
+	}
+	conn.WriteJSON(map[string]string{"id": "2", "type": "message", "channel": "C1", "text": ""})
+	if err := conn.ReadJSON(&ack); err != nil || ack["reply_to"] != "2" || ack["ok"] != false {
+		t.Errorf("Error. Expected the empty message to be rejected, got %v (%v).", ack, err)
+	}
+	if _, err := s.WaitForMessages(2, 10*time.Millisecond); err == nil {
+		t.Error("Error. Expected to time out waiting for a second message.")
+	}
+
+	ts, err := s.SendMessage("C1", "U1", "hello bot")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var event map[string]interface{}
+	if err := conn.ReadJSON(&event); err != nil || event["text"] != "hello bot" || event["ts"] != ts {
+		t.Errorf("Error. Expected the injected message, got %v (%v).", event, err)
+	}
+	first, _ := s.SendMessage("C1", "U1", "again")
+	if first <= ts {
+		t.Errorf("Error. Expected timestamps to increase, got %s after %s.", first, ts)
+	}
+}
@@ -0,0 +1,328 @@
+package slack_test
+
+// These tests run bots against the fake server in package slacktest. They are
+// in package slack_test, since slacktest imports package slack.
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/ajm188/slack"
+	"github.com/ajm188/slack/slacktest"
+)
+
+// startBot points bot at server and starts it, waiting until it connects.
+// The returned function closes the server, and returns what Start returned
+// once the bot has disconnected.
+func startBot(t *testing.T, server *slacktest.Server, bot *slack.Bot) func() error {
+	t.Helper()
+	bot.APIURL = server.URL
+	done := make(chan error, 1)
+	go func() {
+		done <- bot.Start()
+	}()
+	if err := server.WaitForConnection(time.Second); err != nil {
+		t.Fatal(err)
+	}
+	return func() error {
+		server.Close()
+		select {
+		case err := <-done:
+			return err
+		case <-time.After(time.Second):
+			t.Fatal("Error. Expected Start to return once disconnected.")
+			return nil
+		}
+	}
+}
+
+func TestStart(t *testing.T) {
+	log.SetLevel(log.PanicLevel)
+	server := slacktest.NewServer()
+	defer server.Close()
+	server.AddUser(slacktest.User{ID: "U1", Name: "alice", FirstName: "Alice"})
+	server.AddChannel(slacktest.Channel{ID: "C1", Name: "general"})
+
+	bot := slack.NewBot("token")
+	bot.Respond("^ping$", slack.Respond("pong"))
+	stop := startBot(t, server, bot)
+
+	if _, err := server.SendMessage("C1", "U1", "<@UBOT> ping"); err != nil {
+		t.Fatal(err)
+	}
+	messages, err := server.WaitForMessages(1, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if messages[0].Channel != "C1" || messages[0].Text != "<@U1>: pong" {
+		t.Errorf("Error. Expected the bot to reply pong. Got %v.", messages[0])
+	}
+	if bot.ID != "UBOT" || bot.Name != "bot" {
+		t.Errorf("Error. Expected the bot to learn its identity. Got %s, %s.", bot.ID, bot.Name)
+	}
+	if bot.Channels["general"] != "C1" || bot.Users["alice"].FirstName != "Alice" {
+		t.Error("Error. Expected the bot to learn the team's channels and users.")
+	}
+
+	if err := stop(); err != nil {
+		t.Errorf("Error. Expected Start to return nil. Got %v.", err)
+	}
+}
+
+func TestStart_failsWithBadToken(t *testing.T) {
+	server := slacktest.NewServer()
+	defer server.Close()
+	server.Token = "token"
+	bot := slack.NewBot("wrong")
+	bot.APIURL = server.URL
+	if err := bot.Start(); err == nil {
+		t.Error("Error. Expecting error. Got nil")
+	}
+}
+
+func TestOpenDirectMessage(t *testing.T) {
+	log.SetLevel(log.PanicLevel)
+	server := slacktest.NewServer()
+	defer server.Close()
+	server.AddUser(slacktest.User{ID: "U1", Name: "alice"})
+	bot := slack.NewBot("token")
+	bot.APIURL = server.URL
+
+	channel, err := bot.OpenDirectMessage("U1")
+	if err != nil || channel != "DU1" {
+		t.Errorf("Error. Expected DU1. Got %s (%v).", channel, err)
+	}
+	if _, err := bot.OpenDirectMessage("U2"); err == nil {
+		t.Error("Error. Expecting error for an unknown user. Got nil")
+	}
+	if calls := server.Calls("im.open"); len(calls) != 2 || calls[0].Params.Get("token") != "token" {
+		t.Errorf("Error. Unexpected im.open calls: %v.", calls)
+	}
+}
+
+func TestOpenDirectMessage_caches(t *testing.T) {
+	log.SetLevel(log.PanicLevel)
+	server := slacktest.NewServer()
+	defer server.Close()
+	server.AddUser(slacktest.User{ID: "U1", Name: "alice"})
+	bot := slack.NewBot("token")
+	bot.APIURL = server.URL
+
+	for i := 0; i < 3; i++ {
+		message, err := bot.DirectMessage("U1", "hello")
+		if err != nil || message.Channel() != "DU1" {
+			t.Errorf("Error. Expected a message to DU1. Got %v (%v).", message, err)
+		}
+	}
+	if calls := server.Calls("im.open"); len(calls) != 1 {
+		t.Errorf("Error. Expected the channel to be opened once. Got %v.", calls)
+	}
+	message, err := bot.DirectMessage("U2", "hello")
+	if message != nil || err == nil || !strings.Contains(err.Error(), "user_not_found") {
+		t.Errorf("Error. Expected user_not_found. Got %v (%v).", message, err)
+	}
+	if cached := bot.State().Caches["direct_messages"]; cached != 1 {
+		t.Errorf("Error. Expected 1 cached direct message. Got %d.", cached)
+	}
+}
+
+func TestOpenGroupMessage(t *testing.T) {
+	log.SetLevel(log.PanicLevel)
+	server := slacktest.NewServer()
+	defer server.Close()
+	server.AddUser(slacktest.User{ID: "U1", Name: "alice"})
+	server.AddUser(slacktest.User{ID: "U2", Name: "bob"})
+	bot := slack.NewBot("token")
+	bot.APIURL = server.URL
+
+	message, err := bot.GroupMessage("hello", "U2", "U1")
+	if err != nil || message.Channel() != "GU1U2" || message.Text() != "hello" {
+		t.Errorf("Error. Expected a message to GU1U2. Got %v (%v).", message, err)
+	}
+	channel, err := bot.OpenGroupMessage("U1", "U2")
+	if err != nil || channel != "GU1U2" {
+		t.Errorf("Error. Expected GU1U2. Got %s (%v).", channel, err)
+	}
+	calls := server.Calls("conversations.open")
+	if len(calls) != 1 || calls[0].Params.Get("users") != "U1,U2" {
+		t.Errorf("Error. Expected the group to be opened once. Got %v.", calls)
+	}
+
+	var tests = []struct {
+		users    []string
+		expected string
+	}{
+		{nil, "no users"},
+		{[]string{"U1", "U3"}, "user_not_found"},
+	}
+	for _, test := range tests {
+		if _, err := bot.OpenGroupMessage(test.users...); err == nil || !strings.Contains(err.Error(), test.expected) {
+			t.Errorf("Error. Expected %q for %v. Got %v.", test.expected, test.users, err)
+		}
+	}
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	log.SetLevel(log.PanicLevel)
+	server := slacktest.NewServer()
+	defer server.Close()
+	var recording bytes.Buffer
+	bot := slack.NewBot("token")
+	bot.Recorder = slack.NewRecorder(&recording)
+	bot.Listen("^ping$", func(b *slack.Bot, event map[string]interface{}) (*slack.Message, slack.Status) {
+		return slack.NewMessage("pong", event["channel"].(string)), slack.Continue
+	})
+	stop := startBot(t, server, bot)
+
+	server.SendMessage("C1", "U1", "ping")
+	if _, err := server.WaitForMessages(1, time.Second); err != nil {
+		t.Fatal(err)
+	}
+	// The recording is complete once the bot has disconnected.
+	stop()
+
+	records, err := slack.ReadRecording(bytes.NewReader(recording.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sent []string
+	for _, record := range records {
+		if record.Direction == slack.Outgoing {
+			sent = append(sent, record.Message["text"])
+		}
+	}
+	if len(sent) != 1 || sent[0] != "pong" {
+		t.Errorf("Error. Expected the reply to be recorded. Got %q.", sent)
+	}
+
+	replayed, err := bot.Replay(bytes.NewReader(recording.Bytes()), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(replayed) != 1 || replayed[0].Text() != "pong" || replayed[0].Channel() != "C1" {
+		t.Errorf("Error. Expected the replay to reply pong in C1. Got %v.", replayed)
+	}
+}
+
+func TestReady(t *testing.T) {
+	log.SetLevel(log.PanicLevel)
+	server := slacktest.NewServer()
+	defer server.Close()
+	bot := slack.NewBot("token")
+	bot.PingInterval = 10 * time.Millisecond
+	if err := bot.Ready(); err == nil {
+		t.Error("Error. Expected the bot not to be ready before it starts.")
+	}
+	stop := startBot(t, server, bot)
+
+	deadline := time.Now().Add(time.Second)
+	for bot.Ready() != nil || bot.State().Connection.LastPong.IsZero() {
+		if time.Now().After(deadline) {
+			t.Fatalf("Error. Expected the bot to be ready and ponged. Got %v, %+v.", bot.Ready(), bot.State().Connection)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	stop()
+	if err := bot.Ready(); err == nil {
+		t.Error("Error. Expected the bot not to be ready once disconnected.")
+	}
+}
+
+func TestSendAndWait(t *testing.T) {
+	log.SetLevel(log.PanicLevel)
+	server := slacktest.NewServer()
+	defer server.Close()
+	bot := slack.NewBot("token")
+	bot.PingInterval = 0
+	stop := startBot(t, server, bot)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	first, err := bot.SendAndWait(ctx, slack.NewMessage("one", "C1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := bot.SendAndWait(ctx, slack.NewMessage("two", "C1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	messages := server.Messages()
+	if first.ID != "1" || second.ID != "2" || first.TS != messages[0].TS || second.TS != messages[1].TS {
+		t.Errorf("Error. Expected acknowledgements of %v. Got %v, %v.", messages, first, second)
+	}
+	if _, err := bot.SendAndWait(ctx, slack.NewMessage("", "C1")); err == nil || !strings.Contains(err.Error(), "message text is missing") {
+		t.Errorf("Error. Expected the empty message to be rejected. Got %v.", err)
+	}
+	if unacknowledged := bot.State().Connection.Unacknowledged; unacknowledged != 0 {
+		t.Errorf("Error. Expected every message to be acknowledged. Got %d.", unacknowledged)
+	}
+
+	stop()
+}
+
+func TestReactions(t *testing.T) {
+	log.SetLevel(log.PanicLevel)
+	server := slacktest.NewServer()
+	defer server.Close()
+	server.AddReaction("C1", "1.0", "U1", "eyes")
+	bot := slack.NewBot("token")
+	bot.APIURL = server.URL
+
+	if err := bot.AddReaction("C1", "1.0", ":tada:"); err != nil {
+		t.Errorf("Error. Expected to react. Got %v.", err)
+	}
+	err := bot.AddReaction("C1", "1.0", "tada")
+	if err == nil || err.Error() != "reactions.add failed: already_reacted" {
+		t.Errorf("Error. Expected already_reacted. Got %v.", err)
+	}
+	if err := bot.AddReactions("C1", "1.0", "eyes", "tada", "rocket"); err == nil || !strings.Contains(err.Error(), "tada (reactions.add failed: already_reacted)") {
+		t.Errorf("Error. Expected tada to fail. Got %v.", err)
+	}
+
+	reactions, err := bot.Reactions("C1", "1.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []slack.Reaction{
+		{Name: "eyes", Count: 2, Users: []string{"U1", "UBOT"}},
+		{Name: "tada", Count: 1, Users: []string{"UBOT"}},
+		{Name: "rocket", Count: 1, Users: []string{"UBOT"}},
+	}
+	if !reflect.DeepEqual(reactions, expected) {
+		t.Errorf("Error. Expected %v. Got %v.", expected, reactions)
+	}
+
+	if err := bot.RemoveReaction("C1", "1.0", "eyes"); err != nil {
+		t.Errorf("Error. Expected to remove the reaction. Got %v.", err)
+	}
+	err = bot.RemoveReaction("C1", "1.0", "eyes")
+	if err == nil || err.Error() != "reactions.remove failed: no_reaction" {
+		t.Errorf("Error. Expected no_reaction. Got %v.", err)
+	}
+	if reactions, _ := bot.Reactions("C1", "1.0"); len(reactions) != 3 || reactions[0].Count != 1 {
+		t.Errorf("Error. Expected only U1's eyes to be left. Got %v.", reactions)
+	}
+}
+
+func TestReact(t *testing.T) {
+	h := slacktest.NewHarness(t)
+	defer h.Close()
+	h.Bot.Listen("^ship it$", slack.React("shipit", "rocket"))
+
+	result := h.User("U1").In("C1").Says("ship it")
+	result.ExpectReaction("shipit").ExpectReaction("rocket").ExpectNoReply()
+
+	// Reacting to the same message again fails, but React carries on.
+	ts := result.Event["ts"].(string)
+	h.Bot.AddReaction("C1", ts, "eyes")
+	h.Bot.Dispatch(result.Event)
+	if calls := h.Server.Calls("reactions.add"); len(calls) != 5 {
+		t.Errorf("Error. Expected every reaction to be attempted. Got %v.", calls)
+	}
+}
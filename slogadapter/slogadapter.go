@@ -0,0 +1,59 @@
+//go:build go1.21
+// +build go1.21
+
+/*
+Package slogadapter adapts a log/slog logger to a slack.Logger, so that a bot
+logs with slog:
+
+	bot.Logger = slogadapter.New(slog.Default())
+
+It needs Go 1.21, which added log/slog, so it is kept out of package slack.
+*/
+package slogadapter
+
+import (
+	"context"
+	"log/slog"
+	"sort"
+
+	"github.com/ajm188/slack"
+)
+
+// New adapts logger to a slack.Logger. The fields become the attributes of
+// each record, sorted by key.
+func New(logger *slog.Logger) slack.Logger {
+	return slogLogger{logger}
+}
+
+type slogLogger struct {
+	logger *slog.Logger
+}
+
+func (l slogLogger) Debug(message string, fields slack.Fields) {
+	l.log(slog.LevelDebug, message, fields)
+}
+
+func (l slogLogger) Info(message string, fields slack.Fields) {
+	l.log(slog.LevelInfo, message, fields)
+}
+
+func (l slogLogger) Warn(message string, fields slack.Fields) {
+	l.log(slog.LevelWarn, message, fields)
+}
+
+func (l slogLogger) Error(message string, fields slack.Fields) {
+	l.log(slog.LevelError, message, fields)
+}
+
+func (l slogLogger) log(level slog.Level, message string, fields slack.Fields) {
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	attrs := make([]slog.Attr, len(keys))
+	for i, key := range keys {
+		attrs[i] = slog.Any(key, fields[key])
+	}
+	l.logger.LogAttrs(context.Background(), level, message, attrs...)
+}
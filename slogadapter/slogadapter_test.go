@@ -0,0 +1,42 @@
+//go:build go1.21
+// +build go1.21
+
+package slogadapter
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/ajm188/slack"
+)
+
+func TestNew(t *testing.T) {
+	var buffer bytes.Buffer
+	handler := slog.NewTextHandler(&buffer, &slog.HandlerOptions{
+		Level: slog.LevelInfo,
+		ReplaceAttr: func(_ []string, attr slog.Attr) slog.Attr {
+			if attr.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			return attr
+		},
+	})
+	logger := New(slog.New(handler))
+	logger.Debug("hidden", nil)
+	logger.Info("hello", slack.Fields{"b": 2, "a": "one"})
+	logger.Warn("careful", nil)
+	logger.Error("failed", slack.Fields{"error": fmt.Errorf("boom")})
+
+	expected := strings.Join([]string{
+		"level=INFO msg=hello a=one b=2",
+		"level=WARN msg=careful",
+		"level=ERROR msg=failed error=boom",
+		"",
+	}, "\n")
+	if buffer.String() != expected {
+		t.Errorf("Error. Expected:\n%s\nGot:\n%s", expected, buffer.String())
+	}
+}
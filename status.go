@@ -14,3 +14,15 @@ const (
 	// downstream BotActions and then terminate.
 	Shutdown
 )
+
+func (status Status) String() string {
+	switch status {
+	case Continue:
+		return "continue"
+	case ShutdownNow:
+		return "shutdown_now"
+	case Shutdown:
+		return "shutdown"
+	}
+	return "unknown"
+}
@@ -0,0 +1,213 @@
+package slack
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+// Tracer starts spans, which time a piece of work and describe it with
+// attributes. Set a bot's Tracer to trace it: each event the bot handles
+// starts a span, and each handler the event fires and each Web API call made
+// with CallContext is a child span of it.
+//
+// Tracer is shaped like OpenTelemetry's tracer, so wrapping one to use it
+// here takes only a few lines. NewTracer constructs a Tracer which hands
+// finished spans to an Exporter.
+type Tracer interface {
+	// Start starts a span named name. If ctx carries a span, the new span is
+	// its child. The returned context carries the new span.
+	Start(ctx context.Context, name string) (context.Context, Span)
+}
+
+// Span is a piece of work which has been started by a Tracer. The work is
+// over when End is called.
+type Span interface {
+	SetAttribute(key string, value interface{})
+	RecordError(err error)
+	End()
+}
+
+// nopTracer is used when a bot has no Tracer.
+type nopTracer struct{}
+
+func (nopTracer) Start(ctx context.Context, _ string) (context.Context, Span) {
+	return ctx, nopSpan{}
+}
+
+type nopSpan struct{}
+
+func (nopSpan) SetAttribute(string, interface{}) {}
+func (nopSpan) RecordError(error)                {}
+func (nopSpan) End()                             {}
+
+// tracer returns the bot's Tracer, or a Tracer whose spans do nothing if it
+// has none.
+func (bot *Bot) tracer() Tracer {
+	if bot.Tracer == nil {
+		return nopTracer{}
+	}
+	return bot.Tracer
+}
+
+// StartSpan starts a span with the bot's Tracer. Plugins use it to trace
+// their own work, like calls to other services, as part of handling an
+// event:
+//
+//	ctx, span := b.StartSpan(b.Context(event), "github.issues.get")
+//	defer span.End()
+func (bot *Bot) StartSpan(ctx context.Context, name string) (context.Context, Span) {
+	return bot.tracer().Start(ctx, name)
+}
+
+// Context returns the context of the handler which is handling event. It
+// carries the handler's span, so spans started from it are children of the
+// handler's. Outside of a handler, or once the handler has returned, Context
+// returns context.Background().
+//
+// The handlers of an event are given a copy of it which carries its context,
+// under a key which Slack never sends, so copies the handlers make carry it
+// too. The event passed to Dispatch does not. A handler which hands the event
+// to a goroutine that may outlive the handler should call Context first and
+// pass the context along:
+//
+//	ctx := b.Context(event)
+//	go func() { b.CallContext(ctx, "chat.postMessage", data) }()
+func (bot *Bot) Context(event map[string]interface{}) context.Context {
+	if c, ok := event[contextKey].(eventContext); ok {
+		return c.ctx
+	}
+	return context.Background()
+}
+
+// contextKey is the key of an event which carries its context while it is
+// being handled.
+const contextKey = "_slack_context"
+
+// eventContext wraps the context carried by an event, so that an event from
+// Slack which happens to use contextKey is not mistaken for one.
+type eventContext struct {
+	ctx context.Context
+}
+
+// withContext makes ctx the context of event, and returns a function which
+// restores the context it had before.
+func withContext(event map[string]interface{}, ctx context.Context) func() {
+	previous, ok := event[contextKey]
+	event[contextKey] = eventContext{ctx}
+	return func() {
+		if ok {
+			event[contextKey] = previous
+		} else {
+			delete(event, contextKey)
+		}
+	}
+}
+
+// copyEvent returns a shallow copy of event.
+func copyEvent(event map[string]interface{}) map[string]interface{} {
+	copy := make(map[string]interface{}, len(event)+1)
+	for key, value := range event {
+		copy[key] = value
+	}
+	return copy
+}
+
+// SpanData is a finished span, as it is handed to an Exporter. TraceID and
+// SpanID are hex-encoded, and ParentID is empty for the root span of a trace.
+type SpanData struct {
+	TraceID    string
+	SpanID     string
+	ParentID   string
+	Name       string
+	Start      time.Time
+	End        time.Time
+	Attributes map[string]interface{}
+	Errors     []error
+}
+
+// Exporter receives spans as they finish, to send them on to wherever traces
+// are collected. Export is called from several goroutines, so it must be safe
+// for concurrent use.
+type Exporter interface {
+	Export(span SpanData)
+}
+
+// NewTracer constructs a Tracer which hands each span to exporter when it
+// ends.
+func NewTracer(exporter Exporter) Tracer {
+	return &tracer{exporter}
+}
+
+type tracer struct {
+	exporter Exporter
+}
+
+type spanKey struct{}
+
+func (t *tracer) Start(ctx context.Context, name string) (context.Context, Span) {
+	s := &span{
+		tracer: t,
+		data: SpanData{
+			SpanID:     randomID(8),
+			Name:       name,
+			Start:      time.Now(),
+			Attributes: make(map[string]interface{}),
+		},
+	}
+	if parent, ok := ctx.Value(spanKey{}).(*span); ok {
+		s.data.TraceID = parent.data.TraceID
+		s.data.ParentID = parent.data.SpanID
+	} else {
+		s.data.TraceID = randomID(16)
+	}
+	return context.WithValue(ctx, spanKey{}, s), s
+}
+
+type span struct {
+	mu     sync.Mutex
+	tracer *tracer
+	data   SpanData
+	ended  bool
+}
+
+func (s *span) SetAttribute(key string, value interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.ended {
+		s.data.Attributes[key] = value
+	}
+}
+
+func (s *span) RecordError(err error) {
+	if err == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.ended {
+		s.data.Errors = append(s.data.Errors, err)
+	}
+}
+
+// End finishes the span and exports it. Only the first call does anything.
+func (s *span) End() {
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.data.End = time.Now()
+	data := s.data
+	s.mu.Unlock()
+	s.tracer.exporter.Export(data)
+}
+
+func randomID(size int) string {
+	id := make([]byte, size)
+	rand.Read(id)
+	return hex.EncodeToString(id)
+}
@@ -0,0 +1,139 @@
+package slack
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+// fakeExporter keeps the spans it is given.
+type fakeExporter struct {
+	mu    sync.Mutex
+	spans []SpanData
+}
+
+func (e *fakeExporter) Export(span SpanData) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.spans = append(e.spans, span)
+}
+
+func (e *fakeExporter) named(name string) []SpanData {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	var spans []SpanData
+	for _, span := range e.spans {
+		if span.Name == name {
+			spans = append(spans, span)
+		}
+	}
+	return spans
+}
+
+func TestTracing(t *testing.T) {
+	log.SetLevel(log.PanicLevel)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"ok": false, "error": "channel_not_found"}`))
+	}))
+	defer server.Close()
+	exporter := &fakeExporter{}
+	bot := NewBot("token")
+	bot.APIURL = server.URL
+	bot.Tracer = NewTracer(exporter)
+	bot.OnEvent("message", func(b *Bot, event map[string]interface{}) (*Message, Status) {
+		b.CallContext(b.Context(event), "chat.postMessage", url.Values{})
+		return nil, Continue
+	})
+	bot.OnEventWithSubtype("message", "bot_message", func(_ *Bot, _ map[string]interface{}) (*Message, Status) {
+		panic("broken")
+	})
+
+	event := map[string]interface{}{"type": "message", "subtype": "bot_message", "channel": "C1"}
+	bot.Dispatch(event)
+
+	events := exporter.named("slack.event")
+	handlers := exporter.named("slack.handler")
+	calls := exporter.named("slack.call")
+	if len(events) != 1 || len(handlers) != 2 || len(calls) != 1 {
+		t.Fatalf("Error. Expected 1 event, 2 handler and 1 call spans. Got %v.", exporter.spans)
+	}
+	root := events[0]
+	assert(root.ParentID == "" && root.TraceID != "", t)
+	assert(root.Attributes["slack.event.type"] == "message", t)
+	assert(root.Attributes["slack.channel"] == "C1", t)
+	for _, handler := range handlers {
+		assert(handler.TraceID == root.TraceID && handler.ParentID == root.SpanID, t)
+	}
+	assert(handlers[0].Attributes["slack.event.subtype"] == "bot_message", t)
+	assert(len(handlers[0].Errors) == 1, t)
+	assert(len(handlers[1].Errors) == 0, t)
+	call := calls[0]
+	assert(call.TraceID == root.TraceID && call.ParentID == handlers[1].SpanID, t)
+	assert(call.Attributes["slack.method"] == "chat.postMessage", t)
+	assert(call.Attributes["slack.status"] == "channel_not_found", t)
+	assert(len(call.Errors) == 1 && call.Errors[0].Error() == "channel_not_found", t)
+
+	if ctx := bot.Context(event); ctx.Value(spanKey{}) != nil {
+		t.Error("Error. Expected no span once the event has been handled.")
+	}
+	if _, ok := event[contextKey]; ok || len(event) != 3 {
+		t.Errorf("Error. Expected the event to be left as it was. Got %v.", event)
+	}
+}
+
+func TestTracing_copiedEvents(t *testing.T) {
+	exporter := &fakeExporter{}
+	bot := NewBot("token")
+	bot.Tracer = NewTracer(exporter)
+	var copied context.Context
+	bot.OnEvent("message", func(b *Bot, event map[string]interface{}) (*Message, Status) {
+		copied = b.Context(copyEvent(event))
+		return nil, Continue
+	})
+
+	bot.Dispatch(map[string]interface{}{"type": "message"})
+	handlers := exporter.named("slack.handler")
+	if len(handlers) != 1 || copied.Value(spanKey{}) == nil {
+		t.Fatal("Error. Expected a copy of the event to carry the handler's context.")
+	}
+	assert(copied.Value(spanKey{}).(*span).data.SpanID == handlers[0].SpanID, t)
+
+	spoofed := map[string]interface{}{"type": "message", contextKey: "not a context"}
+	assert(bot.Context(spoofed) == context.Background(), t)
+}
+
+func TestTracing_callsWithoutContext(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"ok": true}`))
+	}))
+	defer server.Close()
+	exporter := &fakeExporter{}
+	bot := NewBot("token")
+	bot.APIURL = server.URL
+	bot.Tracer = NewTracer(exporter)
+
+	bot.Call("auth.test", url.Values{})
+	calls := exporter.named("slack.call")
+	assert(len(calls) == 1, t)
+	assert(calls[0].ParentID == "" && len(calls[0].Errors) == 0, t)
+	assert(calls[0].Attributes["slack.status"] == "ok", t)
+}
+
+func TestSpan_End(t *testing.T) {
+	exporter := &fakeExporter{}
+	_, span := NewTracer(exporter).Start(NewBot("token").Context(nil), "work")
+	span.SetAttribute("before", true)
+	span.End()
+	span.SetAttribute("after", true)
+	span.End()
+	assert(len(exporter.spans) == 1, t)
+	assert(exporter.spans[0].Attributes["before"] == true, t)
+	_, ok := exporter.spans[0].Attributes["after"]
+	assert(!ok, t)
+	assert(!exporter.spans[0].End.Before(exporter.spans[0].Start), t)
+}